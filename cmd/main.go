@@ -1,64 +1,262 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"github.com/rs/zerolog"
 
 	"github.com/vkr-mtuci/gitlab-service/config"
-	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/auth"
 	"github.com/vkr-mtuci/gitlab-service/internal/handler"
+	"github.com/vkr-mtuci/gitlab-service/internal/health"
+	"github.com/vkr-mtuci/gitlab-service/internal/idempotency"
+	"github.com/vkr-mtuci/gitlab-service/internal/metrics"
+	"github.com/vkr-mtuci/gitlab-service/internal/openapi"
+	"github.com/vkr-mtuci/gitlab-service/internal/operation"
+	"github.com/vkr-mtuci/gitlab-service/internal/release"
+	"github.com/vkr-mtuci/gitlab-service/internal/scheduler"
+	"github.com/vkr-mtuci/gitlab-service/internal/server"
 	"github.com/vkr-mtuci/gitlab-service/internal/service"
+	"github.com/vkr-mtuci/gitlab-service/internal/tracing"
 )
 
 func main() {
+	// Подкоманда проверки конфигурации: gitlab-service config validate [-config path]
+	if len(os.Args) > 2 && os.Args[1] == "config" && os.Args[2] == "validate" {
+		os.Exit(validateConfig(os.Args[3:]))
+	}
+
 	// Настроим zerolog
 	output := zerolog.ConsoleWriter{Out: os.Stdout}
 	logger := zerolog.New(output).With().Timestamp().Logger()
 
 	// Загружаем конфигурацию
-	cfg := config.LoadConfig()
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		logger.Fatal().Msgf("❌ %v", err)
+	}
 
 	// Вывод информации о запуске сервиса
 	logger.Info().Msg("📢 Запуск GitLab-сервиса...")
 
-	// Создаем клиента для GitLab
-	gitLabClient := adapter.NewGitLabClient(cfg)
+	// Настраиваем трейсинг OpenTelemetry
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.TracingExporter)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("❌ Ошибка настройки трейсинга")
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Error().Err(err).Msg("❌ Ошибка остановки трейсинга")
+		}
+	}()
+
+	// Создаем клиентов и сервисы GitLab для всех проектов
+	store := config.NewStore(cfg)
+	services := service.NewRegistry(cfg)
+
+	// Проверки готовности: токен, проект и права доступа в GitLab для каждого проекта
+	readinessChecks := health.NewRegistry(cfg.ReadinessTimeout, cfg.ReadinessCacheTTL)
+	registerReadinessChecks(readinessChecks, services)
+	healthHandler := handler.NewHealthHandler(readinessChecks)
+
+	// 🔄 Перезагрузка конфигурации без перезапуска: клиенты GitLab и проверки пересоздаются
+	reloader := config.NewReloader(store, cfg.Source)
+	reloader.OnReload(func(next *config.Config) {
+		services.Reload(next)
+		checks := health.NewRegistry(next.ReadinessTimeout, next.ReadinessCacheTTL)
+		registerReadinessChecks(checks, services)
+		readinessChecks.Replace(checks)
+	})
+	adminHandler := handler.NewAdminHandler(store, reloader)
+
+	// 🗓 Отложенные деплои: расписания хранятся в файле и переживают перезапуск
+	promotions := service.NewPromotionStore(service.PromotionHistoryLimit)
+	schedules, err := scheduler.OpenStore(cfg.Scheduler.StateFile)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("❌ Ошибка загрузки расписаний")
+	}
+	deployScheduler := scheduler.New(schedules, services, promotions, cfg.Scheduler.MaxDelay)
+
+	// Останавливаемся по SIGINT/SIGTERM, дожидаясь завершения активных запросов
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// Создаем сервис GitLab
-	gitLabService := service.NewGitLabService(gitLabClient)
+	// 📦 Планы релиза выполняются в фоне; при остановке сервис ждёт их завершения (см. ниже)
+	releasesCtx, interruptReleases := context.WithCancel(context.Background())
+	defer interruptReleases()
+	releases := release.NewManager(releasesCtx, services, cfg.JobPollInterval)
 
-	// Создаем HTTP-обработчик
-	gitLabHandler := handler.NewGitLabHandler(gitLabService)
+	// ⏳ Ожидание завершения запущенных джоб (?wait=true, ?async=true); при остановке операции дожидаются как планы релиза
+	operationsCtx, interruptOperations := context.WithCancel(context.Background())
+	defer interruptOperations()
+	operations := operation.NewManager(operationsCtx, cfg.JobPollInterval)
 
 	// Создаем приложение Fiber
-	app := fiber.New()
+	app := fiber.New(fiber.Config{
+		ErrorHandler:          handler.ErrorHandler, // Единый формат ошибок для всех маршрутов
+		ReadTimeout:           cfg.ReadTimeout,
+		WriteTimeout:          cfg.WriteTimeout,
+		IdleTimeout:           cfg.IdleTimeout,
+		BodyLimit:             cfg.BodyLimit,
+		DisableStartupMessage: true,
+	})
+
+	// 🛟 Паника в обработчике превращается в 500, а не роняет процесс
+	app.Use(recover.New(recover.Config{EnableStackTrace: true}))
+
+	// 🏷️ X-Request-ID: берём из запроса или генерируем, возвращаем в ответе
+	app.Use(requestid.New())
 
 	// 🔥 Включаем CORS
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*", // Или укажи конкретные: "http://localhost:5173"
-		AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders: "Origin, Content-Type, Accept",
+		AllowOrigins:  "*", // Или укажи конкретные: "http://localhost:5173"
+		AllowMethods:  "GET,POST,PUT,DELETE,OPTIONS",
+		AllowHeaders:  "Origin, Content-Type, Accept, " + fiber.HeaderXRequestID + ", " + idempotency.HeaderKey,
+		ExposeHeaders: fiber.HeaderXRequestID + ", " + idempotency.HeaderReplayed,
 	}))
 
-	// Проверка запуска сервиса
-	app.Get("/", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{"message": "✅ GitLab-service is running"})
-	})
+	// 🔭 Спан на каждый запрос (traceparent из заголовков продолжает внешний трейс)
+	app.Use(tracing.Middleware())
+
+	// 📊 Метрики HTTP-запросов (подключаем до маршрутов, чтобы учитывать их все)
+	app.Use(metrics.Middleware())
 
 	// ✅ Регистрируем маршруты
-	app.Get("/environments", gitLabHandler.GetEnvironments)                     // Получить список окружений
-	app.Get("/environments/:id", gitLabHandler.GetEnvironmentDetails)           // Получить детали окружения
-	app.Get("/commits/:ref/:sha", gitLabHandler.GetCommitsInBuild)              // Получить коммиты сборки
-	app.Get("/pipelines/:pipeline_id/deploy-jobs", gitLabHandler.GetDeployJobs) // Получить deploy-джобы
-	app.Post("/jobs/:job_id/play", gitLabHandler.TriggerDeployJob)              // ✅ Запуск deploy-джобы
+	server.Routes{
+		GitLab:      handler.NewGitLabHandler(services, operations),
+		V1:          handler.NewV1Handler(services, operations),
+		Promotions:  handler.NewPromotionHandler(services, promotions),
+		Schedules:   handler.NewScheduleHandler(deployScheduler),
+		Releases:    handler.NewReleaseHandler(releases),
+		Health:      healthHandler,
+		Admin:       adminHandler,
+		Metrics:     metrics.Handler(),
+		Auth:        auth.Middleware(func() config.AuthConfig { return store.Current().Auth }, handler.ErrorHandler),
+		AdminOnly:   auth.RequireAdmin(func() config.AuthConfig { return store.Current().Auth }, handler.ErrorHandler),
+		Validate:    openapi.Middleware(handler.ErrorHandler),
+		Impersonate: auth.Impersonate(func() config.ImpersonationConfig { return store.Current().Impersonation }, handler.ErrorHandler),
+		Deferred:    auth.ImpersonateDeferred(func() config.ImpersonationConfig { return store.Current().Impersonation }, handler.ErrorHandler),
+		Idempotency: idempotency.Middleware(func() config.APIConfig { return store.Current().API }, func() config.AuthConfig { return store.Current().Auth }, handler.ErrorHandler),
+		Deprecated:  handler.Deprecated(func() config.APIConfig { return store.Current().API }),
+	}.Register(app)
+
+	// Следим за файлом конфигурации и SIGHUP
+	go func() {
+		if err := reloader.Watch(ctx); err != nil {
+			logger.Error().Err(err).Msg("❌ Отслеживание изменений конфигурации недоступно")
+		}
+	}()
+
+	// Предупреждаем об истекающих токенах GitLab
+	go services.WatchTokenExpiry(ctx, func() time.Duration { return store.Current().TokenExpiryWarning })
+
+	// Выполняем наступившие расписания
+	go deployScheduler.Run(ctx, cfg.Scheduler.Interval)
 
 	// Запускаем сервер
-	logger.Info().Msgf("🚀 Сервис запущен на порту %s", cfg.ServerPort)
-	err := app.Listen(":" + cfg.ServerPort)
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- listen(app, cfg)
+	}()
+	logger.Info().Msgf("🚀 Сервис запущен на порту %s (TLS: %t)", cfg.ServerPort, cfg.TLSEnabled())
+
+	select {
+	case err := <-serverErr:
+		if err != nil {
+			logger.Fatal().Err(err).Msg("❌ Ошибка запуска сервера")
+		}
+		return
+	case <-ctx.Done():
+		logger.Info().Msgf("🛑 Получен сигнал остановки, ждём завершения активных запросов (до %s)...", cfg.ShutdownTimeout)
+	}
+
+	// Один дедлайн на всю остановку: запросы, планы релиза и операции ждут в пределах cfg.ShutdownTimeout
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancelDrain()
+
+	if err := app.ShutdownWithContext(drainCtx); err != nil {
+		logger.Error().Err(err).Msg("❌ Не все запросы завершились до истечения таймаута остановки")
+	}
+
+	// 📦 Новые планы уже не принимаются: даём выполняющимся завершиться, остальные прерываем
+	if err := releases.Drain(drainCtx); err != nil {
+		logger.Warn().Err(err).Msg("⚠️ Не все планы релиза завершились до истечения таймаута остановки, они прерваны")
+	}
+	interruptReleases()
+
+	// ⏳ Фоновые ожидания джоб (?async=true): не дождавшиеся до таймаута остановки завершаются с ошибкой
+	if err := operations.Drain(drainCtx); err != nil {
+		logger.Warn().Err(err).Msg("⚠️ Не все операции дождались джоб до истечения таймаута остановки, они прерваны")
+	}
+	interruptOperations()
+
+	logger.Info().Msg("👋 Сервис остановлен")
+}
+
+// validateConfig проверяет файл конфигурации и выводит все найденные проблемы
+func validateConfig(args []string) int {
+	flags := flag.NewFlagSet("config validate", flag.ContinueOnError)
+	path := flags.String("config", os.Getenv("CONFIG_FILE"), "путь к файлу конфигурации (YAML или TOML)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := config.Load(*path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("✅ Конфигурация корректна (%s): проектов %d\n", describeSource(cfg.Source), len(cfg.ProjectList()))
+	return 0
+}
+
+// describeSource описывает источник конфигурации для вывода
+func describeSource(source string) string {
+	if source == "" {
+		return "переменные окружения"
+	}
+	return source
+}
+
+// registerReadinessChecks регистрирует проверки GitLab для каждого проекта
+func registerReadinessChecks(checks *health.Registry, services *service.Registry) {
+	clients := services.Clients()
+	for name, client := range clients {
+		if len(clients) == 1 {
+			name = "" // Для единственного проекта имена проверок без суффикса
+		}
+		health.RegisterGitLabChecks(checks, name, client)
+	}
+}
+
+// listen запускает HTTP-сервер, а при заданных сертификате и ключе - HTTPS с перечитыванием сертификата
+func listen(app *fiber.App, cfg *config.Config) error {
+	addr := ":" + cfg.ServerPort
+	if !cfg.TLSEnabled() {
+		return app.Listen(addr)
+	}
+
+	certs, err := server.NewCertReloader(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return err
+	}
+
+	ln, err := tls.Listen("tcp", addr, certs.TLSConfig())
 	if err != nil {
-		logger.Fatal().Err(err).Msg("❌ Ошибка запуска сервера")
+		return err
 	}
+
+	return app.Listener(ln)
 }
@@ -1,12 +1,64 @@
 package config
 
 import (
-	"log"
+	"fmt"
+	"net"
 	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// Значения по умолчанию для параметров HTTP-сервера
+const (
+	DefaultServerPort      = "8080"
+	DefaultReadTimeout     = 15 * time.Second
+	DefaultWriteTimeout    = 30 * time.Second
+	DefaultIdleTimeout     = 60 * time.Second
+	DefaultShutdownTimeout = 30 * time.Second
+	DefaultBodyLimit       = 1 << 20 // 1 МБ
+)
+
+// Значения по умолчанию для обращений к GitLab
+const (
+	DefaultGitLabTimeout     = 10 * time.Second
+	DefaultReadinessTimeout  = 5 * time.Second
+	DefaultReadinessCacheTTL = 10 * time.Second
+	DefaultMaxPages          = 50 // Страниц по 100 элементов за один обход списка
+	DefaultGitLabConcurrency = 8  // Параллельных запросов к GitLab при сборе сводок
+	DefaultJobPollInterval   = 5 * time.Second
+)
+
+// Значения по умолчанию для планировщика отложенных деплоев
+const (
+	DefaultSchedulerStateFile = "schedules.json"
+	DefaultSchedulerInterval  = 15 * time.Second
+	DefaultSchedulerMaxDelay  = time.Hour
+)
+
+// DefaultIdempotencyTTL - сколько хранится ответ на запрос с Idempotency-Key
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// DefaultTokenExpiryWarning - за сколько до истечения токена GitLab начинать предупреждать
+const DefaultTokenExpiryWarning = 14 * 24 * time.Hour
+
+// Типы учётных данных GitLab
+const (
+	CredentialPersonal = "personal" // Personal/project/group access token (PRIVATE-TOKEN)
+	CredentialOAuth    = "oauth"    // OAuth2-токен приложения с обновлением по refresh token
+	CredentialJob      = "job"      // CI job token (JOB-TOKEN)
+)
+
+// DefaultProjectName - имя проекта, заданного через GITLAB_PROJECT_ID без секции projects
+const DefaultProjectName = "default"
+
+// DefaultDeployStages - шаблоны стадий с deploy-джобами, если в конфигурации не указаны другие
+var DefaultDeployStages = []string{"deploy"}
+
 // Config структура для хранения конфигурации приложения
 type Config struct {
 	ServerPort      string
@@ -15,25 +67,386 @@ type Config struct {
 	GitLabAPIToken  string
 	GitLabProjectID string
 	JiraProject     string
+	TracingExporter string // none | stdout | otlp
+
+	ReadTimeout     time.Duration // Таймаут чтения запроса
+	WriteTimeout    time.Duration // Таймаут записи ответа
+	IdleTimeout     time.Duration // Таймаут простоя keep-alive соединения
+	ShutdownTimeout time.Duration // Сколько ждать завершения активных запросов при остановке
+	BodyLimit       int           // Максимальный размер тела запроса в байтах
+	TLSCertFile     string        // Путь к сертификату (TLS включается, если заданы сертификат и ключ)
+	TLSKeyFile      string        // Путь к приватному ключу
+
+	GitLabTimeout     time.Duration // Таймаут одного запроса к GitLab API
+	ReadinessTimeout  time.Duration // Таймаут одной проверки готовности
+	ReadinessCacheTTL time.Duration // Сколько отдавать предыдущий результат проверки готовности
+
+	GitLabAPITokenFile string             // Файл с токеном GitLab (секрет Kubernetes/Docker); перечитывается при изменении
+	GitLabAPITokenType string             // Тип токена из GITLAB_API_TOKEN(_FILE): personal | job
+	GitLabCredentials  []CredentialConfig // Резервные учётные данные GitLab (после gitlab.token) для всех проектов
+	TokenExpiryWarning time.Duration      // За сколько до истечения токена предупреждать в логах
+	Pagination         PaginationConfig   // Обход списков GitLab
+	GitLabConcurrency  int                // Максимум параллельных запросов к GitLab в одной операции (сводка окружений)
+	JobPollInterval    time.Duration      // Как часто опрашивать состояние запущенной джобы при ожидании её завершения
+
+	Impersonation ImpersonationConfig // От чьего имени запускать deploy-джобы в GitLab
+	API           APIConfig           // Версии API и переходный период для старых маршрутов
+	Scheduler     SchedulerConfig     // Отложенные деплои и продвижения
+
+	DeployStages []string        // Шаблоны (glob) стадий с deploy-джобами для всех проектов
+	Projects     []ProjectConfig // Проекты GitLab; если пусто - используется GITLAB_PROJECT_ID
+	Auth         AuthConfig      // Аутентификация клиентов сервиса
+
+	Source string // Путь к файлу конфигурации ("" - только переменные окружения)
+}
+
+// ProjectConfig - настройки проекта GitLab
+type ProjectConfig struct {
+	Name          string              `yaml:"name" toml:"name"`                     // Имя проекта в API сервиса (?project=)
+	ID            string              `yaml:"id" toml:"id"`                         // ID или путь проекта в GitLab
+	Token         string              `yaml:"token" toml:"token"`                   // Токен проекта (по умолчанию gitlab.token)
+	TokenFile     string              `yaml:"token_file" toml:"token_file"`         // Файл с токеном проекта
+	Credentials   []CredentialConfig  `yaml:"credentials" toml:"credentials"`       // Учётные данные проекта по порядку: основные, затем резервные
+	JiraProject   string              `yaml:"jira_project" toml:"jira_project"`     // Ключ проекта Jira (по умолчанию integrations.jira.project)
+	DeployStages  []string            `yaml:"deploy_stages" toml:"deploy_stages"`   // Шаблоны стадий с deploy-джобами
+	Environments  []EnvironmentConfig `yaml:"environments" toml:"environments"`     // Настройки окружений (стендов)
+	PromotionPath []string            `yaml:"promotion_path" toml:"promotion_path"` // Порядок продвижения сборки по окружениям (dev → test → stage → prod)
+	AllowedRefs   []string            `yaml:"allowed_refs" toml:"allowed_refs"`     // Ветки и теги, сборки которых можно деплоить (см. MatchRef); пусто - любые
+}
+
+// PaginationConfig - обход постраничных списков GitLab
+type PaginationConfig struct {
+	MaxPages        int  `yaml:"max_pages" toml:"max_pages"`               // Максимум страниц за один обход (защита от бесконечной выгрузки)
+	DisablePrefetch bool `yaml:"disable_prefetch" toml:"disable_prefetch"` // Не загружать следующую страницу параллельно с обработкой текущей
+}
+
+// APIConfig - переходный период для неверсионированных маршрутов (до /api/v1) и идемпотентность запросов
+type APIConfig struct {
+	LegacySunset   time.Time     `yaml:"legacy_sunset" toml:"legacy_sunset"`     // Дата отключения старых маршрутов (заголовок Sunset)
+	DisableLegacy  bool          `yaml:"disable_legacy" toml:"disable_legacy"`   // Отключить старые маршруты (404 с указанием нового)
+	IdempotencyTTL time.Duration `yaml:"idempotency_ttl" toml:"idempotency_ttl"` // Сколько хранить ответ на POST с Idempotency-Key для повтора
+}
+
+// Режимы запуска джоб от имени пользователя
+const (
+	ImpersonationNone  = "none"  // От имени сервисного аккаунта
+	ImpersonationOAuth = "oauth" // С OAuth-токеном GitLab пользователя из заголовка запроса
+	ImpersonationSudo  = "sudo"  // Токеном администратора с заголовком Sudo
+)
+
+// DefaultUserTokenHeader - заголовок с OAuth-токеном GitLab пользователя
+const DefaultUserTokenHeader = "X-GitLab-Token"
+
+// ImpersonationConfig - запуск deploy-джоб от имени вызывающего пользователя,
+// чтобы GitLab записывал в аудит реального человека и проверял его права
+type ImpersonationConfig struct {
+	Mode        string            `yaml:"mode" toml:"mode"`                 // none (по умолчанию) | oauth | sudo
+	TokenHeader string            `yaml:"token_header" toml:"token_header"` // oauth: заголовок с токеном пользователя (X-GitLab-Token)
+	Required    bool              `yaml:"required" toml:"required"`         // Запрещать запуск от имени сервисного аккаунта
+	Users       map[string]string `yaml:"users" toml:"users"`               // sudo: идентичность клиента -> имя пользователя GitLab (обязательно)
+}
+
+// UserTokenHeader возвращает заголовок с OAuth-токеном пользователя
+func (i ImpersonationConfig) UserTokenHeader() string {
+	if i.TokenHeader == "" {
+		return DefaultUserTokenHeader
+	}
+	return i.TokenHeader
+}
+
+// GitLabUser возвращает имя пользователя GitLab для идентичности клиента из users.
+// Идентичность без явного сопоставления не подставляется как есть: иначе клиент мог бы
+// назваться любым пользователем GitLab, включая администраторов.
+func (i ImpersonationConfig) GitLabUser(identity string) (string, bool) {
+	username, ok := i.Users[identity]
+	return username, ok && username != ""
+}
+
+// CredentialConfig - учётные данные для доступа к GitLab API.
+// Секреты задаются значением или путём к файлу; файлы перечитываются при изменении.
+type CredentialConfig struct {
+	Type             string `yaml:"type" toml:"type"`                             // personal (по умолчанию) | oauth | job
+	Token            string `yaml:"token" toml:"token"`                           // Токен (для oauth - текущий access token, необязательно)
+	TokenFile        string `yaml:"token_file" toml:"token_file"`                 // Файл с токеном
+	ClientID         string `yaml:"client_id" toml:"client_id"`                   // OAuth: ID приложения
+	ClientSecret     string `yaml:"client_secret" toml:"client_secret"`           // OAuth: секрет приложения
+	ClientSecretFile string `yaml:"client_secret_file" toml:"client_secret_file"` // OAuth: файл с секретом приложения
+	RefreshToken     string `yaml:"refresh_token" toml:"refresh_token"`           // OAuth: refresh token
+	RefreshTokenFile string `yaml:"refresh_token_file" toml:"refresh_token_file"` // OAuth: файл с refresh token
+}
+
+// KindOrDefault возвращает тип учётных данных (personal, если не указан)
+func (c CredentialConfig) KindOrDefault() string {
+	if c.Type == "" {
+		return CredentialPersonal
+	}
+	return c.Type
 }
 
-// LoadConfig загружает переменные окружения в структуру Config
-func LoadConfig() *Config {
+// EnvironmentConfig - настройки окружения проекта
+type EnvironmentConfig struct {
+	Name          string         `yaml:"name" toml:"name"`                     // Имя окружения в GitLab
+	Locked        bool           `yaml:"locked" toml:"locked"`                 // Деплой в окружение запрещён
+	LockReason    string         `yaml:"lock_reason" toml:"lock_reason"`       // Причина блокировки
+	FreezeWindows []FreezeWindow `yaml:"freeze_windows" toml:"freeze_windows"` // Периоды заморозки деплоев
+	DeployJob     string         `yaml:"deploy_job" toml:"deploy_job"`         // Шаблон (glob) имени deploy-джобы окружения; по умолчанию - имя окружения отдельным словом в имени джобы
+	AllowedRefs   []string       `yaml:"allowed_refs" toml:"allowed_refs"`     // Ветки и теги сборок для деплоя в окружение (glob или /regexp/); по умолчанию - allowed_refs проекта
+	QualityGates  QualityGates   `yaml:"quality_gates" toml:"quality_gates"`   // Проверки качества сборки перед деплоем (защищённое окружение)
+}
+
+// QualityGates - проверки качества сборки перед запуском deploy-джобы окружения.
+// Нулевые значения отключают проверку; окружение с хотя бы одной проверкой считается защищённым.
+type QualityGates struct {
+	PipelineSuccess       bool    `yaml:"pipeline_success" toml:"pipeline_success"`             // Все джобы пайплайна вне deploy-стадий завершились успешно
+	MinTestPassRate       float64 `yaml:"min_test_pass_rate" toml:"min_test_pass_rate"`         // Минимальная доля успешных тестов в отчёте о тестах пайплайна, %
+	VulnerabilitySeverity string  `yaml:"vulnerability_severity" toml:"vulnerability_severity"` // Нет неотклонённых уязвимостей этого уровня и выше (low, medium, high, critical)
+	ProtectedBranch       bool    `yaml:"protected_branch" toml:"protected_branch"`             // Коммит сборки есть в защищённой ветке проекта
+}
+
+// Enabled проверяет, задана ли хотя бы одна проверка
+func (q QualityGates) Enabled() bool {
+	return q.PipelineSuccess || q.MinTestPassRate > 0 || q.VulnerabilitySeverity != "" || q.ProtectedBranch
+}
+
+// VulnerabilitySeverities - уровни уязвимостей GitLab по возрастанию
+var VulnerabilitySeverities = []string{"info", "unknown", "low", "medium", "high", "critical"}
+
+// SeveritiesFrom возвращает уровни уязвимостей от severity и выше
+func SeveritiesFrom(severity string) []string {
+	for i, s := range VulnerabilitySeverities {
+		if s == severity {
+			return VulnerabilitySeverities[i:]
+		}
+	}
+	return nil
+}
+
+// FreezeWindow - период, в который деплой в окружение запрещён
+type FreezeWindow struct {
+	From   time.Time `yaml:"from" toml:"from"`
+	To     time.Time `yaml:"to" toml:"to"`
+	Reason string    `yaml:"reason" toml:"reason"`
+}
+
+// MatchRef проверяет, подходит ли ветка или тег ref под один из шаблонов: glob (release/*)
+// или регулярное выражение в слэшах (/^v\d+\.\d+\.\d+$/). Некорректные шаблоны не подходят ни к чему.
+func MatchRef(patterns []string, ref string) bool {
+	for _, pattern := range patterns {
+		if expr, ok := refExpression(pattern); ok {
+			if matched, err := regexp.MatchString(expr, ref); err == nil && matched {
+				return true
+			}
+			continue
+		}
+		if matched, _ := path.Match(pattern, ref); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// refExpression возвращает регулярное выражение шаблона вида /.../; false - шаблон glob
+func refExpression(pattern string) (string, bool) {
+	if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		return pattern[1 : len(pattern)-1], true
+	}
+	return "", false
+}
+
+// ActiveFreeze возвращает окно заморозки, действующее в момент now
+func (e EnvironmentConfig) ActiveFreeze(now time.Time) (FreezeWindow, bool) {
+	for _, w := range e.FreezeWindows {
+		if !now.Before(w.From) && now.Before(w.To) {
+			return w, true
+		}
+	}
+	return FreezeWindow{}, false
+}
+
+// SchedulerConfig - планировщик отложенных деплоев (читается при запуске)
+type SchedulerConfig struct {
+	StateFile string        `yaml:"state_file" toml:"state_file"` // Файл, в котором расписания переживают перезапуск сервиса
+	Interval  time.Duration `yaml:"interval" toml:"interval"`     // Как часто проверять наступившие расписания
+	MaxDelay  time.Duration `yaml:"max_delay" toml:"max_delay"`   // Насколько запуск может опоздать (например, после простоя), прежде чем считается пропущенным
+}
+
+// AuthConfig - аутентификация клиентов сервиса
+type AuthConfig struct {
+	UserHeader     string     `yaml:"user_header" toml:"user_header"`         // Заголовок с именем пользователя от доверенного прокси (например X-Forwarded-User)
+	TrustedProxies []string   `yaml:"trusted_proxies" toml:"trusted_proxies"` // Адреса (CIDR или IP) прокси, от которых принимается user_header
+	Tokens         []APIToken `yaml:"tokens" toml:"tokens"`                   // Статические токены доступа к API сервиса
+	Admins         []string   `yaml:"admins" toml:"admins"`                   // Идентичности (имена токенов или пользователи прокси) с доступом к /admin
+}
+
+// TrustsProxy проверяет, входит ли адрес ip в trusted_proxies
+func (a AuthConfig) TrustsProxy(ip net.IP) bool {
+	for _, proxy := range a.TrustedProxies {
+		if network, err := parseNetwork(proxy); err == nil && network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseNetwork разбирает сеть в нотации CIDR или отдельный IP-адрес
+func parseNetwork(value string) (*net.IPNet, error) {
+	if !strings.Contains(value, "/") {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, fmt.Errorf("некорректный IP-адрес %q", value)
+		}
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, network, err := net.ParseCIDR(value)
+	return network, err
+}
+
+// APIToken - токен доступа к API сервиса
+type APIToken struct {
+	Name  string `yaml:"name" toml:"name"`   // Имя клиента (попадает в логи как идентичность)
+	Token string `yaml:"token" toml:"token"` // Значение токена (Authorization: Bearer <token>)
+}
+
+// Enabled возвращает true, если для API сервиса настроена аутентификация
+func (a AuthConfig) Enabled() bool {
+	return a.UserHeader != "" || len(a.Tokens) > 0
+}
+
+// IsAdmin проверяет, есть ли у идентичности клиента доступ к административным эндпоинтам
+func (a AuthConfig) IsAdmin(identity string) bool {
+	return slices.Contains(a.Admins, identity)
+}
+
+// TLSEnabled возвращает true, если сервис должен принимать соединения по TLS
+func (c *Config) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+// ProjectList возвращает проекты с подставленными значениями по умолчанию.
+// Без секции projects единственный проект строится из GITLAB_PROJECT_ID.
+func (c *Config) ProjectList() []ProjectConfig {
+	projects := c.Projects
+	if len(projects) == 0 {
+		projects = []ProjectConfig{{Name: DefaultProjectName, ID: c.GitLabProjectID}}
+	}
+
+	result := make([]ProjectConfig, len(projects))
+	for i, p := range projects {
+		p.Credentials = c.credentialsFor(p)
+		if p.Token == "" {
+			p.Token = c.GitLabAPIToken
+		}
+		if p.JiraProject == "" {
+			p.JiraProject = c.JiraProject
+		}
+		if len(p.DeployStages) == 0 {
+			p.DeployStages = c.DeployStages
+		}
+		if len(p.DeployStages) == 0 {
+			p.DeployStages = DefaultDeployStages
+		}
+		result[i] = p
+	}
+
+	return result
+}
+
+// credentialsFor возвращает цепочку учётных данных проекта: credentials проекта,
+// иначе его token/token_file, иначе общие gitlab.token/token_file и gitlab.credentials
+func (c *Config) credentialsFor(p ProjectConfig) []CredentialConfig {
+	if len(p.Credentials) > 0 {
+		return p.Credentials
+	}
+	if p.Token != "" || p.TokenFile != "" {
+		return []CredentialConfig{{Type: CredentialPersonal, Token: p.Token, TokenFile: p.TokenFile}}
+	}
+
+	var chain []CredentialConfig
+	if c.GitLabAPIToken != "" || c.GitLabAPITokenFile != "" {
+		chain = append(chain, CredentialConfig{Type: c.GitLabAPITokenType, Token: c.GitLabAPIToken, TokenFile: c.GitLabAPITokenFile})
+	}
+	return append(chain, c.GitLabCredentials...)
+}
+
+// DefaultProject возвращает проект по умолчанию: с ID из GITLAB_PROJECT_ID, иначе первый из списка
+func (c *Config) DefaultProject() ProjectConfig {
+	projects := c.ProjectList()
+	for _, p := range projects {
+		if c.GitLabProjectID != "" && p.ID == c.GitLabProjectID {
+			return p
+		}
+	}
+	return projects[0]
+}
+
+// Environment возвращает настройки окружения проекта по имени
+func (p ProjectConfig) Environment(name string) (EnvironmentConfig, bool) {
+	for _, env := range p.Environments {
+		if env.Name == name {
+			return env, true
+		}
+	}
+	return EnvironmentConfig{}, false
+}
+
+// LoadConfig загружает конфигурацию из файла CONFIG_FILE (если задан) и переменных окружения.
+// Переменные окружения переопределяют значения из файла.
+func LoadConfig() (*Config, error) {
 	_ = godotenv.Load() // Загружаем переменные окружения из .env (если файл есть)
 
-	config := &Config{
-		ServerPort:      os.Getenv("SERVER_PORT"),
-		GitLabBaseURL:   os.Getenv("GITLAB_BASE_URL"),
-		GitLabAPIURL:    os.Getenv("GITLAB_API_URL"),
-		GitLabAPIToken:  os.Getenv("GITLAB_API_TOKEN"),
-		GitLabProjectID: os.Getenv("GITLAB_PROJECT_ID"),
-		JiraProject:     os.Getenv("JIRA_PROJECT"),
+	return Load(os.Getenv("CONFIG_FILE"))
+}
+
+// Load загружает конфигурацию из указанного файла (YAML или TOML) и переменных окружения
+// и проверяет её. Все найденные проблемы возвращаются одной ошибкой *ValidationError.
+func Load(path string) (*Config, error) {
+	config := defaultConfig()
+	var problems []Problem
+
+	if path != "" {
+		file, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		file.apply(config)
+		config.Source = path
 	}
 
-	// Проверяем, заданы ли критически важные переменные
-	if config.GitLabBaseURL == "" || config.GitLabAPIURL == "" || config.GitLabAPIToken == "" || config.GitLabProjectID == "" || config.JiraProject == "" {
-		log.Fatal("❌ Ошибка: Не заданы все обязательные переменные окружения для GitLab")
+	problems = append(problems, applyEnv(config)...)
+	problems = append(problems, Validate(config)...)
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Source: config.Source, Problems: problems}
 	}
 
-	return config
+	return config, nil
+}
+
+// defaultConfig возвращает конфигурацию со значениями по умолчанию
+func defaultConfig() *Config {
+	return &Config{
+		ServerPort:         DefaultServerPort,
+		ReadTimeout:        DefaultReadTimeout,
+		WriteTimeout:       DefaultWriteTimeout,
+		IdleTimeout:        DefaultIdleTimeout,
+		ShutdownTimeout:    DefaultShutdownTimeout,
+		BodyLimit:          DefaultBodyLimit,
+		GitLabTimeout:      DefaultGitLabTimeout,
+		ReadinessTimeout:   DefaultReadinessTimeout,
+		ReadinessCacheTTL:  DefaultReadinessCacheTTL,
+		TokenExpiryWarning: DefaultTokenExpiryWarning,
+		Pagination:         PaginationConfig{MaxPages: DefaultMaxPages},
+		GitLabConcurrency:  DefaultGitLabConcurrency,
+		JobPollInterval:    DefaultJobPollInterval,
+		API:                APIConfig{IdempotencyTTL: DefaultIdempotencyTTL},
+		Scheduler: SchedulerConfig{
+			StateFile: DefaultSchedulerStateFile,
+			Interval:  DefaultSchedulerInterval,
+			MaxDelay:  DefaultSchedulerMaxDelay,
+		},
+	}
 }
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// applyEnv переопределяет конфигурацию переменными окружения; некорректные значения возвращаются как проблемы
+func applyEnv(c *Config) []Problem {
+	var problems []Problem
+
+	envString(&c.ServerPort, "SERVER_PORT")
+	envString(&c.GitLabBaseURL, "GITLAB_BASE_URL")
+	envString(&c.GitLabAPIURL, "GITLAB_API_URL")
+	envString(&c.GitLabAPIToken, "GITLAB_API_TOKEN")
+	envString(&c.GitLabAPITokenFile, "GITLAB_API_TOKEN_FILE")
+	envString(&c.GitLabAPITokenType, "GITLAB_API_TOKEN_TYPE")
+	envString(&c.Impersonation.Mode, "GITLAB_IMPERSONATION")
+	envString(&c.GitLabProjectID, "GITLAB_PROJECT_ID")
+	envString(&c.JiraProject, "JIRA_PROJECT")
+	envString(&c.TracingExporter, "TRACING_EXPORTER")
+	envString(&c.TLSCertFile, "TLS_CERT_FILE")
+	envString(&c.TLSKeyFile, "TLS_KEY_FILE")
+	envString(&c.Auth.UserHeader, "AUTH_USER_HEADER")
+	envString(&c.Scheduler.StateFile, "SCHEDULER_STATE_FILE")
+
+	if value := os.Getenv("GITLAB_DEPLOY_STAGES"); value != "" {
+		c.DeployStages = splitList(value)
+	}
+	if value := os.Getenv("AUTH_TRUSTED_PROXIES"); value != "" {
+		c.Auth.TrustedProxies = splitList(value)
+	}
+
+	problems = appendProblem(problems, envDuration(&c.ReadTimeout, "SERVER_READ_TIMEOUT"))
+	problems = appendProblem(problems, envDuration(&c.WriteTimeout, "SERVER_WRITE_TIMEOUT"))
+	problems = appendProblem(problems, envDuration(&c.IdleTimeout, "SERVER_IDLE_TIMEOUT"))
+	problems = appendProblem(problems, envDuration(&c.ShutdownTimeout, "SERVER_SHUTDOWN_TIMEOUT"))
+	problems = appendProblem(problems, envDuration(&c.GitLabTimeout, "GITLAB_TIMEOUT"))
+	problems = appendProblem(problems, envDuration(&c.ReadinessTimeout, "READINESS_TIMEOUT"))
+	problems = appendProblem(problems, envDuration(&c.ReadinessCacheTTL, "READINESS_CACHE_TTL"))
+	problems = appendProblem(problems, envDuration(&c.TokenExpiryWarning, "GITLAB_TOKEN_EXPIRY_WARNING"))
+	problems = appendProblem(problems, envInt(&c.BodyLimit, "SERVER_BODY_LIMIT"))
+	problems = appendProblem(problems, envInt(&c.Pagination.MaxPages, "GITLAB_MAX_PAGES"))
+	problems = appendProblem(problems, envBool(&c.Pagination.DisablePrefetch, "GITLAB_DISABLE_PREFETCH"))
+	problems = appendProblem(problems, envInt(&c.GitLabConcurrency, "GITLAB_CONCURRENCY"))
+	problems = appendProblem(problems, envDuration(&c.JobPollInterval, "GITLAB_JOB_POLL_INTERVAL"))
+	problems = appendProblem(problems, envTime(&c.API.LegacySunset, "API_LEGACY_SUNSET"))
+	problems = appendProblem(problems, envBool(&c.API.DisableLegacy, "API_DISABLE_LEGACY"))
+	problems = appendProblem(problems, envDuration(&c.API.IdempotencyTTL, "API_IDEMPOTENCY_TTL"))
+	problems = appendProblem(problems, envDuration(&c.Scheduler.Interval, "SCHEDULER_INTERVAL"))
+	problems = appendProblem(problems, envDuration(&c.Scheduler.MaxDelay, "SCHEDULER_MAX_DELAY"))
+
+	return problems
+}
+
+// envString переопределяет строку значением переменной окружения, если она задана
+func envString(dst *string, name string) {
+	if value := os.Getenv(name); value != "" {
+		*dst = value
+	}
+}
+
+// envDuration читает длительность (например 30s, 2m) из переменной окружения
+func envDuration(dst *time.Duration, name string) *Problem {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return &Problem{Field: "env." + name, Message: "некорректная длительность " + strconv.Quote(value)}
+	}
+	*dst = d
+	return nil
+}
+
+// envInt читает целое число из переменной окружения
+func envInt(dst *int, name string) *Problem {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return &Problem{Field: "env." + name, Message: "некорректное целое число " + strconv.Quote(value)}
+	}
+	*dst = n
+	return nil
+}
+
+// envTime читает момент времени в RFC 3339 (или дату 2006-01-02) из переменной окружения
+func envTime(dst *time.Time, name string) *Problem {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			*dst = t
+			return nil
+		}
+	}
+	return &Problem{Field: "env." + name, Message: "некорректная дата " + strconv.Quote(value) + " (ожидается RFC 3339)"}
+}
+
+// envBool читает логическое значение (true/false, 1/0) из переменной окружения
+func envBool(dst *bool, name string) *Problem {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return &Problem{Field: "env." + name, Message: "некорректное логическое значение " + strconv.Quote(value)}
+	}
+	*dst = b
+	return nil
+}
+
+// splitList разбирает список значений через запятую
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// appendProblem добавляет проблему, если она есть
+func appendProblem(problems []Problem, p *Problem) []Problem {
+	if p != nil {
+		return append(problems, *p)
+	}
+	return problems
+}
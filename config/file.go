@@ -0,0 +1,200 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// duration - длительность в файле конфигурации строкой вида "30s" или "1h30m".
+// В отличие от time.Duration разбирается одинаково из YAML и TOML.
+type duration time.Duration
+
+// UnmarshalText разбирает длительность из строки (encoding.TextUnmarshaler для YAML и TOML)
+func (d *duration) UnmarshalText(text []byte) error {
+	value, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = duration(value)
+	return nil
+}
+
+// fileConfig - схема файла конфигурации (YAML или TOML)
+type fileConfig struct {
+	Server struct {
+		Port            string   `yaml:"port" toml:"port"`
+		ReadTimeout     duration `yaml:"read_timeout" toml:"read_timeout"`
+		WriteTimeout    duration `yaml:"write_timeout" toml:"write_timeout"`
+		IdleTimeout     duration `yaml:"idle_timeout" toml:"idle_timeout"`
+		ShutdownTimeout duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+		BodyLimit       int      `yaml:"body_limit" toml:"body_limit"`
+		TLS             struct {
+			CertFile string `yaml:"cert_file" toml:"cert_file"`
+			KeyFile  string `yaml:"key_file" toml:"key_file"`
+		} `yaml:"tls" toml:"tls"`
+	} `yaml:"server" toml:"server"`
+
+	GitLab struct {
+		BaseURL            string              `yaml:"base_url" toml:"base_url"`
+		APIURL             string              `yaml:"api_url" toml:"api_url"`
+		Token              string              `yaml:"token" toml:"token"`
+		TokenFile          string              `yaml:"token_file" toml:"token_file"`
+		TokenType          string              `yaml:"token_type" toml:"token_type"`
+		Credentials        []CredentialConfig  `yaml:"credentials" toml:"credentials"`
+		TokenExpiryWarning duration            `yaml:"token_expiry_warning" toml:"token_expiry_warning"`
+		Pagination         PaginationConfig    `yaml:"pagination" toml:"pagination"`
+		Concurrency        int                 `yaml:"concurrency" toml:"concurrency"`
+		JobPollInterval    duration            `yaml:"job_poll_interval" toml:"job_poll_interval"`
+		Impersonation      ImpersonationConfig `yaml:"impersonation" toml:"impersonation"`
+		ProjectID          string              `yaml:"project_id" toml:"project_id"`
+		DeployStages       []string            `yaml:"deploy_stages" toml:"deploy_stages"`
+	} `yaml:"gitlab" toml:"gitlab"`
+
+	Timeouts struct {
+		GitLab         duration `yaml:"gitlab" toml:"gitlab"`
+		Readiness      duration `yaml:"readiness" toml:"readiness"`
+		ReadinessCache duration `yaml:"readiness_cache" toml:"readiness_cache"`
+	} `yaml:"timeouts" toml:"timeouts"`
+
+	Tracing struct {
+		Exporter string `yaml:"exporter" toml:"exporter"`
+	} `yaml:"tracing" toml:"tracing"`
+
+	Auth AuthConfig `yaml:"auth" toml:"auth"`
+
+	API struct {
+		LegacySunset   time.Time `yaml:"legacy_sunset" toml:"legacy_sunset"`
+		DisableLegacy  bool      `yaml:"disable_legacy" toml:"disable_legacy"`
+		IdempotencyTTL duration  `yaml:"idempotency_ttl" toml:"idempotency_ttl"`
+	} `yaml:"api" toml:"api"`
+
+	Scheduler struct {
+		StateFile string   `yaml:"state_file" toml:"state_file"`
+		Interval  duration `yaml:"interval" toml:"interval"`
+		MaxDelay  duration `yaml:"max_delay" toml:"max_delay"`
+	} `yaml:"scheduler" toml:"scheduler"`
+
+	Integrations struct {
+		Jira struct {
+			Project string `yaml:"project" toml:"project"`
+		} `yaml:"jira" toml:"jira"`
+	} `yaml:"integrations" toml:"integrations"`
+
+	Projects []ProjectConfig `yaml:"projects" toml:"projects"`
+}
+
+// readFile читает файл конфигурации; формат определяется по расширению
+func readFile(path string) (*fileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла конфигурации %s: %w", path, err)
+	}
+
+	// Опечатка в имени поля - ошибка, а не молча игнорируемая настройка
+	var file fileConfig
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("ошибка разбора файла конфигурации %s: %w", path, err)
+		}
+	case ".toml":
+		// TOML разбирается сразу в схему: даты (legacy_sunset, freeze_windows) остаются time.Time
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&file); err != nil {
+			var strict *toml.StrictMissingError
+			if errors.As(err, &strict) {
+				return nil, fmt.Errorf("ошибка разбора TOML %s: неизвестные поля:\n%s", path, strict.String())
+			}
+			return nil, fmt.Errorf("ошибка разбора TOML %s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("неподдерживаемый формат файла конфигурации %s (ожидается .yaml, .yml или .toml)", path)
+	}
+
+	return &file, nil
+}
+
+// apply переносит заданные в файле значения в конфигурацию
+func (f *fileConfig) apply(c *Config) {
+	setString(&c.ServerPort, f.Server.Port)
+	setDuration(&c.ReadTimeout, f.Server.ReadTimeout)
+	setDuration(&c.WriteTimeout, f.Server.WriteTimeout)
+	setDuration(&c.IdleTimeout, f.Server.IdleTimeout)
+	setDuration(&c.ShutdownTimeout, f.Server.ShutdownTimeout)
+	if f.Server.BodyLimit != 0 {
+		c.BodyLimit = f.Server.BodyLimit
+	}
+	setString(&c.TLSCertFile, f.Server.TLS.CertFile)
+	setString(&c.TLSKeyFile, f.Server.TLS.KeyFile)
+
+	setString(&c.GitLabBaseURL, f.GitLab.BaseURL)
+	setString(&c.GitLabAPIURL, f.GitLab.APIURL)
+	setString(&c.GitLabAPIToken, f.GitLab.Token)
+	setString(&c.GitLabAPITokenFile, f.GitLab.TokenFile)
+	setString(&c.GitLabAPITokenType, f.GitLab.TokenType)
+	if len(f.GitLab.Credentials) > 0 {
+		c.GitLabCredentials = f.GitLab.Credentials
+	}
+	setDuration(&c.TokenExpiryWarning, f.GitLab.TokenExpiryWarning)
+	if f.GitLab.Pagination.MaxPages != 0 {
+		c.Pagination.MaxPages = f.GitLab.Pagination.MaxPages
+	}
+	c.Pagination.DisablePrefetch = f.GitLab.Pagination.DisablePrefetch
+	if f.GitLab.Concurrency != 0 {
+		c.GitLabConcurrency = f.GitLab.Concurrency
+	}
+	setDuration(&c.JobPollInterval, f.GitLab.JobPollInterval)
+	if f.GitLab.Impersonation.Mode != "" {
+		c.Impersonation = f.GitLab.Impersonation
+	}
+	setString(&c.GitLabProjectID, f.GitLab.ProjectID)
+	if len(f.GitLab.DeployStages) > 0 {
+		c.DeployStages = f.GitLab.DeployStages
+	}
+
+	setDuration(&c.GitLabTimeout, f.Timeouts.GitLab)
+	setDuration(&c.ReadinessTimeout, f.Timeouts.Readiness)
+	setDuration(&c.ReadinessCacheTTL, f.Timeouts.ReadinessCache)
+
+	if !f.API.LegacySunset.IsZero() {
+		c.API.LegacySunset = f.API.LegacySunset
+	}
+	c.API.DisableLegacy = f.API.DisableLegacy
+	setDuration(&c.API.IdempotencyTTL, f.API.IdempotencyTTL)
+
+	setString(&c.Scheduler.StateFile, f.Scheduler.StateFile)
+	setDuration(&c.Scheduler.Interval, f.Scheduler.Interval)
+	setDuration(&c.Scheduler.MaxDelay, f.Scheduler.MaxDelay)
+
+	setString(&c.TracingExporter, f.Tracing.Exporter)
+	setString(&c.JiraProject, f.Integrations.Jira.Project)
+
+	c.Auth = f.Auth
+	c.Projects = f.Projects
+}
+
+// setString заменяет значение, если новое не пустое
+func setString(dst *string, value string) {
+	if value != "" {
+		*dst = value
+	}
+}
+
+// setDuration заменяет значение, если новое задано
+func setDuration(dst *time.Duration, value duration) {
+	if value != 0 {
+		*dst = time.Duration(value)
+	}
+}
@@ -0,0 +1,218 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+)
+
+// Источники перезагрузки конфигурации
+const (
+	TriggerFile   = "file"   // Изменился файл конфигурации
+	TriggerSignal = "signal" // Получен SIGHUP
+	TriggerAPI    = "api"    // Запрос к административному эндпоинту
+)
+
+// Итоги перезагрузки
+const (
+	ReloadApplied  = "applied"  // Новая конфигурация применена
+	ReloadRejected = "rejected" // Конфигурация некорректна, действует прежняя
+)
+
+// reloadDebounce - пауза после последнего события файловой системы перед перезагрузкой
+// (редакторы и Kubernetes меняют файл в несколько шагов)
+const reloadDebounce = 500 * time.Millisecond
+
+// reloadHistorySize - сколько последних перезагрузок хранить для административного эндпоинта
+const reloadHistorySize = 20
+
+// ReloadResult - итог одной перезагрузки конфигурации
+type ReloadResult struct {
+	Time            time.Time `json:"time"`
+	Trigger         string    `json:"trigger"`
+	Status          string    `json:"status"`
+	Error           string    `json:"error,omitempty"`
+	Problems        []Problem `json:"problems,omitempty"`
+	Projects        []string  `json:"projects,omitempty"`
+	RestartRequired []string  `json:"restart_required,omitempty"` // Изменённые параметры, вступающие в силу только после перезапуска
+}
+
+// Reloader перечитывает файл конфигурации по изменению файла, SIGHUP или запросу
+// и атомарно подменяет конфигурацию в Store. Некорректная конфигурация отклоняется.
+type Reloader struct {
+	store *Store
+	path  string
+
+	mu        sync.Mutex // Перезагрузки выполняются по одной
+	listeners []func(*Config)
+	history   []ReloadResult
+}
+
+// NewReloader создаёт перезагрузчик для файла path ("" - только переменные окружения)
+func NewReloader(store *Store, path string) *Reloader {
+	return &Reloader{store: store, path: path}
+}
+
+// OnReload регистрирует функцию, вызываемую с новой конфигурацией после её применения
+// (например, пересоздание клиентов GitLab)
+func (r *Reloader) OnReload(fn func(*Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.listeners = append(r.listeners, fn)
+}
+
+// Reload перечитывает конфигурацию и применяет её, если она корректна
+func (r *Reloader) Reload(trigger string) ReloadResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := ReloadResult{Time: time.Now().UTC(), Trigger: trigger}
+
+	cfg, err := Load(r.path)
+	if err != nil {
+		result.Status = ReloadRejected
+		result.Error = err.Error()
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			result.Problems = validationErr.Problems
+		}
+		log.Error().Str("trigger", trigger).Msgf("❌ Новая конфигурация отклонена, действует прежняя: %v", err)
+		r.record(result)
+		return result
+	}
+
+	previous := r.store.Swap(cfg)
+	for _, fn := range r.listeners {
+		fn(cfg)
+	}
+
+	result.Status = ReloadApplied
+	for _, project := range cfg.ProjectList() {
+		result.Projects = append(result.Projects, project.Name)
+	}
+	result.RestartRequired = restartRequired(previous, cfg)
+
+	log.Info().Str("trigger", trigger).Strs("projects", result.Projects).Msg("🔄 Конфигурация перезагружена")
+	if len(result.RestartRequired) > 0 {
+		log.Warn().Strs("fields", result.RestartRequired).Msg("⚠️ Часть изменений вступит в силу только после перезапуска")
+	}
+
+	r.record(result)
+	return result
+}
+
+// History возвращает последние перезагрузки, начиная с самой свежей
+func (r *Reloader) History() []ReloadResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	history := make([]ReloadResult, len(r.history))
+	for i, result := range r.history {
+		history[len(r.history)-1-i] = result
+	}
+	return history
+}
+
+// record сохраняет итог перезагрузки, ограничивая размер истории
+func (r *Reloader) record(result ReloadResult) {
+	r.history = append(r.history, result)
+	if len(r.history) > reloadHistorySize {
+		r.history = r.history[len(r.history)-reloadHistorySize:]
+	}
+}
+
+// Watch перезагружает конфигурацию по SIGHUP и изменению файла, пока не отменён ctx
+func (r *Reloader) Watch(ctx context.Context) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var events <-chan fsnotify.Event
+	var watchErrors <-chan error
+	if r.path != "" {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return err
+		}
+		defer watcher.Close()
+
+		// Следим за каталогом: файл могут заменить переименованием (редакторы, ConfigMap в Kubernetes)
+		if err := watcher.Add(filepath.Dir(r.path)); err != nil {
+			return err
+		}
+		events, watchErrors = watcher.Events, watcher.Errors
+	}
+
+	// Таймер откладывает перезагрузку до окончания серии событий
+	debounce := time.NewTimer(time.Hour)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			r.Reload(TriggerSignal)
+		case event := <-events:
+			if r.affects(event) {
+				debounce.Reset(reloadDebounce)
+			}
+		case err := <-watchErrors:
+			log.Error().Err(err).Msg("❌ Ошибка отслеживания файла конфигурации")
+		case <-debounce.C:
+			r.Reload(TriggerFile)
+		}
+	}
+}
+
+// affects сообщает, относится ли событие к файлу конфигурации
+func (r *Reloader) affects(event fsnotify.Event) bool {
+	if event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write) {
+		return false
+	}
+	name := filepath.Base(event.Name)
+	// ..data - символическая ссылка, которую Kubernetes переключает при обновлении ConfigMap
+	return filepath.Clean(event.Name) == filepath.Clean(r.path) || name == "..data"
+}
+
+// restartRequired перечисляет изменённые параметры, которые применяются только при запуске
+func restartRequired(previous, next *Config) []string {
+	if previous == nil {
+		return nil
+	}
+
+	fields := []struct {
+		name       string
+		prev, next any
+	}{
+		{"server.port", previous.ServerPort, next.ServerPort},
+		{"server.read_timeout", previous.ReadTimeout, next.ReadTimeout},
+		{"server.write_timeout", previous.WriteTimeout, next.WriteTimeout},
+		{"server.idle_timeout", previous.IdleTimeout, next.IdleTimeout},
+		{"server.shutdown_timeout", previous.ShutdownTimeout, next.ShutdownTimeout},
+		{"server.body_limit", previous.BodyLimit, next.BodyLimit},
+		{"server.tls", [2]string{previous.TLSCertFile, previous.TLSKeyFile}, [2]string{next.TLSCertFile, next.TLSKeyFile}},
+		{"timeouts.readiness", previous.ReadinessTimeout, next.ReadinessTimeout},
+		{"timeouts.readiness_cache", previous.ReadinessCacheTTL, next.ReadinessCacheTTL},
+		{"tracing.exporter", previous.TracingExporter, next.TracingExporter},
+	}
+
+	var changed []string
+	for _, f := range fields {
+		if !reflect.DeepEqual(f.prev, f.next) {
+			changed = append(changed, f.name)
+		}
+	}
+	return changed
+}
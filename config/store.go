@@ -0,0 +1,25 @@
+package config
+
+import "sync/atomic"
+
+// Store - текущая конфигурация с атомарной заменой при перезагрузке
+type Store struct {
+	current atomic.Pointer[Config]
+}
+
+// NewStore создаёт хранилище с начальной конфигурацией
+func NewStore(cfg *Config) *Store {
+	s := &Store{}
+	s.current.Store(cfg)
+	return s
+}
+
+// Current возвращает действующую конфигурацию; её нельзя изменять
+func (s *Store) Current() *Config {
+	return s.current.Load()
+}
+
+// Swap заменяет конфигурацию и возвращает предыдущую
+func (s *Store) Swap(cfg *Config) *Config {
+	return s.current.Swap(cfg)
+}
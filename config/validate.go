@@ -0,0 +1,328 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Поддерживаемые экспортёры трейсов (см. internal/tracing)
+var tracingExporters = []string{"", "none", "stdout", "otlp"}
+
+// Problem - одна проблема конфигурации с путём к полю
+type Problem struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError - все проблемы конфигурации, найденные при проверке
+type ValidationError struct {
+	Source   string
+	Problems []Problem
+}
+
+// Error реализует интерфейс error
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	if e.Source != "" {
+		fmt.Fprintf(&b, "некорректная конфигурация %s:", e.Source)
+	} else {
+		b.WriteString("некорректная конфигурация:")
+	}
+	for _, p := range e.Problems {
+		fmt.Fprintf(&b, "\n  - %s: %s", p.Field, p.Message)
+	}
+	return b.String()
+}
+
+// Validate проверяет конфигурацию и возвращает все найденные проблемы
+func Validate(c *Config) []Problem {
+	v := &validator{}
+
+	// Сервер
+	v.require("server.port (SERVER_PORT)", c.ServerPort)
+	v.positive("server.read_timeout", int64(c.ReadTimeout))
+	v.positive("server.write_timeout", int64(c.WriteTimeout))
+	v.positive("server.idle_timeout", int64(c.IdleTimeout))
+	v.positive("server.shutdown_timeout", int64(c.ShutdownTimeout))
+	v.positive("server.body_limit", int64(c.BodyLimit))
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		v.add("server.tls", "cert_file и key_file должны задаваться вместе")
+	}
+
+	// GitLab
+	v.require("gitlab.base_url (GITLAB_BASE_URL)", c.GitLabBaseURL)
+	if c.GitLabBaseURL != "" {
+		if u, err := url.Parse(c.GitLabBaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.add("gitlab.base_url", "ожидается URL вида https://gitlab.example.com")
+		}
+	}
+	v.require("gitlab.api_url (GITLAB_API_URL)", c.GitLabAPIURL)
+	v.positive("timeouts.gitlab", int64(c.GitLabTimeout))
+	v.positive("gitlab.pagination.max_pages (GITLAB_MAX_PAGES)", int64(c.Pagination.MaxPages))
+	v.positive("gitlab.concurrency (GITLAB_CONCURRENCY)", int64(c.GitLabConcurrency))
+	v.positive("gitlab.job_poll_interval (GITLAB_JOB_POLL_INTERVAL)", int64(c.JobPollInterval))
+	v.positive("timeouts.readiness", int64(c.ReadinessTimeout))
+	v.stagePatterns("gitlab.deploy_stages", c.DeployStages)
+
+	// API
+	v.positive("api.idempotency_ttl (API_IDEMPOTENCY_TTL)", int64(c.API.IdempotencyTTL))
+
+	// Планировщик
+	v.require("scheduler.state_file (SCHEDULER_STATE_FILE)", c.Scheduler.StateFile)
+	v.positive("scheduler.interval (SCHEDULER_INTERVAL)", int64(c.Scheduler.Interval))
+	v.positive("scheduler.max_delay (SCHEDULER_MAX_DELAY)", int64(c.Scheduler.MaxDelay))
+
+	if !contains(tracingExporters, c.TracingExporter) {
+		v.add("tracing.exporter (TRACING_EXPORTER)", fmt.Sprintf("неизвестный экспортёр %q (ожидается none, stdout или otlp)", c.TracingExporter))
+	}
+
+	// Проекты
+	if len(c.Projects) == 0 {
+		v.require("gitlab.project_id (GITLAB_PROJECT_ID)", c.GitLabProjectID)
+		if c.GitLabAPIToken == "" && c.GitLabAPITokenFile == "" && len(c.GitLabCredentials) == 0 {
+			v.add("gitlab.token (GITLAB_API_TOKEN)", "обязательное поле не задано (или gitlab.token_file, gitlab.credentials)")
+		}
+		v.require("integrations.jira.project (JIRA_PROJECT)", c.JiraProject)
+	}
+
+	names := map[string]bool{}
+	for i, p := range c.Projects {
+		field := fmt.Sprintf("projects[%d]", i)
+		v.require(field+".name", p.Name)
+		v.require(field+".id", p.ID)
+		if p.Name != "" && names[p.Name] {
+			v.add(field+".name", fmt.Sprintf("проект %q уже описан", p.Name))
+		}
+		names[p.Name] = true
+
+		if len(c.credentialsFor(p)) == 0 {
+			v.add(field+".token", "не задан токен ни для проекта, ни в gitlab.token")
+		}
+		if p.Token != "" && p.TokenFile != "" {
+			v.add(field+".token_file", "token и token_file взаимоисключающие")
+		}
+		v.credentials(field+".credentials", p.Credentials)
+		if p.JiraProject == "" && c.JiraProject == "" {
+			v.add(field+".jira_project", "не задан ключ Jira ни для проекта, ни в integrations.jira.project")
+		}
+		v.stagePatterns(field+".deploy_stages", p.DeployStages)
+		v.environments(field+".environments", p.Environments)
+		v.promotionPath(field+".promotion_path", p.PromotionPath)
+		v.refPatterns(field+".allowed_refs", p.AllowedRefs)
+	}
+
+	// Учётные данные GitLab
+	if c.GitLabAPIToken != "" && c.GitLabAPITokenFile != "" {
+		v.add("gitlab.token_file (GITLAB_API_TOKEN_FILE)", "token и token_file взаимоисключающие")
+	}
+	if c.GitLabAPITokenType != "" && c.GitLabAPITokenType != CredentialPersonal && c.GitLabAPITokenType != CredentialJob {
+		v.add("gitlab.token_type (GITLAB_API_TOKEN_TYPE)", fmt.Sprintf("неизвестный тип токена %q (ожидается personal или job)", c.GitLabAPITokenType))
+	}
+	v.secretFile("gitlab.token_file (GITLAB_API_TOKEN_FILE)", c.GitLabAPITokenFile)
+	v.credentials("gitlab.credentials", c.GitLabCredentials)
+	v.positive("gitlab.token_expiry_warning", int64(c.TokenExpiryWarning))
+
+	switch c.Impersonation.Mode {
+	case "", ImpersonationNone, ImpersonationOAuth:
+	case ImpersonationSudo:
+		if !c.Auth.Enabled() {
+			v.add("gitlab.impersonation.mode", "для sudo нужна аутентификация клиентов (auth), иначе пользователь неизвестен")
+		}
+		if len(c.Impersonation.Users) == 0 {
+			v.add("gitlab.impersonation.users", "для sudo нужно сопоставление идентичностей клиентов пользователям GitLab")
+		}
+		for identity, username := range c.Impersonation.Users {
+			v.require(fmt.Sprintf("gitlab.impersonation.users[%s]", identity), username)
+		}
+	default:
+		v.add("gitlab.impersonation.mode (GITLAB_IMPERSONATION)", fmt.Sprintf("неизвестный режим %q (ожидается none, oauth или sudo)", c.Impersonation.Mode))
+	}
+
+	// Аутентификация
+	tokens := map[string]bool{}
+	for i, t := range c.Auth.Tokens {
+		field := fmt.Sprintf("auth.tokens[%d]", i)
+		v.require(field+".name", t.Name)
+		v.require(field+".token", t.Token)
+		if t.Token != "" && tokens[t.Token] {
+			v.add(field+".token", "токен уже используется другим клиентом")
+		}
+		tokens[t.Token] = true
+	}
+	if c.Auth.UserHeader != "" && len(c.Auth.TrustedProxies) == 0 {
+		v.add("auth.trusted_proxies (AUTH_TRUSTED_PROXIES)", "заголовок user_header принимается только от доверенных прокси: укажите их адреса")
+	}
+	for i, proxy := range c.Auth.TrustedProxies {
+		if _, err := parseNetwork(proxy); err != nil {
+			v.add(fmt.Sprintf("auth.trusted_proxies[%d]", i), fmt.Sprintf("ожидается CIDR или IP-адрес: %v", err))
+		}
+	}
+	if len(c.Auth.Admins) > 0 && !c.Auth.Enabled() {
+		v.add("auth.admins", "администраторы задаются только вместе с аутентификацией клиентов (auth.tokens или auth.user_header)")
+	}
+	for i, admin := range c.Auth.Admins {
+		v.require(fmt.Sprintf("auth.admins[%d]", i), admin)
+	}
+
+	return v.problems
+}
+
+// validator накапливает проблемы конфигурации
+type validator struct {
+	problems []Problem
+}
+
+// add добавляет проблему
+func (v *validator) add(field, message string) {
+	v.problems = append(v.problems, Problem{Field: field, Message: message})
+}
+
+// require проверяет, что значение задано
+func (v *validator) require(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(field, "обязательное поле не задано")
+	}
+}
+
+// positive проверяет, что число положительное
+func (v *validator) positive(field string, value int64) {
+	if value <= 0 {
+		v.add(field, "значение должно быть больше нуля")
+	}
+}
+
+// stagePatterns проверяет синтаксис glob-шаблонов стадий
+func (v *validator) stagePatterns(field string, patterns []string) {
+	for i, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			v.add(fmt.Sprintf("%s[%d]", field, i), fmt.Sprintf("некорректный шаблон стадии %q", pattern))
+		}
+	}
+}
+
+// refPatterns проверяет синтаксис шаблонов веток и тегов: glob или /регулярное выражение/
+func (v *validator) refPatterns(field string, patterns []string) {
+	for i, pattern := range patterns {
+		var err error
+		if expr, ok := refExpression(pattern); ok {
+			_, err = regexp.Compile(expr)
+		} else {
+			_, err = path.Match(pattern, "")
+		}
+		if err != nil || pattern == "" {
+			v.add(fmt.Sprintf("%s[%d]", field, i), fmt.Sprintf("некорректный шаблон ветки %q", pattern))
+		}
+	}
+}
+
+// credentials проверяет учётные данные GitLab
+func (v *validator) credentials(field string, creds []CredentialConfig) {
+	for i, cred := range creds {
+		credField := fmt.Sprintf("%s[%d]", field, i)
+		v.secret(credField+".token", cred.Token, cred.TokenFile)
+
+		switch cred.KindOrDefault() {
+		case CredentialPersonal, CredentialJob:
+			if cred.Token == "" && cred.TokenFile == "" {
+				v.add(credField+".token", "необходимо указать token или token_file")
+			}
+		case CredentialOAuth:
+			v.require(credField+".client_id", cred.ClientID)
+			v.secret(credField+".client_secret", cred.ClientSecret, cred.ClientSecretFile)
+			v.secret(credField+".refresh_token", cred.RefreshToken, cred.RefreshTokenFile)
+			if cred.ClientSecret == "" && cred.ClientSecretFile == "" {
+				v.add(credField+".client_secret", "необходимо указать client_secret или client_secret_file")
+			}
+			if cred.RefreshToken == "" && cred.RefreshTokenFile == "" {
+				v.add(credField+".refresh_token", "необходимо указать refresh_token или refresh_token_file")
+			}
+		default:
+			v.add(credField+".type", fmt.Sprintf("неизвестный тип %q (ожидается personal, oauth или job)", cred.Type))
+		}
+	}
+}
+
+// secret проверяет секрет, заданный значением или файлом
+func (v *validator) secret(field, value, file string) {
+	if value != "" && file != "" {
+		v.add(field, "значение и файл взаимоисключающие")
+	}
+	v.secretFile(field+"_file", file)
+}
+
+// secretFile проверяет, что файл с секретом доступен для чтения
+func (v *validator) secretFile(field, file string) {
+	if file == "" {
+		return
+	}
+	if _, err := os.Stat(file); err != nil {
+		v.add(field, fmt.Sprintf("файл недоступен: %v", err))
+	}
+}
+
+// environments проверяет настройки окружений проекта
+func (v *validator) environments(field string, envs []EnvironmentConfig) {
+	names := map[string]bool{}
+	for i, env := range envs {
+		envField := fmt.Sprintf("%s[%d]", field, i)
+		v.require(envField+".name", env.Name)
+		if env.Name != "" && names[env.Name] {
+			v.add(envField+".name", fmt.Sprintf("окружение %q уже описано", env.Name))
+		}
+		names[env.Name] = true
+
+		if env.DeployJob != "" {
+			if _, err := path.Match(env.DeployJob, ""); err != nil {
+				v.add(envField+".deploy_job", fmt.Sprintf("некорректный шаблон джобы %q", env.DeployJob))
+			}
+		}
+		v.refPatterns(envField+".allowed_refs", env.AllowedRefs)
+		v.qualityGates(envField+".quality_gates", env.QualityGates)
+
+		for j, w := range env.FreezeWindows {
+			windowField := fmt.Sprintf("%s.freeze_windows[%d]", envField, j)
+			if w.From.IsZero() || w.To.IsZero() {
+				v.add(windowField, "необходимо указать from и to")
+			} else if !w.To.After(w.From) {
+				v.add(windowField, "to должно быть позже from")
+			}
+		}
+	}
+}
+
+// qualityGates проверяет пороги проверок качества
+func (v *validator) qualityGates(field string, gates QualityGates) {
+	if gates.MinTestPassRate < 0 || gates.MinTestPassRate > 100 {
+		v.add(field+".min_test_pass_rate", "должно быть от 0 до 100")
+	}
+	if gates.VulnerabilitySeverity != "" && !contains(VulnerabilitySeverities, gates.VulnerabilitySeverity) {
+		v.add(field+".vulnerability_severity", fmt.Sprintf("допустимые значения: %s", strings.Join(VulnerabilitySeverities, ", ")))
+	}
+}
+
+// promotionPath проверяет порядок продвижения: окружения не повторяются
+func (v *validator) promotionPath(field string, envs []string) {
+	seen := map[string]bool{}
+	for i, env := range envs {
+		if env == "" {
+			v.add(fmt.Sprintf("%s[%d]", field, i), "пустое имя окружения")
+		} else if seen[env] {
+			v.add(fmt.Sprintf("%s[%d]", field, i), fmt.Sprintf("окружение %q уже есть в пути продвижения", env))
+		}
+		seen[env] = true
+	}
+}
+
+// contains проверяет наличие строки в списке
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package adapter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/vkr-mtuci/gitlab-service/internal/metrics"
+)
+
+// cacheEntry - значение в кэше со сроком жизни
+type cacheEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// ttlCache - потокобезопасный кэш с ограничением по времени жизни и количеству записей
+type ttlCache[V any] struct {
+	name    string
+	ttl     time.Duration
+	maxSize int
+
+	mu    sync.Mutex
+	items map[string]cacheEntry[V]
+}
+
+// newTTLCache создаёт кэш; name используется в метриках попаданий
+func newTTLCache[V any](name string, ttl time.Duration, maxSize int) *ttlCache[V] {
+	return &ttlCache[V]{
+		name:    name,
+		ttl:     ttl,
+		maxSize: maxSize,
+		items:   make(map[string]cacheEntry[V]),
+	}
+}
+
+// Get возвращает значение, если оно есть и не устарело
+func (c *ttlCache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	entry, ok := c.items[key]
+	if ok && time.Now().After(entry.expiresAt) {
+		delete(c.items, key)
+		ok = false
+	}
+	c.mu.Unlock()
+
+	metrics.ObserveCache(c.name, ok)
+	return entry.value, ok
+}
+
+// Set сохраняет значение; при переполнении сначала удаляет устаревшие записи, затем произвольную
+func (c *ttlCache[V]) Set(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.items) >= c.maxSize {
+		now := time.Now()
+		for k, entry := range c.items {
+			if now.After(entry.expiresAt) {
+				delete(c.items, k)
+			}
+		}
+		for k := range c.items {
+			if len(c.items) < c.maxSize {
+				break
+			}
+			delete(c.items, k)
+		}
+	}
+
+	c.items[key] = cacheEntry[V]{value: value, expiresAt: time.Now().Add(c.ttl)}
+}
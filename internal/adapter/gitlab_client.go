@@ -3,8 +3,11 @@ package adapter
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"path"
 	"regexp"
 	"strings"
 	"time"
@@ -12,6 +15,10 @@ import (
 	"github.com/go-resty/resty/v2"
 	"github.com/rs/zerolog/log"
 	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/credentials"
+	"github.com/vkr-mtuci/gitlab-service/internal/metrics"
+	"github.com/vkr-mtuci/gitlab-service/internal/tracing"
 )
 
 // GitLabClientInterface - интерфейс для моков
@@ -20,68 +27,119 @@ type GitLabClientInterface interface {
 	GetEnvironmentDetails(ctx context.Context, environmentID string) (*DeploymentInfo, error)
 	GetPreviousPipelineSHA(ctx context.Context, ref, currentSHA string) (string, error)
 	GetCommitsBetweenSHAs(ctx context.Context, ref, fromSHA, toSHA string) ([]CommitInfo, error)
+	CompareCommits(ctx context.Context, fromSHA, toSHA string) ([]CommitInfo, error)
 	GetPipelineJobs(ctx context.Context, pipelineID string) ([]JobInfo, error)
+	GetProjectJobs(ctx context.Context, scopes ...string) ([]JobInfo, error)
 	TriggerDeployJob(ctx context.Context, jobID string) (*TriggeredJob, error) // ✅ Новый метод
+	GetJob(ctx context.Context, jobID string) (*TriggeredJob, error)
+	GetJobTrace(ctx context.Context, jobID string) (string, error)
+	GetBuildVersion(ctx context.Context, jobID string) (string, error)
+	GetProject(ctx context.Context) (*Project, error)
+	RetryJob(ctx context.Context, jobID string) (*TriggeredJob, error)
+	GetLatestPipeline(ctx context.Context, ref string) (*Pipeline, error)
+	GetPipelineBuildJobs(ctx context.Context, pipelineID string) ([]JobInfo, error)
+	GetTestReportSummary(ctx context.Context, pipelineID string) (*TestReportSummary, error)
+	GetVulnerabilityFindings(ctx context.Context, pipelineID string, severities []string) ([]VulnerabilityFinding, error)
+	GetCommitBranches(ctx context.Context, sha string) ([]string, error)
+	GetProtectedBranches(ctx context.Context) ([]ProtectedBranch, error)
+	GetCommitMergeRequests(ctx context.Context, sha string) ([]MergeRequest, error)
+	GetMergeRequestApprovers(ctx context.Context, iid int) ([]User, error)
 }
 
 // Убедимся, что GitLabClient реализует интерфейс GitLabClientInterface
 var _ GitLabClientInterface = (*GitLabClient)(nil)
 
+// Параметры кэша BUILD_VERSION: лог завершённой джобы не меняется, поэтому версию можно хранить долго
+const (
+	buildVersionCacheTTL  = 24 * time.Hour
+	buildVersionCacheSize = 1000
+)
+
 // GitLabClient - клиент для взаимодействия с API GitLab
 type GitLabClient struct {
-	client      *resty.Client
-	baseURL     string
-	apiURL      string
-	projectID   string
-	jiraProject string
+	client        *resty.Client
+	credentials   *credentials.Pool // Учётные данные проекта
+	baseURL       string
+	apiURL        string
+	projectID     string
+	jiraProject   string
+	deployStages  []string          // Шаблоны (glob) стадий с deploy-джобами
+	buildVersions *ttlCache[string] // BUILD_VERSION по jobID
+	pagination    config.PaginationConfig
 }
 
-// NewGitLabClient - создание нового клиента для GitLab
+// NewGitLabClient - создание нового клиента для GitLab (для проекта по умолчанию)
 func NewGitLabClient(cfg *config.Config) *GitLabClient {
+	return NewProjectClient(cfg, cfg.DefaultProject())
+}
+
+// NewProjectClient - создание клиента GitLab для указанного проекта
+func NewProjectClient(cfg *config.Config, project config.ProjectConfig) *GitLabClient {
+	timeout := cfg.GitLabTimeout
+	if timeout == 0 {
+		timeout = config.DefaultGitLabTimeout
+	}
+
 	client := resty.New().
 		SetBaseURL(cfg.GitLabBaseURL).
-		SetTimeout(10*time.Second).
-		SetHeader("Accept", "application/json").
-		SetHeader("PRIVATE-TOKEN", cfg.GitLabAPIToken) // ✅ Авторизация через PRIVATE-TOKEN
+		SetTimeout(timeout).
+		SetHeader("Accept", "application/json")
 
-	log.Info().Msg("🔗 Подключение к GitLab API: " + cfg.GitLabBaseURL)
+	// 🔑 Авторизация: PRIVATE-TOKEN, JOB-TOKEN или OAuth, с переключением на резервные данные при 401
+	pool := credentials.NewPool(cfg.GitLabBaseURL, project.Name, project.Credentials)
+	pool.Instrument(client)
 
-	return &GitLabClient{
-		client:      client,
-		baseURL:     cfg.GitLabBaseURL,
-		apiURL:      cfg.GitLabAPIURL,
-		projectID:   cfg.GitLabProjectID,
-		jiraProject: cfg.JiraProject,
-	}
-}
+	tracing.InstrumentClient(client) // 🔭 Спаны и проброс traceparent в GitLab
+	metrics.InstrumentClient(client) // 📊 Метрики запросов к GitLab API
 
-// GetEnvironments - получает список окружений для указанного проекта
-func (g *GitLabClient) GetEnvironments(ctx context.Context) ([]Environment, error) {
-	if g.projectID == "" {
-		return nil, fmt.Errorf("❌ projectID не может быть пустым")
+	pagination := cfg.Pagination
+	if pagination.MaxPages == 0 {
+		pagination.MaxPages = config.DefaultMaxPages
 	}
 
-	url := fmt.Sprintf("%s%s%s/environments", g.baseURL, g.apiURL, g.projectID)
-	log.Debug().Msgf("📡 Запрос окружений GitLab: projectID=%s, URL=%s", g.projectID, url)
+	log.Info().Msgf("🔗 Подключение к GitLab API: %s, проект %s (%s)", cfg.GitLabBaseURL, project.Name, project.ID)
 
-	resp, err := g.client.R().
-		SetContext(ctx).
-		Get(url)
+	return &GitLabClient{
+		client:        client,
+		credentials:   pool,
+		baseURL:       cfg.GitLabBaseURL,
+		apiURL:        cfg.GitLabAPIURL,
+		projectID:     project.ID,
+		jiraProject:   project.JiraProject,
+		deployStages:  project.DeployStages,
+		buildVersions: newTTLCache[string]("build_version", buildVersionCacheTTL, buildVersionCacheSize),
+		pagination:    pagination,
+	}
+}
 
-	if err != nil {
-		log.Error().Err(err).Msg("❌ Ошибка запроса окружений GitLab")
-		return nil, err
+// pageOptions возвращает параметры обхода списка name по конфигурации клиента
+func (g *GitLabClient) pageOptions(name string) PageOptions {
+	return PageOptions{
+		Name:     name,
+		PerPage:  listPerPage,
+		MaxPages: g.pagination.MaxPages,
+		Prefetch: !g.pagination.DisablePrefetch,
 	}
+}
 
-	if resp.StatusCode() != http.StatusOK {
-		log.Warn().Msgf("⚠️ GitLab вернул статус %d", resp.StatusCode())
-		return nil, ParseGitLabError(resp.Body()) // Теперь функция используется!
+// projectURL возвращает адрес ресурса проекта
+func (g *GitLabClient) projectURL(resource string) string {
+	return fmt.Sprintf("%s%s%s%s", g.baseURL, g.apiURL, g.projectID, resource)
+}
+
+// GetEnvironments - получает список окружений для указанного проекта
+func (g *GitLabClient) GetEnvironments(ctx context.Context) ([]Environment, error) {
+	if g.projectID == "" {
+		return nil, apperror.Validation("projectID не может быть пустым")
 	}
 
-	// Распарсим JSON-ответ
+	// Выгружаем все страницы: GitLab по умолчанию отдаёт только первые 20 окружений
 	var environments []Environment
-	if err := json.Unmarshal(resp.Body(), &environments); err != nil {
-		log.Error().Err(err).Msg("❌ Ошибка парсинга окружений GitLab")
+	_, err := Paginate(ctx, g.client, g.projectURL("/environments"), nil, g.pageOptions("environments"), func(items []Environment) bool {
+		environments = append(environments, items...)
+		return true
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -89,27 +147,65 @@ func (g *GitLabClient) GetEnvironments(ctx context.Context) ([]Environment, erro
 	return environments, nil
 }
 
-// ParseGitLabError - обрабатывает тело ответа с ошибкой от GitLab API
-func ParseGitLabError(body []byte) error {
-	var gitlabErr GitLabError
+// ParseGitLabError - обрабатывает ответ GitLab API с ошибкой и приводит его к типизированной ошибке
+func ParseGitLabError(statusCode int, body []byte) error {
+	gitlabErr := &GitLabError{StatusCode: statusCode}
 
 	// ✅ Проверяем, пустое ли тело
 	if len(body) == 0 {
-		return fmt.Errorf("GitLab API вернул пустой ответ")
+		gitlabErr.Message = http.StatusText(statusCode)
+		return apperror.FromStatus(statusCode, gitlabErr)
 	}
 
-	// ✅ Если парсинг JSON неудачен, возвращаем тело как строку
-	if err := json.Unmarshal(body, &gitlabErr); err != nil {
-		return fmt.Errorf("GitLab API: %s", string(body))
+	// GitLab отдаёт либо {"message": ...} (строка или объект с ошибками полей), либо {"error": ...}
+	var payload struct {
+		Message          json.RawMessage `json:"message"`
+		Error            string          `json:"error"`
+		ErrorDescription string          `json:"error_description"`
 	}
 
-	return &gitlabErr
+	// ✅ Если парсинг JSON неудачен, используем тело как строку
+	if err := json.Unmarshal(body, &payload); err != nil {
+		gitlabErr.Message = strings.TrimSpace(string(body))
+		return apperror.FromStatus(statusCode, gitlabErr)
+	}
+
+	switch {
+	case len(payload.Message) > 0:
+		var message string
+		if err := json.Unmarshal(payload.Message, &message); err != nil {
+			message = string(payload.Message)
+		}
+		gitlabErr.Message = message
+	case payload.ErrorDescription != "":
+		gitlabErr.Message = payload.ErrorDescription
+	case payload.Error != "":
+		gitlabErr.Message = payload.Error
+	default:
+		gitlabErr.Message = strings.TrimSpace(string(body))
+	}
+
+	return apperror.FromStatus(statusCode, gitlabErr)
+}
+
+// responseError - строит ошибку из неуспешного ответа GitLab с учётом заголовка Retry-After
+func responseError(resp *resty.Response) error {
+	log.Warn().Msgf("⚠️ GitLab вернул статус %d", resp.StatusCode())
+
+	err := ParseGitLabError(resp.StatusCode(), resp.Body())
+
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) && appErr.Kind == apperror.KindRateLimited {
+		appErr.RetryAfter = resp.Header().Get("Retry-After")
+	}
+
+	return err
 }
 
 // GetEnvironmentDetails - получает информацию о конкретном окружении
 func (g *GitLabClient) GetEnvironmentDetails(ctx context.Context, environmentID string) (*DeploymentInfo, error) {
 	if environmentID == "" {
-		return nil, fmt.Errorf("❌ environmentID не может быть пустым")
+		return nil, apperror.Validation("environmentID не может быть пустым")
 	}
 
 	url := fmt.Sprintf("%s%s%s/environments/%s", g.baseURL, g.apiURL, g.projectID, environmentID)
@@ -121,18 +217,17 @@ func (g *GitLabClient) GetEnvironmentDetails(ctx context.Context, environmentID
 
 	if err != nil {
 		log.Error().Err(err).Msg("❌ Ошибка запроса к GitLab")
-		return nil, err
+		return nil, apperror.Unavailable(err, "ошибка запроса к GitLab")
 	}
 
 	if resp.StatusCode() != http.StatusOK {
-		log.Warn().Msgf("⚠️ GitLab вернул статус %d", resp.StatusCode())
-		return nil, ParseGitLabError(resp.Body()) // Используем ParseGitLabError
+		return nil, responseError(resp)
 	}
 
 	var envDetails EnvironmentDetails
 	if err := json.Unmarshal(resp.Body(), &envDetails); err != nil {
 		log.Error().Err(err).Msg("❌ Ошибка парсинга ответа GitLab")
-		return nil, err
+		return nil, apperror.Unavailable(err, "некорректный ответ GitLab")
 	}
 
 	deployment := DeploymentInfo{
@@ -163,156 +258,175 @@ func (g *GitLabClient) GetEnvironmentDetails(ctx context.Context, environmentID
 // GetBuildVersion - получает BUILD_VERSION из логов джобы
 func (g *GitLabClient) GetBuildVersion(ctx context.Context, jobID string) (string, error) {
 	if jobID == "" {
-		return "", fmt.Errorf("❌ jobID не может быть пустым")
+		return "", apperror.Validation("jobID не может быть пустым")
 	}
 
-	url := fmt.Sprintf("%s%s%s/jobs/%s/trace", g.baseURL, g.apiURL, g.projectID, jobID)
-	log.Debug().Msgf("📡 Запрос логов джобы: jobID=%s, URL=%s", jobID, url)
-
-	resp, err := g.client.R().
-		SetContext(ctx).
-		Get(url)
+	if buildVersion, ok := g.buildVersions.Get(jobID); ok {
+		log.Debug().Msgf("📦 BUILD_VERSION для jobID=%s взят из кэша: %s", jobID, buildVersion)
+		return buildVersion, nil
+	}
 
+	trace, err := g.GetJobTrace(ctx, jobID)
 	if err != nil {
-		log.Error().Err(err).Msg("❌ Ошибка запроса логов GitLab")
 		return "", err
 	}
 
-	if resp.StatusCode() != http.StatusOK {
-		log.Warn().Msgf("⚠️ GitLab вернул статус %d", resp.StatusCode())
-		return "", ParseGitLabError(resp.Body()) // Используем ParseGitLabError
-	}
-
 	re := regexp.MustCompile(`(?m)^\s*BUILD_VERSION\s*=\s*(\S+)`)
-	matches := re.FindStringSubmatch(string(resp.Body()))
+	matches := re.FindStringSubmatch(trace)
 	if len(matches) < 2 {
-		return "", fmt.Errorf("⚠️ BUILD_VERSION не найден в логах")
+		return "", apperror.NotFound("BUILD_VERSION не найден в логах")
 	}
 
 	buildVersion := strings.TrimSpace(matches[1])
+	g.buildVersions.Set(jobID, buildVersion)
 	log.Info().Msgf("✅ BUILD_VERSION найден: %s", buildVersion)
 	return buildVersion, nil
 }
 
-// GetPreviousPipelineSHA - ищет SHA предыдущей успешной сборки с пагинацией
-func (g *GitLabClient) GetPreviousPipelineSHA(ctx context.Context, ref, currentSHA string) (string, error) {
-	if g.projectID == "" {
-		return "", fmt.Errorf("❌ projectID не может быть пустым")
+// GetJobTrace - получает лог джобы
+func (g *GitLabClient) GetJobTrace(ctx context.Context, jobID string) (string, error) {
+	if jobID == "" {
+		return "", apperror.Validation("jobID не может быть пустым")
 	}
 
-	perPage := 100 // Максимальное количество записей на страницу
-	page := 1
-	foundCurrent := false
+	url := fmt.Sprintf("%s%s%s/jobs/%s/trace", g.baseURL, g.apiURL, g.projectID, jobID)
+	log.Debug().Msgf("📡 Запрос логов джобы: jobID=%s, URL=%s", jobID, url)
 
-	for {
-		url := fmt.Sprintf("%s%s%s/pipelines?ref=%s&per_page=%d&page=%d",
-			g.baseURL, g.apiURL, g.projectID, ref, perPage, page)
-		log.Debug().Msgf("📡 Запрос пайплайнов (страница %d): URL=%s", page, url)
+	resp, err := g.client.R().
+		SetContext(ctx).
+		Get(url)
 
-		resp, err := g.client.R().
-			SetContext(ctx).
-			Get(url)
+	if err != nil {
+		log.Error().Err(err).Msg("❌ Ошибка запроса логов GitLab")
+		return "", apperror.Unavailable(err, "ошибка запроса к GitLab")
+	}
 
-		if err != nil {
-			log.Error().Err(err).Msg("❌ Ошибка запроса пайплайнов GitLab")
-			return "", err
-		}
+	if resp.StatusCode() != http.StatusOK {
+		return "", responseError(resp)
+	}
 
-		if resp.StatusCode() != http.StatusOK {
-			return "", ParseGitLabError(resp.Body())
-		}
+	return string(resp.Body()), nil
+}
 
-		var pipelines []Pipeline
-		if err := json.Unmarshal(resp.Body(), &pipelines); err != nil {
-			log.Error().Err(err).Msg("❌ Ошибка парсинга списка пайплайнов GitLab")
-			return "", err
-		}
+// GetPreviousPipelineSHA - ищет SHA предыдущей успешной сборки с пагинацией
+func (g *GitLabClient) GetPreviousPipelineSHA(ctx context.Context, ref, currentSHA string) (string, error) {
+	if g.projectID == "" {
+		return "", apperror.Validation("projectID не может быть пустым")
+	}
 
-		if len(pipelines) == 0 {
-			break // Если больше нет данных, выходим
-		}
+	foundCurrent := false
+	previousSHA := ""
 
+	// Пайплайны идут от новых к старым: останавливаемся на первом после текущего
+	params := url.Values{"ref": {ref}}
+	_, err := Paginate(ctx, g.client, g.projectURL("/pipelines"), params, g.pageOptions("pipelines"), func(pipelines []Pipeline) bool {
 		for _, pipeline := range pipelines {
 			if pipeline.SHA == currentSHA {
 				foundCurrent = true
 				continue // Пропускаем текущий пайплайн
 			}
-			if foundCurrent && pipeline.SHA != currentSHA {
-				log.Info().Msgf("✅ Найден предыдущий SHA: %s", pipeline.SHA)
-				return pipeline.SHA, nil
+			if foundCurrent {
+				previousSHA = pipeline.SHA
+				return false
 			}
 		}
+		return true
+	})
+	if err != nil {
+		return "", err
+	}
 
-		page++ // Запрашиваем следующую страницу
+	if previousSHA != "" {
+		log.Info().Msgf("✅ Найден предыдущий SHA: %s", previousSHA)
+		return previousSHA, nil
 	}
 
-	return "", fmt.Errorf("❌ Не удалось найти предыдущий SHA для ref=%s", ref)
+	return "", apperror.NotFound("не удалось найти предыдущий SHA для ref=%s", ref)
 }
 
 // GetCommitsBetweenSHAs - получает список коммитов между SHA с поддержкой пагинации
 func (g *GitLabClient) GetCommitsBetweenSHAs(ctx context.Context, ref, fromSHA, toSHA string) ([]CommitInfo, error) {
 	if g.projectID == "" {
-		return nil, fmt.Errorf("❌ projectID не может быть пустым")
+		return nil, apperror.Validation("projectID не может быть пустым")
 	}
 
 	var allCommits []CommitInfo
 	foundSHA := false
-	perPage := 100 // Максимально возможное значение
-	page := 1
-
-	for {
-		url := fmt.Sprintf("%s%s%s/repository/commits?ref_name=%s&per_page=%d&page=%d",
-			g.baseURL, g.apiURL, g.projectID, ref, perPage, page)
-		log.Debug().Msgf("📡 Запрос коммитов (страница %d): URL=%s", page, url)
-
-		resp, err := g.client.R().
-			SetContext(ctx).
-			Get(url)
-
-		if err != nil {
-			log.Error().Err(err).Msg("❌ Ошибка запроса коммитов GitLab")
-			return nil, err
-		}
-
-		if resp.StatusCode() != http.StatusOK {
-			return nil, ParseGitLabError(resp.Body())
-		}
-
-		var commits []CommitInfo
-		if err := json.Unmarshal(resp.Body(), &commits); err != nil {
-			log.Error().Err(err).Msg("❌ Ошибка парсинга коммитов GitLab")
-			return nil, err
-		}
-
-		if len(commits) == 0 {
-			break // Нет больше данных
-		}
+	reachedFrom := false
 
+	// Коммиты идут от новых к старым: собираем начиная с toSHA и останавливаемся на fromSHA
+	params := url.Values{"ref_name": {ref}}
+	stats, err := Paginate(ctx, g.client, g.projectURL("/repository/commits"), params, g.pageOptions("commits"), func(commits []CommitInfo) bool {
 		for _, commit := range commits {
 			if commit.ID == toSHA {
 				foundSHA = true
 			}
 			if commit.ID == fromSHA {
-				log.Info().Msgf("✅ Достигнут fromSHA: %s", fromSHA)
-				return allCommits, nil
+				reachedFrom = true
+				return false
 			}
 			if foundSHA {
 				commit.JiraKeys = ExtractJiraKeys([]CommitInfo{commit}, g.jiraProject)
 				allCommits = append(allCommits, commit)
 			}
 		}
+		return true
+	})
+
+	// 📊 Фиксируем, сколько страниц пришлось загрузить
+	metrics.CommitPagesFetched.Observe(float64(stats.Pages))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if reachedFrom {
+		log.Info().Msgf("✅ Достигнут fromSHA: %s", fromSHA)
+		return allCommits, nil
+	}
 
-		page++ // Переход на следующую страницу
+	// История обрезана лимитом страниц: неполный список нельзя выдавать за все коммиты сборки
+	if stats.Truncated {
+		return nil, apperror.Validation("коммит %s не найден в первых %d страницах истории ветки %s: "+
+			"изменений больше, чем позволяет gitlab.pagination.max_pages", fromSHA, stats.Pages, ref)
 	}
 
 	if len(allCommits) == 0 {
-		return nil, fmt.Errorf("❌ Не найдено новых коммитов между SHA %s и %s", fromSHA, toSHA)
+		return nil, apperror.NotFound("не найдено новых коммитов между SHA %s и %s", fromSHA, toSHA)
 	}
 
 	log.Info().Msgf("✅ Найдено %d новых коммита(ов)", len(allCommits))
 	return allCommits, nil
 }
 
+// CompareCommits - получает коммиты toSHA, которых нет в fromSHA (от общего предка, как git log from...to).
+// В отличие от GetCommitsBetweenSHAs не зависит от ветки: SHA могут быть из разошедшихся веток
+func (g *GitLabClient) CompareCommits(ctx context.Context, fromSHA, toSHA string) ([]CommitInfo, error) {
+	if g.projectID == "" {
+		return nil, apperror.Validation("projectID не может быть пустым")
+	}
+
+	params := url.Values{"from": {fromSHA}, "to": {toSHA}}
+	compareURL := g.projectURL("/repository/compare?" + params.Encode())
+	log.Debug().Msgf("📡 Сравнение коммитов %s...%s: URL=%s", fromSHA, toSHA, compareURL)
+
+	var comparison Comparison
+	if err := g.getJSON(ctx, compareURL, &comparison); err != nil {
+		return nil, err
+	}
+
+	// GitLab отдаёт коммиты от старых к новым, остальные методы - от новых к старым
+	commits := make([]CommitInfo, 0, len(comparison.Commits))
+	for i := len(comparison.Commits) - 1; i >= 0; i-- {
+		commit := comparison.Commits[i]
+		commit.JiraKeys = ExtractJiraKeys([]CommitInfo{commit}, g.jiraProject)
+		commits = append(commits, commit)
+	}
+
+	log.Info().Msgf("✅ Сравнение %s...%s: %d коммит(ов)", fromSHA, toSHA, len(commits))
+	return commits, nil
+}
+
 // ExtractJiraKeys - ищет Jira-ключи в сообщениях коммитов
 func ExtractJiraKeys(commits []CommitInfo, project string) []string {
 	jiraRegex := regexp.MustCompile(fmt.Sprintf(`\b%s-\d+\b`, project))
@@ -335,48 +449,82 @@ func ExtractJiraKeys(commits []CommitInfo, project string) []string {
 
 // GetPipelineJobs - получает список джоб для указанного pipelineID
 func (g *GitLabClient) GetPipelineJobs(ctx context.Context, pipelineID string) ([]JobInfo, error) {
-	if pipelineID == "" {
-		return nil, fmt.Errorf("❌ pipelineID не может быть пустым")
+	jobs, err := g.pipelineJobs(ctx, pipelineID)
+	if err != nil {
+		return nil, err
 	}
 
-	url := fmt.Sprintf("%s%s%s/pipelines/%s/jobs", g.baseURL, g.apiURL, g.projectID, pipelineID)
-	log.Debug().Msgf("📡 Запрос джоб пайплайна: pipelineID=%s, URL=%s", pipelineID, url)
+	// Фильтруем только deploy-стадии (по шаблонам из конфигурации)
+	var deployJobs []JobInfo
+	for _, job := range jobs {
+		if g.IsDeployStage(job.Stage) {
+			deployJobs = append(deployJobs, job)
+		}
+	}
 
-	resp, err := g.client.R().
-		SetContext(ctx).
-		Get(url)
+	log.Info().Msgf("✅ Найдено %d джоб(ы) в deploy-стадии", len(deployJobs))
+	return deployJobs, nil
+}
 
+// GetPipelineBuildJobs - получает джобы пайплайна вне deploy-стадий (сборка, тесты, сканирование)
+func (g *GitLabClient) GetPipelineBuildJobs(ctx context.Context, pipelineID string) ([]JobInfo, error) {
+	jobs, err := g.pipelineJobs(ctx, pipelineID)
 	if err != nil {
-		log.Error().Err(err).Msg("❌ Ошибка запроса джоб GitLab")
 		return nil, err
 	}
 
-	if resp.StatusCode() != http.StatusOK {
-		return nil, ParseGitLabError(resp.Body())
+	var buildJobs []JobInfo
+	for _, job := range jobs {
+		if !g.IsDeployStage(job.Stage) {
+			buildJobs = append(buildJobs, job)
+		}
+	}
+	return buildJobs, nil
+}
+
+// pipelineJobs - получает все джобы пайплайна
+func (g *GitLabClient) pipelineJobs(ctx context.Context, pipelineID string) ([]JobInfo, error) {
+	if pipelineID == "" {
+		return nil, apperror.Validation("pipelineID не может быть пустым")
 	}
 
 	var jobs []JobInfo
-	if err := json.Unmarshal(resp.Body(), &jobs); err != nil {
-		log.Error().Err(err).Msg("❌ Ошибка парсинга списка джоб GitLab")
+	_, err := Paginate(ctx, g.client, g.projectURL("/pipelines/"+pipelineID+"/jobs"), nil, g.pageOptions("jobs"), func(items []JobInfo) bool {
+		jobs = append(jobs, items...)
+		return true
+	})
+	if err != nil {
 		return nil, err
 	}
+	return jobs, nil
+}
 
-	// Фильтруем только stage=deploy
-	var deployJobs []JobInfo
-	for _, job := range jobs {
-		if job.Stage == "deploy" {
-			deployJobs = append(deployJobs, job)
-		}
+// GetProjectJobs - получает джобы проекта (новые первыми) со статусами из scopes; без scopes - все.
+// Список джоб проекта большой, поэтому он выгружается keyset-пагинацией: она не замедляется на дальних страницах.
+func (g *GitLabClient) GetProjectJobs(ctx context.Context, scopes ...string) ([]JobInfo, error) {
+	params := url.Values{}
+	for _, scope := range scopes {
+		params.Add("scope[]", scope)
 	}
 
-	log.Info().Msgf("✅ Найдено %d джоб(ы) в deploy-стадии", len(deployJobs))
-	return deployJobs, nil
+	opts := g.pageOptions("project_jobs")
+	opts.Keyset = &Keyset{OrderBy: "id", Sort: "desc"}
+
+	var jobs []JobInfo
+	_, err := Paginate(ctx, g.client, g.projectURL("/jobs"), params, opts, func(items []JobInfo) bool {
+		jobs = append(jobs, items...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
 }
 
 // TriggerDeployJob - запускает указанную deploy-джобу
 func (g *GitLabClient) TriggerDeployJob(ctx context.Context, jobID string) (*TriggeredJob, error) {
 	if jobID == "" {
-		return nil, fmt.Errorf("❌ jobID не может быть пустым")
+		return nil, apperror.Validation("jobID не может быть пустым")
 	}
 
 	url := fmt.Sprintf("%s%s%s/jobs/%s/play", g.baseURL, g.apiURL, g.projectID, jobID)
@@ -388,19 +536,120 @@ func (g *GitLabClient) TriggerDeployJob(ctx context.Context, jobID string) (*Tri
 
 	if err != nil {
 		log.Error().Err(err).Msg("❌ Ошибка запроса на запуск деплоя")
-		return nil, err
+		return nil, apperror.Unavailable(err, "ошибка запроса к GitLab")
 	}
 
 	if resp.StatusCode() != http.StatusOK {
-		return nil, ParseGitLabError(resp.Body())
+		return nil, responseError(resp)
 	}
 
 	var triggeredJob TriggeredJob
 	if err := json.Unmarshal(resp.Body(), &triggeredJob); err != nil {
 		log.Error().Err(err).Msg("❌ Ошибка парсинга ответа GitLab")
-		return nil, err
+		return nil, apperror.Unavailable(err, "некорректный ответ GitLab")
 	}
 
 	log.Info().Msgf("✅ Деплой запущен: jobID=%s, статус=%s", jobID, triggeredJob.Status)
 	return &triggeredJob, nil
 }
+
+// GetJob - получает текущее состояние джобы
+func (g *GitLabClient) GetJob(ctx context.Context, jobID string) (*TriggeredJob, error) {
+	if jobID == "" {
+		return nil, apperror.Validation("jobID не может быть пустым")
+	}
+
+	resp, err := g.client.R().
+		SetContext(ctx).
+		Get(g.projectURL("/jobs/" + jobID))
+
+	if err != nil {
+		log.Error().Err(err).Msg("❌ Ошибка запроса джобы GitLab")
+		return nil, apperror.Unavailable(err, "ошибка запроса к GitLab")
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var job TriggeredJob
+	if err := json.Unmarshal(resp.Body(), &job); err != nil {
+		log.Error().Err(err).Msg("❌ Ошибка парсинга ответа GitLab")
+		return nil, apperror.Unavailable(err, "некорректный ответ GitLab")
+	}
+
+	return &job, nil
+}
+
+// RetryJob - перезапускает завершённую джобу (GitLab создаёт новую джобу с тем же заданием)
+func (g *GitLabClient) RetryJob(ctx context.Context, jobID string) (*TriggeredJob, error) {
+	if jobID == "" {
+		return nil, apperror.Validation("jobID не может быть пустым")
+	}
+
+	log.Debug().Msgf("🔁 Перезапуск джобы: jobID=%s", jobID)
+
+	resp, err := g.client.R().
+		SetContext(ctx).
+		Post(g.projectURL("/jobs/" + jobID + "/retry"))
+
+	if err != nil {
+		log.Error().Err(err).Msg("❌ Ошибка запроса на перезапуск джобы")
+		return nil, apperror.Unavailable(err, "ошибка запроса к GitLab")
+	}
+
+	if resp.StatusCode() != http.StatusOK && resp.StatusCode() != http.StatusCreated {
+		return nil, responseError(resp)
+	}
+
+	var job TriggeredJob
+	if err := json.Unmarshal(resp.Body(), &job); err != nil {
+		log.Error().Err(err).Msg("❌ Ошибка парсинга ответа GitLab")
+		return nil, apperror.Unavailable(err, "некорректный ответ GitLab")
+	}
+
+	log.Info().Msgf("✅ Джоба %s перезапущена: новая jobID=%d, статус=%s", jobID, job.ID, job.Status)
+	return &job, nil
+}
+
+// GetLatestPipeline - получает последний пайплайн ветки или тега (например, тега версии)
+func (g *GitLabClient) GetLatestPipeline(ctx context.Context, ref string) (*Pipeline, error) {
+	if ref == "" {
+		return nil, apperror.Validation("ref не может быть пустым")
+	}
+
+	resp, err := g.client.R().
+		SetContext(ctx).
+		SetQueryParams(map[string]string{"ref": ref, "per_page": "1"}).
+		Get(g.projectURL("/pipelines"))
+
+	if err != nil {
+		log.Error().Err(err).Msg("❌ Ошибка запроса пайплайнов GitLab")
+		return nil, apperror.Unavailable(err, "ошибка запроса к GitLab")
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var pipelines []Pipeline
+	if err := json.Unmarshal(resp.Body(), &pipelines); err != nil {
+		log.Error().Err(err).Msg("❌ Ошибка парсинга ответа GitLab")
+		return nil, apperror.Unavailable(err, "некорректный ответ GitLab")
+	}
+	if len(pipelines) == 0 {
+		return nil, apperror.NotFound("пайплайны для ref=%s не найдены", ref)
+	}
+
+	return &pipelines[0], nil
+}
+
+// IsDeployStage - проверяет, подходит ли стадия под шаблоны deploy-стадий проекта
+func (g *GitLabClient) IsDeployStage(stage string) bool {
+	for _, pattern := range g.deployStages {
+		if ok, _ := path.Match(pattern, stage); ok {
+			return true
+		}
+	}
+	return false
+}
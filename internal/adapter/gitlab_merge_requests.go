@@ -0,0 +1,43 @@
+package adapter
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+)
+
+// GetCommitMergeRequests - получает merge request'ы, в которые входит коммит sha
+func (g *GitLabClient) GetCommitMergeRequests(ctx context.Context, sha string) ([]MergeRequest, error) {
+	if sha == "" {
+		return nil, apperror.Validation("sha не может быть пустым")
+	}
+
+	var mergeRequests []MergeRequest
+	_, err := Paginate(ctx, g.client, g.projectURL("/repository/commits/"+sha+"/merge_requests"), nil, g.pageOptions("commit_merge_requests"), func(items []MergeRequest) bool {
+		mergeRequests = append(mergeRequests, items...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mergeRequests, nil
+}
+
+// GetMergeRequestApprovers - получает пользователей, одобривших merge request iid
+func (g *GitLabClient) GetMergeRequestApprovers(ctx context.Context, iid int) ([]User, error) {
+	url := g.projectURL("/merge_requests/" + strconv.Itoa(iid) + "/approvals")
+	log.Debug().Msgf("📡 Запрос одобрений merge request !%d: URL=%s", iid, url)
+
+	var approvals MergeRequestApprovals
+	if err := g.getJSON(ctx, url, &approvals); err != nil {
+		return nil, err
+	}
+
+	approvers := make([]User, 0, len(approvals.ApprovedBy))
+	for _, approval := range approvals.ApprovedBy {
+		approvers = append(approvers, approval.User)
+	}
+	return approvers, nil
+}
@@ -0,0 +1,93 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+)
+
+// apiRoot - корень API GitLab (например /api/v4/), вычисляется из GITLAB_API_URL (/api/v4/projects/)
+func (g *GitLabClient) apiRoot() string {
+	return strings.TrimSuffix(strings.TrimSuffix(g.apiURL, "/"), "projects")
+}
+
+// getJSON - выполняет GET-запрос к GitLab и разбирает JSON-ответ в out
+func (g *GitLabClient) getJSON(ctx context.Context, url string, out any) error {
+	resp, err := g.client.R().
+		SetContext(ctx).
+		Get(url)
+
+	if err != nil {
+		log.Error().Err(err).Msgf("❌ Ошибка запроса к GitLab: URL=%s", url)
+		return apperror.Unavailable(err, "ошибка запроса к GitLab")
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		return responseError(resp)
+	}
+
+	if err := json.Unmarshal(resp.Body(), out); err != nil {
+		log.Error().Err(err).Msgf("❌ Ошибка парсинга ответа GitLab: URL=%s", url)
+		return apperror.Unavailable(err, "некорректный ответ GitLab")
+	}
+
+	return nil
+}
+
+// CredentialKind - тип действующих учётных данных клиента (personal, oauth, job)
+func (g *GitLabClient) CredentialKind() string {
+	return g.credentials.Kind()
+}
+
+// GetCurrentUser - получает пользователя, которому принадлежит токен
+func (g *GitLabClient) GetCurrentUser(ctx context.Context) (*User, error) {
+	url := fmt.Sprintf("%s%suser", g.baseURL, g.apiRoot())
+	log.Debug().Msgf("📡 Запрос текущего пользователя GitLab: URL=%s", url)
+
+	var user User
+	if err := g.getJSON(ctx, url, &user); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// GetProject - получает информацию о настроенном проекте, включая права токена
+func (g *GitLabClient) GetProject(ctx context.Context) (*Project, error) {
+	if g.projectID == "" {
+		return nil, apperror.Validation("projectID не может быть пустым")
+	}
+
+	url := fmt.Sprintf("%s%s%s", g.baseURL, g.apiURL, g.projectID)
+	log.Debug().Msgf("📡 Запрос проекта GitLab: projectID=%s, URL=%s", g.projectID, url)
+
+	var project Project
+	if err := g.getJSON(ctx, url, &project); err != nil {
+		return nil, err
+	}
+
+	return &project, nil
+}
+
+// GetTokenInfo - получает информацию о текущем токене (scopes, срок действия)
+func (g *GitLabClient) GetTokenInfo(ctx context.Context) (*TokenInfo, error) {
+	if kind := g.CredentialKind(); kind != config.CredentialPersonal {
+		return nil, apperror.NotFound("информация о токене недоступна для учётных данных типа %s", kind)
+	}
+
+	url := fmt.Sprintf("%s%spersonal_access_tokens/self", g.baseURL, g.apiRoot())
+	log.Debug().Msgf("📡 Запрос информации о токене GitLab: URL=%s", url)
+
+	var token TokenInfo
+	if err := g.getJSON(ctx, url, &token); err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
@@ -0,0 +1,80 @@
+package adapter
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/rs/zerolog/log"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+)
+
+// GetTestReportSummary - получает сводку отчёта о тестах пайплайна
+func (g *GitLabClient) GetTestReportSummary(ctx context.Context, pipelineID string) (*TestReportSummary, error) {
+	if pipelineID == "" {
+		return nil, apperror.Validation("pipelineID не может быть пустым")
+	}
+
+	url := g.projectURL("/pipelines/" + pipelineID + "/test_report_summary")
+	log.Debug().Msgf("📡 Запрос отчёта о тестах пайплайна: pipelineID=%s, URL=%s", pipelineID, url)
+
+	var summary TestReportSummary
+	if err := g.getJSON(ctx, url, &summary); err != nil {
+		return nil, err
+	}
+
+	return &summary, nil
+}
+
+// GetVulnerabilityFindings - получает неотклонённые уязвимости пайплайна с уровнями severities
+// (отчёты сканеров безопасности, GitLab Ultimate)
+func (g *GitLabClient) GetVulnerabilityFindings(ctx context.Context, pipelineID string, severities []string) ([]VulnerabilityFinding, error) {
+	if pipelineID == "" {
+		return nil, apperror.Validation("pipelineID не может быть пустым")
+	}
+
+	params := url.Values{"pipeline_id": {pipelineID}, "severity[]": severities}
+	var findings []VulnerabilityFinding
+	_, err := Paginate(ctx, g.client, g.projectURL("/vulnerability_findings"), params, g.pageOptions("vulnerability_findings"), func(items []VulnerabilityFinding) bool {
+		findings = append(findings, items...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debug().Msgf("🛡 Найдено %d уязвимост(ей) в пайплайне %s", len(findings), pipelineID)
+	return findings, nil
+}
+
+// GetCommitBranches - получает ветки, в которых есть коммит sha
+func (g *GitLabClient) GetCommitBranches(ctx context.Context, sha string) ([]string, error) {
+	if sha == "" {
+		return nil, apperror.Validation("sha не может быть пустым")
+	}
+
+	params := url.Values{"type": {"branch"}}
+	var branches []string
+	_, err := Paginate(ctx, g.client, g.projectURL("/repository/commits/"+sha+"/refs"), params, g.pageOptions("commit_refs"), func(items []CommitRef) bool {
+		for _, ref := range items {
+			branches = append(branches, ref.Name)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return branches, nil
+}
+
+// GetProtectedBranches - получает защищённые ветки проекта
+func (g *GitLabClient) GetProtectedBranches(ctx context.Context) ([]ProtectedBranch, error) {
+	var branches []ProtectedBranch
+	_, err := Paginate(ctx, g.client, g.projectURL("/protected_branches"), nil, g.pageOptions("protected_branches"), func(items []ProtectedBranch) bool {
+		branches = append(branches, items...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return branches, nil
+}
@@ -7,7 +7,8 @@ import (
 
 // GitLabError - структура для обработки ошибок от GitLab API
 type GitLabError struct {
-	Message string `json:"message"`
+	StatusCode int    `json:"-"`
+	Message    string `json:"message"`
 }
 
 // Error реализует интерфейс error для GitLabError
@@ -17,8 +18,9 @@ func (e *GitLabError) Error() string {
 
 // Environment - структура для хранения информации об окружении
 type Environment struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	State string `json:"state"` // available | stopping | stopped
 }
 
 // EnvironmentDetails - структура с детальной информацией об окружении
@@ -79,22 +81,166 @@ type CommitInfo struct {
 	JiraKeys    []string `json:"jira_keys"`
 }
 
+// MergeRequest - merge request, через который коммит попал в ветку
+type MergeRequest struct {
+	IID          int       `json:"iid"`
+	Title        string    `json:"title"`
+	State        string    `json:"state"` // opened | merged | closed
+	Author       User      `json:"author"`
+	Labels       []string  `json:"labels"`
+	SourceBranch string    `json:"source_branch"`
+	TargetBranch string    `json:"target_branch"`
+	MergedAt     time.Time `json:"merged_at"`
+	WebURL       string    `json:"web_url"`
+}
+
+// MergeRequestApprovals - одобрения merge request
+type MergeRequestApprovals struct {
+	ApprovedBy []struct {
+		User User `json:"user"`
+	} `json:"approved_by"`
+}
+
 // JobInfo - информация о джобе
 type JobInfo struct {
+	ID           int       `json:"id"`
+	Status       string    `json:"status"`
+	FinishedAt   time.Time `json:"finished_at"`
+	Stage        string    `json:"stage"`
+	WebURL       string    `json:"web_url"`
+	Stand        string    `json:"name"`
+	Ref          string    `json:"ref"`           // Ветка или тег пайплайна
+	AllowFailure bool      `json:"allow_failure"` // Падение джобы не делает пайплайн неуспешным
+}
+
+// TestReportSummary - сводка отчёта о тестах пайплайна
+type TestReportSummary struct {
+	Total struct {
+		Count   int `json:"count"`
+		Success int `json:"success"`
+		Failed  int `json:"failed"`
+		Skipped int `json:"skipped"`
+		Error   int `json:"error"`
+	} `json:"total"`
+}
+
+// VulnerabilityFinding - найденная сканером безопасности уязвимость
+type VulnerabilityFinding struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Severity   string `json:"severity"`    // info | unknown | low | medium | high | critical
+	ReportType string `json:"report_type"` // sast | dependency_scanning | container_scanning | ...
+}
+
+// Comparison - результат сравнения двух коммитов (GET /repository/compare)
+type Comparison struct {
+	Commits []CommitInfo `json:"commits"`
+}
+
+// CommitRef - ветка или тег, в которых есть коммит
+type CommitRef struct {
+	Type string `json:"type"` // branch | tag
+	Name string `json:"name"`
+}
+
+// ProtectedBranch - защищённая ветка проекта (имя может быть шаблоном, например release/*)
+type ProtectedBranch struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// TriggeredJob - структура для информации о запущенной джобе
+type TriggeredJob struct {
 	ID         int       `json:"id"`
+	Name       string    `json:"name"`
+	Stage      string    `json:"stage"`
 	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+	StartedAt  time.Time `json:"started_at"`
 	FinishedAt time.Time `json:"finished_at"`
-	Stage      string    `json:"stage"`
+	Duration   float64   `json:"duration"` // Длительность выполнения в секундах (0, пока джоба не завершилась)
 	WebURL     string    `json:"web_url"`
-	Stand      string    `json:"name"`
+	Pipeline   *Pipeline `json:"pipeline,omitempty"` // Пайплайн джобы: ID, ветка и SHA сборки
+	User       *User     `json:"user,omitempty"`     // Пользователь GitLab, запустивший джобу
 }
 
-// TriggeredJob - структура для информации о запущенной джобе
-type TriggeredJob struct {
-	ID        int       `json:"id"`
-	Name      string    `json:"name"`
-	Stage     string    `json:"stage"`
-	Status    string    `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
-	WebURL    string    `json:"web_url"`
+// User - пользователь GitLab, от имени которого работает токен
+type User struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	State    string `json:"state"`
+	IsAdmin  bool   `json:"is_admin"`
+}
+
+// ProjectAccess - уровень доступа к проекту или группе
+type ProjectAccess struct {
+	AccessLevel int `json:"access_level"`
+}
+
+// Project - информация о проекте GitLab
+type Project struct {
+	ID                int    `json:"id"`
+	Name              string `json:"name"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	DefaultBranch     string `json:"default_branch"`
+	WebURL            string `json:"web_url"`
+	Permissions       struct {
+		ProjectAccess *ProjectAccess `json:"project_access"`
+		GroupAccess   *ProjectAccess `json:"group_access"`
+	} `json:"permissions"`
+}
+
+// AccessLevel возвращает максимальный уровень доступа токена к проекту (с учётом доступа через группу)
+func (p *Project) AccessLevel() int {
+	level := 0
+	if p.Permissions.ProjectAccess != nil {
+		level = p.Permissions.ProjectAccess.AccessLevel
+	}
+	if p.Permissions.GroupAccess != nil && p.Permissions.GroupAccess.AccessLevel > level {
+		level = p.Permissions.GroupAccess.AccessLevel
+	}
+	return level
+}
+
+// Уровни доступа GitLab
+const (
+	AccessLevelGuest      = 10
+	AccessLevelReporter   = 20
+	AccessLevelDeveloper  = 30
+	AccessLevelMaintainer = 40
+	AccessLevelOwner      = 50
+)
+
+// TokenInfo - информация о текущем токене доступа (personal/project/group access token)
+type TokenInfo struct {
+	ID        int      `json:"id"`
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	Active    bool     `json:"active"`
+	Revoked   bool     `json:"revoked"`
+	ExpiresAt string   `json:"expires_at"`
+}
+
+// Expiry возвращает дату истечения токена; false - токен бессрочный или дата не распознана
+func (t *TokenInfo) Expiry() (time.Time, bool) {
+	if t.ExpiresAt == "" {
+		return time.Time{}, false
+	}
+	// GitLab отдаёт дату без времени; токен действует до конца этого дня (UTC)
+	expiry, err := time.Parse("2006-01-02", t.ExpiresAt)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return expiry.Add(24 * time.Hour), true
+}
+
+// HasScope проверяет, выдан ли токену указанный scope
+func (t *TokenInfo) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,250 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/rs/zerolog/log"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/metrics"
+)
+
+// listPerPage - размер страницы при выгрузке списков из GitLab (максимум GitLab API)
+const listPerPage = 100
+
+// linkNextRe - ссылка rel="next" в заголовке Link
+var linkNextRe = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// PageOptions - параметры обхода списка GitLab
+type PageOptions struct {
+	Name     string  // Имя списка для логов и метрик (environments, commits, ...)
+	PerPage  int     // Размер страницы (по умолчанию 100 - максимум GitLab)
+	MaxPages int     // Ограничение числа страниц; 0 - без ограничения
+	Prefetch bool    // Загружать следующую страницу, пока обрабатывается текущая
+	Keyset   *Keyset // Keyset-пагинация; nil - по номерам страниц
+}
+
+// Keyset - параметры keyset-пагинации. GitLab поддерживает её не для всех списков
+// (проекты, джобы проекта, дерево репозитория и др.), зато она не замедляется на дальних страницах.
+type Keyset struct {
+	OrderBy string
+	Sort    string
+}
+
+// PageStats - итог обхода списка
+type PageStats struct {
+	Pages      int  // Загружено и обработано страниц
+	TotalPages int  // Всего страниц по X-Total-Pages (0 - GitLab не сообщил, например для больших списков)
+	Truncated  bool // Обход прерван ограничением MaxPages
+	Stopped    bool // Обход остановлен обработчиком страницы
+}
+
+// pageResult - загруженная страница и ссылка на следующую
+type pageResult[T any] struct {
+	items      []T
+	next       string // URL следующей страницы; "" - страниц больше нет
+	totalPages int
+	err        error
+}
+
+// Paginate обходит страницы списка GitLab по адресу endpoint с параметрами params.
+// visit вызывается для каждой страницы по порядку; false останавливает обход досрочно
+// (например, когда нужный элемент найден). При Prefetch следующая страница загружается
+// параллельно с обработкой текущей - не больше чем на одну страницу вперёд.
+func Paginate[T any](ctx context.Context, client *resty.Client, endpoint string, params url.Values, opts PageOptions, visit func(items []T) bool) (PageStats, error) {
+	if opts.PerPage == 0 {
+		opts.PerPage = listPerPage
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // Останавливает предзагрузку при досрочном выходе
+
+	first := firstPageURL(endpoint, params, opts)
+	next := sequentialPages[T](ctx, client, first, endpoint, params, opts)
+	if opts.Prefetch {
+		next = prefetchPages[T](ctx, client, first, endpoint, params, opts)
+	}
+
+	var stats PageStats
+	for {
+		result, ok := next()
+		if !ok {
+			return stats, nil
+		}
+		if result.err != nil {
+			log.Error().Err(result.err).Msgf("❌ Ошибка загрузки страницы %d списка GitLab %s", stats.Pages+1, opts.Name)
+			return stats, result.err
+		}
+
+		stats.Pages++
+		stats.TotalPages = result.totalPages
+
+		if !visit(result.items) {
+			stats.Stopped = true
+			return stats, nil
+		}
+
+		if result.next != "" && opts.MaxPages > 0 && stats.Pages >= opts.MaxPages {
+			stats.Truncated = true
+			metrics.PaginationTruncated.WithLabelValues(opts.Name).Inc()
+			log.Warn().Msgf("⚠️ Список GitLab %s обрезан: загружено максимум %d страниц (всего страниц: %s)",
+				opts.Name, opts.MaxPages, totalPagesText(result.totalPages))
+			return stats, nil
+		}
+	}
+}
+
+// sequentialPages загружает страницы по одной: следующая - только по запросу обработчика
+func sequentialPages[T any](ctx context.Context, client *resty.Client, first, endpoint string, params url.Values, opts PageOptions) func() (pageResult[T], bool) {
+	pageURL := first
+	return func() (pageResult[T], bool) {
+		if pageURL == "" {
+			return pageResult[T]{}, false
+		}
+		result := fetchPage[T](ctx, client, pageURL, endpoint, params, opts)
+		pageURL = result.next
+		if result.err != nil {
+			pageURL = ""
+		}
+		return result, true
+	}
+}
+
+// prefetchPages загружает следующую страницу, пока обработчик разбирает текущую.
+// Канал без буфера: загрузчик опережает обработчик не больше чем на одну страницу.
+func prefetchPages[T any](ctx context.Context, client *resty.Client, first, endpoint string, params url.Values, opts PageOptions) func() (pageResult[T], bool) {
+	results := make(chan pageResult[T])
+	go func() {
+		defer close(results)
+		pageURL := first
+		for n := 1; pageURL != ""; n++ {
+			result := fetchPage[T](ctx, client, pageURL, endpoint, params, opts)
+			select {
+			case results <- result:
+			case <-ctx.Done():
+				return
+			}
+			if result.err != nil || (opts.MaxPages > 0 && n >= opts.MaxPages) {
+				return
+			}
+			pageURL = result.next
+		}
+	}()
+
+	return func() (pageResult[T], bool) {
+		result, ok := <-results
+		return result, ok
+	}
+}
+
+// fetchPage загружает и разбирает одну страницу списка
+func fetchPage[T any](ctx context.Context, client *resty.Client, pageURL, endpoint string, params url.Values, opts PageOptions) pageResult[T] {
+	log.Debug().Msgf("📡 Запрос списка GitLab %s: URL=%s", opts.Name, pageURL)
+
+	resp, err := client.R().
+		SetContext(ctx).
+		Get(pageURL)
+
+	if err != nil {
+		return pageResult[T]{err: apperror.Unavailable(err, "ошибка запроса к GitLab")}
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		return pageResult[T]{err: responseError(resp)}
+	}
+
+	var items []T
+	if err := json.Unmarshal(resp.Body(), &items); err != nil {
+		return pageResult[T]{err: apperror.Unavailable(err, "некорректный ответ GitLab")}
+	}
+
+	result := pageResult[T]{items: items}
+	result.totalPages, _ = strconv.Atoi(resp.Header().Get("X-Total-Pages"))
+	if len(items) == 0 {
+		return result // Пустая страница - список закончился, даже если GitLab сообщил о следующей
+	}
+
+	if opts.Keyset != nil {
+		result.next = linkNext(resp) // Курсор следующей страницы есть только в ссылке rel="next"
+		return result
+	}
+
+	if page := nextPage(resp); page != 0 {
+		result.next = pageURLFor(endpoint, params, opts, page)
+	}
+	return result
+}
+
+// firstPageURL строит URL первой страницы: для keyset-пагинации - с порядком сортировки,
+// дальше GitLab сам ведёт курсор в ссылке rel="next"
+func firstPageURL(endpoint string, params url.Values, opts PageOptions) string {
+	if opts.Keyset == nil {
+		return pageURLFor(endpoint, params, opts, 1)
+	}
+
+	query := cloneValues(params)
+	query.Set("pagination", "keyset")
+	query.Set("per_page", strconv.Itoa(opts.PerPage))
+	query.Set("order_by", opts.Keyset.OrderBy)
+	query.Set("sort", opts.Keyset.Sort)
+	return endpoint + "?" + query.Encode()
+}
+
+// pageURLFor строит URL страницы с номером page
+func pageURLFor(endpoint string, params url.Values, opts PageOptions, page int) string {
+	query := cloneValues(params)
+	query.Set("per_page", strconv.Itoa(opts.PerPage))
+	query.Set("page", strconv.Itoa(page))
+	return endpoint + "?" + query.Encode()
+}
+
+// cloneValues копирует параметры запроса, чтобы не менять переданные
+func cloneValues(params url.Values) url.Values {
+	query := url.Values{}
+	for key, values := range params {
+		query[key] = append([]string(nil), values...)
+	}
+	return query
+}
+
+// nextPage определяет номер следующей страницы по заголовку X-Next-Page,
+// а если его нет (GitLab не отдаёт его для больших списков) - по ссылке rel="next" в Link.
+// 0 - следующей страницы нет.
+func nextPage(resp *resty.Response) int {
+	if value := resp.Header().Get("X-Next-Page"); value != "" {
+		if page, err := strconv.Atoi(value); err == nil {
+			return page
+		}
+	}
+
+	if next := linkNext(resp); next != "" {
+		if u, err := url.Parse(next); err == nil {
+			if page, err := strconv.Atoi(u.Query().Get("page")); err == nil {
+				return page
+			}
+		}
+	}
+
+	return 0
+}
+
+// linkNext возвращает ссылку rel="next" из заголовка Link
+func linkNext(resp *resty.Response) string {
+	if match := linkNextRe.FindStringSubmatch(resp.Header().Get("Link")); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// totalPagesText описывает число страниц для логов
+func totalPagesText(totalPages int) string {
+	if totalPages == 0 {
+		return "неизвестно"
+	}
+	return strconv.Itoa(totalPages)
+}
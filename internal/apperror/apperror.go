@@ -0,0 +1,195 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Kind - категория ошибки, по которой выбирается HTTP-статус и код ответа
+type Kind string
+
+const (
+	KindNotFound     Kind = "not_found"
+	KindUnauthorized Kind = "unauthorized"
+	KindForbidden    Kind = "forbidden"
+	KindConflict     Kind = "conflict"
+	KindRateLimited  Kind = "rate_limited"
+	KindUnavailable  Kind = "upstream_unavailable"
+	KindBadRequest   Kind = "bad_request"
+	KindValidation   Kind = "validation_failed"
+	KindJobFailed    Kind = "job_failed"
+	KindInternal     Kind = "internal_error"
+)
+
+// Error - типизированная ошибка сервиса
+type Error struct {
+	Kind           Kind
+	Message        string // Подробности (сообщение GitLab или описание проблемы)
+	UpstreamStatus int    // HTTP-статус ответа GitLab, 0 если ошибка не от GitLab
+	RetryAfter     string // Значение Retry-After от GitLab для KindRateLimited
+	Err            error  // Исходная ошибка
+	Details        any    // Данные для клиента (например, итог ожидания джобы)
+}
+
+// Сигнальные значения для проверки категории через errors.Is
+var (
+	ErrNotFound    = &Error{Kind: KindNotFound}
+	ErrForbidden   = &Error{Kind: KindForbidden}
+	ErrConflict    = &Error{Kind: KindConflict}
+	ErrRateLimited = &Error{Kind: KindRateLimited}
+	ErrUnavailable = &Error{Kind: KindUnavailable}
+	ErrBadRequest  = &Error{Kind: KindBadRequest}
+	ErrValidation  = &Error{Kind: KindValidation}
+	ErrJobFailed   = &Error{Kind: KindJobFailed}
+	ErrInternal    = &Error{Kind: KindInternal}
+)
+
+// Error реализует интерфейс error
+func (e *Error) Error() string {
+	if e.Err != nil {
+		if e.Message == "" {
+			return e.Err.Error()
+		}
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	if e.Message == "" {
+		return string(e.Kind)
+	}
+	return e.Message
+}
+
+// Unwrap возвращает исходную ошибку
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is сравнивает ошибки по категории, чтобы работал errors.Is(err, apperror.ErrNotFound)
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return t.Kind == e.Kind && t.Message == "" && t.Err == nil
+}
+
+// Code - стабильный машиночитаемый код ошибки
+func (e *Error) Code() string {
+	return string(e.Kind)
+}
+
+// HTTPStatus возвращает HTTP-статус, которым сервис отвечает на эту ошибку
+func (e *Error) HTTPStatus() int {
+	switch e.Kind {
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindConflict:
+		return http.StatusConflict
+	case KindRateLimited:
+		return http.StatusTooManyRequests
+	case KindBadRequest:
+		return http.StatusBadRequest
+	case KindValidation:
+		return http.StatusUnprocessableEntity
+	case KindJobFailed:
+		return http.StatusFailedDependency
+	case KindUnavailable:
+		if errors.Is(e.Err, context.DeadlineExceeded) {
+			return http.StatusGatewayTimeout
+		}
+		if e.UpstreamStatus == http.StatusServiceUnavailable {
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// NotFound создаёт ошибку "не найдено"
+func NotFound(format string, args ...any) *Error {
+	return &Error{Kind: KindNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+// Forbidden создаёт ошибку "доступ запрещён"
+func Forbidden(format string, args ...any) *Error {
+	return &Error{Kind: KindForbidden, Message: fmt.Sprintf(format, args...)}
+}
+
+// Unauthorized создаёт ошибку "клиент сервиса не аутентифицирован"
+func Unauthorized(format string, args ...any) *Error {
+	return &Error{Kind: KindUnauthorized, Message: fmt.Sprintf(format, args...)}
+}
+
+// Conflict создаёт ошибку конфликта состояния
+func Conflict(format string, args ...any) *Error {
+	return &Error{Kind: KindConflict, Message: fmt.Sprintf(format, args...)}
+}
+
+// BadRequest создаёт ошибку некорректного запроса: не указан или не разбирается параметр, заголовок или тело
+func BadRequest(format string, args ...any) *Error {
+	return &Error{Kind: KindBadRequest, Message: fmt.Sprintf(format, args...)}
+}
+
+// Validation создаёт ошибку валидации: запрос разобран, но его значения недопустимы
+func Validation(format string, args ...any) *Error {
+	return &Error{Kind: KindValidation, Message: fmt.Sprintf(format, args...)}
+}
+
+// JobFailed создаёт ошибку "джоба GitLab завершилась неуспешно"
+func JobFailed(format string, args ...any) *Error {
+	return &Error{Kind: KindJobFailed, Message: fmt.Sprintf(format, args...)}
+}
+
+// WithDetails возвращает копию ошибки с данными для клиента
+func WithDetails(err error, details any) *Error {
+	withDetails := *From(err)
+	withDetails.Details = details
+	return &withDetails
+}
+
+// Unavailable оборачивает ошибку обращения к GitLab (сеть, таймаут, некорректный ответ)
+func Unavailable(err error, format string, args ...any) *Error {
+	return &Error{Kind: KindUnavailable, Message: fmt.Sprintf(format, args...), Err: err}
+}
+
+// FromStatus создаёт ошибку по HTTP-статусу ответа GitLab
+func FromStatus(status int, err error) *Error {
+	e := &Error{UpstreamStatus: status, Err: err}
+
+	switch {
+	case status == http.StatusNotFound:
+		e.Kind = KindNotFound
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		e.Kind = KindForbidden
+	case status == http.StatusConflict:
+		e.Kind = KindConflict
+	case status == http.StatusTooManyRequests:
+		e.Kind = KindRateLimited
+	case status == http.StatusBadRequest, status == http.StatusUnprocessableEntity:
+		e.Kind = KindValidation
+	case status >= http.StatusInternalServerError:
+		e.Kind = KindUnavailable
+	default:
+		e.Kind = KindInternal
+	}
+
+	return e
+}
+
+// From приводит произвольную ошибку к *Error (неизвестные ошибки считаются внутренними)
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return &Error{Kind: KindUnavailable, Err: err}
+	}
+	return &Error{Kind: KindInternal, Err: err}
+}
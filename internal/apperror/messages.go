@@ -0,0 +1,47 @@
+package apperror
+
+// Поддерживаемые языки сообщений об ошибках
+const (
+	LangRU = "ru"
+	LangEN = "en"
+)
+
+// messages - локализованные описания категорий ошибок
+var messages = map[string]map[Kind]string{
+	LangRU: {
+		KindNotFound:     "Запрошенный ресурс не найден",
+		KindUnauthorized: "Требуется аутентификация",
+		KindForbidden:    "Недостаточно прав для выполнения операции",
+		KindConflict:     "Операция конфликтует с текущим состоянием ресурса",
+		KindRateLimited:  "Превышен лимит запросов к GitLab, повторите позже",
+		KindUnavailable:  "GitLab недоступен или вернул некорректный ответ",
+		KindBadRequest:   "Некорректный запрос",
+		KindValidation:   "Недопустимые параметры запроса",
+		KindJobFailed:    "Джоба GitLab завершилась неуспешно",
+		KindInternal:     "Внутренняя ошибка сервиса",
+	},
+	LangEN: {
+		KindNotFound:     "The requested resource was not found",
+		KindUnauthorized: "Authentication required",
+		KindForbidden:    "Not enough permissions to perform the operation",
+		KindConflict:     "The operation conflicts with the current state of the resource",
+		KindRateLimited:  "GitLab rate limit exceeded, please retry later",
+		KindUnavailable:  "GitLab is unavailable or returned an invalid response",
+		KindBadRequest:   "Malformed request",
+		KindValidation:   "Invalid request parameters",
+		KindJobFailed:    "The GitLab job did not succeed",
+		KindInternal:     "Internal service error",
+	},
+}
+
+// Localize возвращает описание категории ошибки на указанном языке (по умолчанию - русский)
+func Localize(kind Kind, lang string) string {
+	table, ok := messages[lang]
+	if !ok {
+		table = messages[LangRU]
+	}
+	if msg, ok := table[kind]; ok {
+		return msg
+	}
+	return table[KindInternal]
+}
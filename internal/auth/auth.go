@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+)
+
+// identityKey - ключ c.Locals, под которым хранится идентичность клиента
+const identityKey = "auth.identity"
+
+// Anonymous - идентичность клиента при выключенной аутентификации
+const Anonymous = "anonymous"
+
+// Middleware проверяет клиента по статическому токену (Authorization: Bearer) или
+// берёт имя пользователя из заголовка прокси из trusted_proxies. Без настроек пропускает всех.
+// settings возвращает действующие настройки (меняются при перезагрузке конфигурации),
+// onError формирует ответ об ошибке (см. handler.ErrorHandler).
+func Middleware(settings func() config.AuthConfig, onError fiber.ErrorHandler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		cfg := settings()
+		if !cfg.Enabled() {
+			c.Locals(identityKey, Anonymous)
+			return c.Next()
+		}
+
+		if token := bearerToken(c); token != "" {
+			for _, t := range cfg.Tokens {
+				if subtle.ConstantTimeCompare([]byte(token), []byte(t.Token)) == 1 {
+					c.Locals(identityKey, t.Name)
+					return c.Next()
+				}
+			}
+			return onError(c, apperror.Unauthorized("неизвестный токен доступа"))
+		}
+
+		if cfg.UserHeader != "" {
+			if user := strings.TrimSpace(c.Get(cfg.UserHeader)); user != "" {
+				// Заголовок может подставить любой клиент: верим ему, только если запрос пришёл от прокси
+				if !cfg.TrustsProxy(c.Context().RemoteIP()) {
+					return onError(c, apperror.Unauthorized("заголовок %s принимается только от доверенных прокси", cfg.UserHeader))
+				}
+				// Строка заголовка ссылается на буфер запроса fasthttp: копируем, ведь идентичность
+				// хранится дольше запроса (создатель операции, расписания, плана релиза)
+				c.Locals(identityKey, strings.Clone(user))
+				return c.Next()
+			}
+		}
+
+		return onError(c, apperror.Unauthorized("не передан токен доступа"))
+	}
+}
+
+// RequireAdmin пропускает только клиентов из auth.admins (административные эндпоинты).
+// Без аутентификации клиентов администратор неизвестен, и доступ закрыт для всех.
+func RequireAdmin(settings func() config.AuthConfig, onError fiber.ErrorHandler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if identity := Identity(c); identity == Anonymous || !settings().IsAdmin(identity) {
+			return onError(c, apperror.Forbidden("административные эндпоинты доступны только клиентам из auth.admins"))
+		}
+		return c.Next()
+	}
+}
+
+// Identity возвращает идентичность клиента, установленную Middleware
+func Identity(c *fiber.Ctx) string {
+	if identity, ok := c.Locals(identityKey).(string); ok && identity != "" {
+		return identity
+	}
+	return Anonymous
+}
+
+// bearerToken извлекает токен из заголовка Authorization
+func bearerToken(c *fiber.Ctx) string {
+	header := c.Get(fiber.HeaderAuthorization)
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/credentials"
+)
+
+// Impersonate выполняет запросы обработчика к GitLab от имени вызывающего пользователя:
+// с его OAuth-токеном (режим oauth) или через Sudo по его идентичности (режим sudo).
+// Подключается к маршрутам, меняющим состояние в GitLab (запуск джоб).
+func Impersonate(settings func() config.ImpersonationConfig, onError fiber.ErrorHandler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		cfg := settings()
+
+		switch cfg.Mode {
+		case config.ImpersonationOAuth:
+			if token := strings.TrimSpace(c.Get(cfg.UserTokenHeader())); token != "" {
+				c.SetUserContext(credentials.WithUserToken(c.UserContext(), token))
+				log.Debug().Str("identity", Identity(c)).Msg("👤 Запрос к GitLab с токеном пользователя")
+				return c.Next()
+			}
+			if cfg.Required {
+				return onError(c, apperror.Unauthorized("не передан OAuth-токен GitLab в заголовке %s", cfg.UserTokenHeader()))
+			}
+
+		case config.ImpersonationSudo:
+			if identity := Identity(c); identity != Anonymous {
+				username, ok := cfg.GitLabUser(identity)
+				if !ok {
+					return onError(c, apperror.Forbidden("клиенту %q не сопоставлен пользователь GitLab (gitlab.impersonation.users)", identity))
+				}
+				c.SetUserContext(credentials.WithSudo(c.UserContext(), username))
+				log.Debug().Str("identity", identity).Str("sudo", username).Msg("👤 Запрос к GitLab от имени пользователя (Sudo)")
+				return c.Next()
+			}
+			if cfg.Required {
+				return onError(c, apperror.Unauthorized("пользователь не определён, запуск от имени сервисного аккаунта запрещён"))
+			}
+		}
+
+		return c.Next()
+	}
+}
+
+// ImpersonateDeferred - Impersonate для действий, которые выполняются позже (расписания, планы релиза).
+// Пользователь Sudo попадает в контекст запроса, и обработчик сохраняет его вместе с действием.
+// OAuth-токен пользователя сохранить нельзя (он истечёт): при required создание отклоняется,
+// иначе действие выполняется от имени сервисного аккаунта.
+func ImpersonateDeferred(settings func() config.ImpersonationConfig, onError fiber.ErrorHandler) fiber.Handler {
+	impersonate := Impersonate(settings, onError)
+	return func(c *fiber.Ctx) error {
+		cfg := settings()
+		if cfg.Mode != config.ImpersonationOAuth {
+			return impersonate(c)
+		}
+		if cfg.Required {
+			return onError(c, apperror.Forbidden("отложенный запуск от имени пользователя недоступен в режиме oauth: токен пользователя не сохраняется"))
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,31 @@
+package credentials
+
+import "context"
+
+// HeaderSudo - заголовок GitLab для выполнения запроса от имени другого пользователя (нужен токен администратора)
+const HeaderSudo = "Sudo"
+
+// actorKey - ключ контекста с пользователем, от имени которого выполняется запрос к GitLab
+type actorKey struct{}
+
+// Actor - пользователь GitLab, от имени которого выполняется запрос
+type Actor struct {
+	Token string // OAuth-токен пользователя, передаётся вместо учётных данных сервиса
+	Sudo  string // Имя пользователя для Sudo при токене администратора
+}
+
+// WithUserToken возвращает контекст, в котором запросы к GitLab выполняются с OAuth-токеном пользователя
+func WithUserToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, actorKey{}, Actor{Token: token})
+}
+
+// WithSudo возвращает контекст, в котором запросы к GitLab выполняются от имени username через Sudo
+func WithSudo(ctx context.Context, username string) context.Context {
+	return context.WithValue(ctx, actorKey{}, Actor{Sudo: username})
+}
+
+// ActorFrom возвращает пользователя, от имени которого выполняется запрос
+func ActorFrom(ctx context.Context) (Actor, bool) {
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	return actor, ok
+}
@@ -0,0 +1,69 @@
+package credentials
+
+import (
+	"context"
+
+	"github.com/vkr-mtuci/gitlab-service/config"
+)
+
+// Заголовки авторизации GitLab API
+const (
+	HeaderPrivateToken  = "PRIVATE-TOKEN"
+	HeaderJobToken      = "JOB-TOKEN"
+	HeaderAuthorization = "Authorization"
+)
+
+// Credential - заголовок авторизации для запроса к GitLab
+type Credential struct {
+	Header string
+	Value  string
+}
+
+// Source - источник учётных данных одного типа
+type Source interface {
+	// Kind возвращает тип учётных данных (config.CredentialPersonal, ...)
+	Kind() string
+	// Credential возвращает действующие учётные данные
+	Credential(ctx context.Context) (Credential, error)
+	// Recover пытается получить новые учётные данные после ответа 401
+	// (перечитать файл, обновить OAuth-токен); true - стоит повторить запрос
+	Recover(ctx context.Context) bool
+}
+
+// tokenSource - токен, передаваемый в заголовке как есть (personal access token, CI job token)
+type tokenSource struct {
+	kind   string
+	header string
+	token  *secret
+}
+
+// Kind возвращает тип учётных данных
+func (s *tokenSource) Kind() string {
+	return s.kind
+}
+
+// Credential возвращает текущее значение токена
+func (s *tokenSource) Credential(context.Context) (Credential, error) {
+	token, err := s.token.Get()
+	if err != nil {
+		return Credential{}, err
+	}
+	return Credential{Header: s.header, Value: token}, nil
+}
+
+// Recover перечитывает файл с токеном: возможно, секрет уже обновили
+func (s *tokenSource) Recover(context.Context) bool {
+	return s.token.Reload()
+}
+
+// newSource создаёт источник учётных данных по конфигурации
+func newSource(baseURL string, cfg config.CredentialConfig) Source {
+	switch cfg.KindOrDefault() {
+	case config.CredentialJob:
+		return &tokenSource{kind: config.CredentialJob, header: HeaderJobToken, token: newSecret(cfg.Token, cfg.TokenFile)}
+	case config.CredentialOAuth:
+		return newOAuthSource(baseURL, cfg)
+	default:
+		return &tokenSource{kind: config.CredentialPersonal, header: HeaderPrivateToken, token: newSecret(cfg.Token, cfg.TokenFile)}
+	}
+}
@@ -0,0 +1,166 @@
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/vkr-mtuci/gitlab-service/config"
+)
+
+// Параметры обновления OAuth-токена
+const (
+	oauthRefreshTimeout = 10 * time.Second
+	oauthExpiryMargin   = time.Minute // Обновляем токен заранее, чтобы он не истёк посреди запроса
+)
+
+// oauthToken - ответ GitLab на /oauth/token
+type oauthToken struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// oauthSource - OAuth2-токен приложения GitLab, обновляемый по refresh token.
+// Одновременные запросы ждут одно общее обновление, а не выстраиваются в очередь.
+type oauthSource struct {
+	client       *resty.Client
+	clientID     string
+	clientSecret *secret
+	refreshToken *secret
+	refreshes    singleflight.Group
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time // Нулевое значение - срок неизвестен
+}
+
+// newOAuthSource создаёт источник OAuth-токенов
+func newOAuthSource(baseURL string, cfg config.CredentialConfig) *oauthSource {
+	s := &oauthSource{
+		client:       resty.New().SetBaseURL(baseURL).SetTimeout(oauthRefreshTimeout),
+		clientID:     cfg.ClientID,
+		clientSecret: newSecret(cfg.ClientSecret, cfg.ClientSecretFile),
+		refreshToken: newSecret(cfg.RefreshToken, cfg.RefreshTokenFile),
+	}
+
+	if cfg.Token != "" || cfg.TokenFile != "" {
+		if token, err := newSecret(cfg.Token, cfg.TokenFile).Get(); err == nil {
+			s.accessToken = token
+		}
+	}
+
+	return s
+}
+
+// Kind возвращает тип учётных данных
+func (s *oauthSource) Kind() string {
+	return config.CredentialOAuth
+}
+
+// Credential возвращает access token. Истекающий токен обновляется в фоне и пока
+// отдаётся прежний; ждать обновления приходится, только если токена нет или он уже истёк.
+func (s *oauthSource) Credential(ctx context.Context) (Credential, error) {
+	s.mu.Lock()
+	token, expiresAt := s.accessToken, s.expiresAt
+	s.mu.Unlock()
+
+	switch {
+	case token == "" || (!expiresAt.IsZero() && time.Now().After(expiresAt)):
+		var err error
+		if token, err = s.refreshShared(ctx); err != nil {
+			return Credential{}, err
+		}
+	case !expiresAt.IsZero() && time.Until(expiresAt) < oauthExpiryMargin:
+		s.refreshes.DoChan(refreshKey, func() (any, error) { return s.refresh(context.WithoutCancel(ctx)) })
+	}
+
+	return Credential{Header: HeaderAuthorization, Value: "Bearer " + token}, nil
+}
+
+// Recover обновляет access token после ответа 401
+func (s *oauthSource) Recover(ctx context.Context) bool {
+	s.refreshToken.Reload()
+	if _, err := s.refreshShared(ctx); err != nil {
+		log.Error().Err(err).Msg("❌ Не удалось обновить OAuth-токен GitLab")
+		return false
+	}
+	return true
+}
+
+// refreshKey - ключ общего обновления токена в singleflight.Group
+const refreshKey = "refresh"
+
+// refreshShared обновляет access token; одновременные вызовы получают результат одного обновления.
+// Обновление не прерывается отменой запроса, который его начал: его результат ждут и другие.
+func (s *oauthSource) refreshShared(ctx context.Context) (string, error) {
+	result := s.refreshes.DoChan(refreshKey, func() (any, error) { return s.refresh(context.WithoutCancel(ctx)) })
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case r := <-result:
+		if r.Err != nil {
+			return "", r.Err
+		}
+		return r.Val.(string), nil
+	}
+}
+
+// refresh получает новый access token по refresh token и сохраняет выданный GitLab новый refresh token
+func (s *oauthSource) refresh(ctx context.Context) (string, error) {
+	clientSecret, err := s.clientSecret.Get()
+	if err != nil {
+		return "", err
+	}
+	refreshToken, err := s.refreshToken.Get()
+	if err != nil {
+		return "", err
+	}
+
+	var token oauthToken
+	resp, err := s.client.R().
+		SetContext(ctx).
+		SetFormData(map[string]string{
+			"grant_type":    "refresh_token",
+			"refresh_token": refreshToken,
+			"client_id":     s.clientID,
+			"client_secret": clientSecret,
+		}).
+		SetResult(&token).
+		Post("/oauth/token")
+	if err != nil {
+		return "", fmt.Errorf("ошибка обновления OAuth-токена: %w", err)
+	}
+	if resp.StatusCode() != http.StatusOK || token.AccessToken == "" {
+		return "", fmt.Errorf("GitLab отклонил обновление OAuth-токена: %d %s", resp.StatusCode(), strings.TrimSpace(resp.String()))
+	}
+
+	// GitLab выдаёт новый refresh token при каждом обновлении, старый становится недействительным:
+	// без сохранения в refresh_token_file после перезапуска обновить токен будет нечем
+	if token.RefreshToken != "" {
+		if err := s.refreshToken.Store(token.RefreshToken); err != nil {
+			log.Error().Err(err).Msg("❌ Новый refresh token GitLab не сохранён: после перезапуска обновление OAuth-токена не сработает")
+		} else if s.refreshToken.path == "" {
+			log.Warn().Msg("⚠️ GitLab выдал новый refresh token, но refresh_token_file не задан: после перезапуска обновление OAuth-токена не сработает")
+		}
+	}
+
+	var expiresAt time.Time
+	if token.ExpiresIn > 0 {
+		expiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+
+	s.mu.Lock()
+	s.accessToken, s.expiresAt = token.AccessToken, expiresAt
+	s.mu.Unlock()
+
+	log.Info().Time("expires_at", expiresAt).Msg("🔑 OAuth-токен GitLab обновлён")
+	return token.AccessToken, nil
+}
@@ -0,0 +1,164 @@
+package credentials
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/rs/zerolog/log"
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/metrics"
+)
+
+// primaryRetryInterval - через сколько после переключения на резервные учётные данные снова пробовать основные
+const primaryRetryInterval = 5 * time.Minute
+
+// Pool - цепочка учётных данных проекта: основные и резервные.
+// При ответе 401 пул пытается восстановить текущие данные, а затем переключается на следующие.
+type Pool struct {
+	project string
+	sources []Source
+
+	mu         sync.Mutex
+	active     int
+	switchedAt time.Time
+}
+
+// NewPool создаёт пул учётных данных проекта
+func NewPool(baseURL, project string, creds []config.CredentialConfig) *Pool {
+	p := &Pool{project: project}
+	for _, cred := range creds {
+		p.sources = append(p.sources, newSource(baseURL, cred))
+	}
+	return p
+}
+
+// Kind возвращает тип действующих учётных данных ("" - не настроены)
+func (p *Pool) Kind() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.sources) == 0 {
+		return ""
+	}
+	return p.sources[p.active].Kind()
+}
+
+// Instrument подключает пул к resty-клиенту: заголовок авторизации ставится перед каждым
+// запросом, а ответ 401 один раз повторяется с восстановленными или резервными данными.
+// Если в контексте запроса задан пользователь (см. WithUserToken, WithSudo), запрос идёт от его имени.
+func (p *Pool) Instrument(client *resty.Client) {
+	client.OnBeforeRequest(func(_ *resty.Client, req *resty.Request) error {
+		for _, header := range []string{HeaderPrivateToken, HeaderJobToken, HeaderAuthorization, HeaderSudo} {
+			req.Header.Del(header)
+		}
+
+		actor, _ := ActorFrom(req.Context())
+		if actor.Token != "" {
+			req.Header.Set(HeaderAuthorization, "Bearer "+actor.Token)
+			return nil
+		}
+
+		cred, err := p.credential(req.Context())
+		if err != nil {
+			return err
+		}
+		if cred.Header != "" {
+			req.Header.Set(cred.Header, cred.Value)
+		}
+		if actor.Sudo != "" {
+			req.Header.Set(HeaderSudo, actor.Sudo)
+		}
+		return nil
+	})
+
+	client.SetRetryCount(1).
+		SetRetryWaitTime(10 * time.Millisecond).
+		AddRetryCondition(func(resp *resty.Response, _ error) bool {
+			if resp == nil || resp.StatusCode() != http.StatusUnauthorized {
+				return false
+			}
+			// Токен пользователя не заменяем учётными данными сервиса
+			if actor, _ := ActorFrom(resp.Request.Context()); actor.Token != "" {
+				return false
+			}
+			return p.failover(resp.Request.Context(), usedCredential(resp.Request))
+		})
+}
+
+// credential возвращает действующие учётные данные. Пул блокируется только на выбор источника:
+// обновление токена (OAuth) идёт без блокировки пула и не задерживает остальные запросы.
+func (p *Pool) credential(ctx context.Context) (Credential, error) {
+	source, _ := p.current()
+	if source == nil {
+		return Credential{}, nil
+	}
+	return source.Credential(ctx)
+}
+
+// current возвращает действующий источник учётных данных и его номер (nil - не настроены)
+func (p *Pool) current() (Source, int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.sources) == 0 {
+		return nil, 0
+	}
+
+	// Спустя время снова пробуем основные данные: секрет могли обновить
+	if p.active > 0 && time.Since(p.switchedAt) > primaryRetryInterval {
+		log.Info().Str("project", p.project).Msg("🔑 Возвращаемся к основным учётным данным GitLab")
+		p.active = 0
+	}
+
+	return p.sources[p.active], p.active
+}
+
+// failover обрабатывает ответ 401; возвращает true, если запрос стоит повторить
+func (p *Pool) failover(ctx context.Context, used Credential) bool {
+	source, active := p.current()
+	if source == nil {
+		return false
+	}
+
+	// Другой запрос уже обновил учётные данные - просто повторяем
+	if current, err := source.Credential(ctx); err == nil && current != used {
+		return true
+	}
+
+	if source.Recover(ctx) {
+		log.Warn().Str("project", p.project).Str("kind", source.Kind()).Msg("🔑 Учётные данные GitLab обновлены после ответа 401")
+		return true
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Другой запрос уже переключил пул, пока обновлялись данные
+	if p.active != active {
+		return true
+	}
+	if p.active+1 >= len(p.sources) {
+		log.Error().Str("project", p.project).Msg("❌ GitLab отклонил все настроенные учётные данные")
+		return false
+	}
+
+	p.active++
+	p.switchedAt = time.Now()
+	metrics.TokenFailovers.WithLabelValues(p.project, p.sources[p.active].Kind()).Inc()
+	log.Warn().Str("project", p.project).Str("from", source.Kind()).Str("to", p.sources[p.active].Kind()).
+		Msgf("⚠️ Токен GitLab истёк или отозван, переключаемся на резервные учётные данные №%d", p.active)
+	return true
+}
+
+// usedCredential восстанавливает учётные данные, с которыми был отправлен запрос
+func usedCredential(req *resty.Request) Credential {
+	for _, header := range []string{HeaderPrivateToken, HeaderJobToken, HeaderAuthorization} {
+		if value := req.Header.Get(header); value != "" {
+			return Credential{Header: header, Value: value}
+		}
+	}
+	return Credential{}
+}
@@ -0,0 +1,116 @@
+package credentials
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// secretCheckInterval - как часто проверять, не изменился ли файл с секретом
+const secretCheckInterval = 5 * time.Second
+
+// secret - значение секрета, заданное напрямую или файлом (секрет Kubernetes/Docker).
+// Файл перечитывается, когда меняется время его изменения.
+type secret struct {
+	path string
+
+	mu        sync.Mutex
+	value     string
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+// newSecret создаёт секрет из значения или пути к файлу
+func newSecret(value, path string) *secret {
+	return &secret{value: value, path: path}
+}
+
+// Get возвращает текущее значение секрета
+func (s *secret) Get() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.path == "" || time.Since(s.checkedAt) < secretCheckInterval {
+		return s.value, nil
+	}
+	if _, err := s.reload(); err != nil {
+		return "", err
+	}
+	return s.value, nil
+}
+
+// Reload принудительно перечитывает файл; возвращает true, если значение изменилось
+func (s *secret) Reload() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.path == "" {
+		return false
+	}
+	s.modTime = time.Time{}
+	changed, err := s.reload()
+	return err == nil && changed
+}
+
+// Store подменяет значение (например, новый refresh token после его ротации) и, если секрет
+// задан файлом, записывает его в файл, чтобы значение пережило перезапуск.
+// При ошибке записи новое значение остаётся в памяти.
+func (s *secret) Store(value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.value = value
+	if s.path == "" {
+		return nil
+	}
+
+	// Пишем во временный файл рядом и переименовываем, чтобы не оставить секрет недописанным
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), "."+filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("ошибка записи секрета %s: %w", s.path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(value + "\n"); err != nil {
+		tmp.Close()
+		return fmt.Errorf("ошибка записи секрета %s: %w", s.path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("ошибка записи секрета %s: %w", s.path, err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("ошибка записи секрета %s: %w", s.path, err)
+	}
+
+	if info, err := os.Stat(s.path); err == nil {
+		s.modTime = info.ModTime()
+	}
+	return nil
+}
+
+// reload перечитывает файл, если он изменился с прошлого чтения
+func (s *secret) reload() (bool, error) {
+	s.checkedAt = time.Now()
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return false, fmt.Errorf("ошибка чтения секрета %s: %w", s.path, err)
+	}
+	if info.ModTime().Equal(s.modTime) {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return false, fmt.Errorf("ошибка чтения секрета %s: %w", s.path, err)
+	}
+
+	value := strings.TrimSpace(string(data))
+	changed := value != s.value
+	s.value = value
+	s.modTime = info.ModTime()
+	return changed, nil
+}
@@ -0,0 +1,79 @@
+// Package dto - стабильные модели ответов версионированного API (/api/v1).
+// Они не зависят от формата ответов GitLab: изменения разбора в adapter
+// не должны менять публичный JSON.
+package dto
+
+import (
+	"time"
+)
+
+// APIVersion - текущая версия API
+const APIVersion = "v1"
+
+// Time - момент времени, который сериализуется в RFC 3339 (UTC, без долей секунды);
+// нулевое значение сериализуется как null
+type Time struct {
+	time.Time
+}
+
+// MarshalJSON реализует json.Marshaler
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(`"` + t.UTC().Format(time.RFC3339) + `"`), nil
+}
+
+// UnmarshalJSON реализует json.Unmarshaler
+func (t *Time) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		t.Time = time.Time{}
+		return nil
+	}
+	return t.Time.UnmarshalJSON(data)
+}
+
+// NewTime оборачивает time.Time
+func NewTime(t time.Time) Time {
+	return Time{Time: t}
+}
+
+// ParseTime разбирает время из ответа GitLab; некорректное или пустое значение даёт null
+func ParseTime(value string) Time {
+	if value == "" {
+		return Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return Time{}
+	}
+	return Time{Time: t}
+}
+
+// Envelope - единый конверт ответов API v1
+type Envelope struct {
+	Data any  `json:"data"`
+	Meta Meta `json:"meta"`
+}
+
+// Meta - метаданные ответа
+type Meta struct {
+	APIVersion string      `json:"api_version"`
+	RequestID  string      `json:"request_id,omitempty"`
+	Pagination *Pagination `json:"pagination,omitempty"` // Только для списков
+	Partial    bool        `json:"partial,omitempty"`    // Часть элементов собрана с ошибками (см. error у элементов)
+}
+
+// Pagination - метаданные постраничной выдачи списка
+type Pagination struct {
+	Page       int `json:"page"`
+	PerPage    int `json:"per_page"`
+	Total      int `json:"total"`
+	TotalPages int `json:"total_pages"`
+	NextPage   int `json:"next_page,omitempty"` // Номер следующей страницы; нет - последняя страница
+}
+
+// SinglePage возвращает метаданные для списка, отданного целиком
+func SinglePage(total int) *Pagination {
+	return &Pagination{Page: 1, PerPage: total, Total: total, TotalPages: 1}
+}
@@ -0,0 +1,636 @@
+package dto
+
+import (
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/operation"
+	"github.com/vkr-mtuci/gitlab-service/internal/release"
+	"github.com/vkr-mtuci/gitlab-service/internal/scheduler"
+	"github.com/vkr-mtuci/gitlab-service/internal/service"
+)
+
+// Environment - окружение (стенд)
+type Environment struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+// EnvironmentOverview - окружение с последним деплоем, блокировкой и заморозкой
+type EnvironmentOverview struct {
+	ID             int         `json:"id"`
+	Name           string      `json:"name"`
+	State          string      `json:"state"`
+	LastDeployment *Deployment `json:"last_deployment"` // null - деплоев не было или их не удалось получить
+	Locked         bool        `json:"locked"`
+	LockReason     string      `json:"lock_reason,omitempty"`
+	Freeze         *Freeze     `json:"freeze"` // Действующее окно заморозки или null
+	Error          *ItemError  `json:"error,omitempty"`
+}
+
+// Freeze - окно заморозки деплоев
+type Freeze struct {
+	From   Time   `json:"from"`
+	To     Time   `json:"to"`
+	Reason string `json:"reason"`
+}
+
+// ItemError - ошибка по одному элементу частично собранного ответа
+type ItemError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// EnvironmentComparison - что есть в нижнем окружении и ещё не доехало до верхнего
+type EnvironmentComparison struct {
+	From     Deployment `json:"from"`
+	To       Deployment `json:"to"`
+	InSync   bool       `json:"in_sync"`   // В окружениях один и тот же коммит
+	AheadBy  int        `json:"ahead_by"`  // Коммитов from, которых нет в to
+	Behind   bool       `json:"behind"`    // В to есть коммиты, которых нет в from
+	BehindBy int        `json:"behind_by"` // Сколько таких коммитов
+	Diverged bool       `json:"diverged"`  // Окружения разошлись: коммиты есть с обеих сторон
+	Commits  []Commit   `json:"commits"`
+	JiraKeys []string   `json:"jira_keys"`
+}
+
+// Deployment - последний деплой в окружение
+type Deployment struct {
+	Environment    string `json:"environment"`
+	Status         string `json:"status"`
+	DeployedAt     Time   `json:"deployed_at"`
+	Ref            string `json:"ref"`
+	SHA            string `json:"sha"`
+	PipelineID     int    `json:"pipeline_id"`
+	PipelineURL    string `json:"pipeline_url"`
+	JobID          int    `json:"job_id"`
+	JobURL         string `json:"job_url"`
+	BuildVersion   string `json:"build_version"`
+	BuildCreatedAt Time   `json:"build_created_at"`
+}
+
+// Commit - коммит сборки
+type Commit struct {
+	ID          string   `json:"id"`
+	Message     string   `json:"message"`
+	CreatedAt   Time     `json:"created_at"`
+	AuthorName  string   `json:"author_name"`
+	AuthorEmail string   `json:"author_email"`
+	WebURL      string   `json:"web_url"`
+	JiraKeys    []string `json:"jira_keys"`
+
+	MergeRequests []MergeRequestRef `json:"merge_requests,omitempty"` // Merge request'ы коммита (?with_merge_requests=true)
+}
+
+// MergeRequestRef - ссылка на merge request коммита
+type MergeRequestRef struct {
+	IID    int    `json:"iid"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+	WebURL string `json:"web_url"`
+}
+
+// Person - пользователь GitLab
+type Person struct {
+	Username string `json:"username"`
+	Name     string `json:"name"`
+}
+
+// MergeRequest - merge request, через который изменения попали в сборку
+type MergeRequest struct {
+	IID          int      `json:"iid"`
+	Title        string   `json:"title"`
+	State        string   `json:"state"`
+	Author       Person   `json:"author"`
+	Labels       []string `json:"labels"`
+	Approvers    []Person `json:"approvers"`
+	SourceBranch string   `json:"source_branch"`
+	TargetBranch string   `json:"target_branch"`
+	MergedAt     Time     `json:"merged_at"`
+	WebURL       string   `json:"web_url"`
+}
+
+// ReleaseNote - изменения сборки из одного merge request
+type ReleaseNote struct {
+	MergeRequest *MergeRequest `json:"merge_request"` // null - коммиты без merge request
+	Commits      []Commit      `json:"commits"`
+	JiraKeys     []string      `json:"jira_keys"`
+}
+
+// DeployJob - deploy-джоба пайплайна
+type DeployJob struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Stage      string `json:"stage"`
+	Status     string `json:"status"`
+	FinishedAt Time   `json:"finished_at"`
+	WebURL     string `json:"web_url"`
+
+	Ref         string   `json:"ref"`                   // Ветка или тег пайплайна
+	Environment string   `json:"environment,omitempty"` // Окружение, в которое деплоит джоба
+	AllowedRefs []string `json:"allowed_refs"`          // Ветки и теги, из которых можно деплоить в окружение (пусто - любые)
+	RefAllowed  bool     `json:"ref_allowed"`           // Джобу можно запустить: ветка пайплайна разрешена
+}
+
+// TriggeredJob - запущенная deploy-джоба
+type TriggeredJob struct {
+	ID          int           `json:"id"`
+	Name        string        `json:"name"`
+	Stage       string        `json:"stage"`
+	Status      string        `json:"status"`
+	CreatedAt   Time          `json:"created_at"`
+	StartedAt   Time          `json:"started_at"`
+	FinishedAt  Time          `json:"finished_at"`
+	WebURL      string        `json:"web_url"`
+	TriggeredBy string        `json:"triggered_by,omitempty"` // Пользователь GitLab, запустивший джобу
+	Gates       []QualityGate `json:"gates,omitempty"`        // Проверки качества окружения перед запуском
+}
+
+// QualityGate - результат проверки качества сборки перед деплоем
+type QualityGate struct {
+	Gate    string `json:"gate"` // pipeline_success | test_pass_rate | vulnerabilities | protected_branch
+	Passed  bool   `json:"passed"`
+	Message string `json:"message"`
+}
+
+// JobRun - итог выполнения джобы, которого дождался сервис
+type JobRun struct {
+	Job       TriggeredJob `json:"job"`
+	Duration  float64      `json:"duration"`   // Длительность в секундах
+	TraceTail string       `json:"trace_tail"` // Последние строки лога
+}
+
+// Operation - фоновое ожидание завершения джобы
+type Operation struct {
+	ID         string        `json:"id"`
+	Project    string        `json:"project,omitempty"`
+	JobID      int           `json:"job_id"`
+	Status     string        `json:"status"`          // running | succeeded | failed
+	Gates      []QualityGate `json:"gates,omitempty"` // Проверки качества окружения перед запуском джобы
+	Result     *JobRun       `json:"result,omitempty"`
+	Error      *ItemError    `json:"error,omitempty"`
+	CreatedBy  string        `json:"created_by,omitempty"`
+	CreatedAt  Time          `json:"created_at"`
+	FinishedAt Time          `json:"finished_at"`
+}
+
+// DeployPreview - что будет запущено (пробный запуск, ?dry_run=true): джоба не запускается
+type DeployPreview struct {
+	DryRun       bool          `json:"dry_run"` // Всегда true: ответ пробного запуска
+	Environment  string        `json:"environment,omitempty"`
+	Job          TriggeredJob  `json:"job"`
+	PipelineID   int           `json:"pipeline_id"`
+	Ref          string        `json:"ref"`
+	SHA          string        `json:"sha"`
+	BuildVersion string        `json:"build_version"`
+	Current      *Deployment   `json:"current,omitempty"` // Развёрнутая сейчас сборка (нет - деплоев не было)
+	Commits      []Commit      `json:"commits"`           // Коммиты, которые поедут в окружение
+	JiraKeys     []string      `json:"jira_keys"`
+	Gates        []QualityGate `json:"gates,omitempty"` // Проверки качества окружения (все пройдены)
+}
+
+// ReleaseStepPreview - что будет запущено шагом плана релиза
+type ReleaseStepPreview struct {
+	StepID  string        `json:"step_id"`
+	Project string        `json:"project,omitempty"`
+	Preview DeployPreview `json:"preview"`
+}
+
+// Promotion - продвижение сборки из одного окружения в следующее
+type Promotion struct {
+	ID           string        `json:"id"`
+	Project      string        `json:"project,omitempty"`
+	FromEnv      string        `json:"from_env"`
+	ToEnv        string        `json:"to_env"`
+	Ref          string        `json:"ref"`
+	SHA          string        `json:"sha"`
+	BuildVersion string        `json:"build_version"`
+	PipelineID   int           `json:"pipeline_id"`
+	JobID        int           `json:"job_id"`
+	JobName      string        `json:"job_name"`
+	JobURL       string        `json:"job_url"`
+	JobStatus    string        `json:"job_status,omitempty"` // Статус джобы сразу после запуска
+	Status       string        `json:"status"`               // triggered | failed
+	Error        string        `json:"error,omitempty"`      // Причина, если GitLab не запустил джобу
+	Gates        []QualityGate `json:"gates,omitempty"`      // Проверки качества целевого окружения
+	TriggeredBy  string        `json:"triggered_by,omitempty"`
+	CreatedAt    Time          `json:"created_at"`
+}
+
+// Schedule - отложенный деплой или продвижение
+type Schedule struct {
+	ID             string `json:"id"`
+	Type           string `json:"type"` // deploy | promotion
+	Project        string `json:"project,omitempty"`
+	Environment    string `json:"environment,omitempty"`
+	PipelineID     int    `json:"pipeline_id,omitempty"`
+	JobID          int    `json:"job_id,omitempty"`
+	FromEnv        string `json:"from_env,omitempty"`
+	ToEnv          string `json:"to_env,omitempty"`
+	RunAt          Time   `json:"run_at"`
+	Status         string `json:"status"`
+	Error          string `json:"error,omitempty"`
+	ExecutedAt     Time   `json:"executed_at"`
+	TriggeredJobID int    `json:"triggered_job_id,omitempty"`
+	JobName        string `json:"job_name,omitempty"`
+	JobStatus      string `json:"job_status,omitempty"`
+	JobURL         string `json:"job_url,omitempty"`
+	PromotionID    string `json:"promotion_id,omitempty"`
+	CreatedBy      string `json:"created_by,omitempty"`
+	RunAs          string `json:"run_as,omitempty"`
+	CreatedAt      Time   `json:"created_at"`
+}
+
+// ReleasePlan - план релиза и состояние его шагов
+type ReleasePlan struct {
+	ID            string        `json:"id"`
+	Name          string        `json:"name,omitempty"`
+	FailurePolicy string        `json:"failure_policy"` // stop | continue | rollback
+	StepTimeout   string        `json:"step_timeout"`
+	Status        string        `json:"status"` // running | succeeded | failed | rolled_back
+	Steps         []ReleaseStep `json:"steps"`
+	CreatedBy     string        `json:"created_by,omitempty"`
+	RunAs         string        `json:"run_as,omitempty"`
+	CreatedAt     Time          `json:"created_at"`
+	FinishedAt    Time          `json:"finished_at"`
+}
+
+// ReleaseStep - шаг плана релиза
+type ReleaseStep struct {
+	ID            string   `json:"id"`
+	Project       string   `json:"project,omitempty"`
+	Environment   string   `json:"environment"`
+	PipelineID    int      `json:"pipeline_id,omitempty"`
+	Ref           string   `json:"ref,omitempty"`
+	DependsOn     []string `json:"depends_on"`
+	Status        string   `json:"status"`
+	Error         string   `json:"error,omitempty"`
+	JobID         int      `json:"job_id,omitempty"`
+	JobURL        string   `json:"job_url,omitempty"`
+	JobStatus     string   `json:"job_status,omitempty"`
+	PreviousJobID int      `json:"previous_job_id,omitempty"` // Deploy-джоба, к которой откатывается шаг
+	RollbackJobID int      `json:"rollback_job_id,omitempty"`
+	StartedAt     Time     `json:"started_at"`
+	FinishedAt    Time     `json:"finished_at"`
+	RolledBackAt  Time     `json:"rolled_back_at"`
+}
+
+// FromEnvironments преобразует окружения GitLab
+func FromEnvironments(environments []adapter.Environment) []Environment {
+	result := make([]Environment, 0, len(environments))
+	for _, env := range environments {
+		result = append(result, Environment{ID: env.ID, Name: env.Name, State: env.State})
+	}
+	return result
+}
+
+// FromEnvironmentOverview преобразует сводку по окружению (без ошибки - её заполняет обработчик)
+func FromEnvironmentOverview(item service.EnvironmentOverview) EnvironmentOverview {
+	result := EnvironmentOverview{
+		ID:         item.Environment.ID,
+		Name:       item.Environment.Name,
+		State:      item.Environment.State,
+		Locked:     item.Locked,
+		LockReason: item.LockReason,
+	}
+	if item.Deployment != nil {
+		deployment := FromDeployment(item.Deployment)
+		result.LastDeployment = &deployment
+	}
+	if item.Freeze != nil {
+		result.Freeze = &Freeze{From: NewTime(item.Freeze.From), To: NewTime(item.Freeze.To), Reason: item.Freeze.Reason}
+	}
+	return result
+}
+
+// FromEnvironmentComparison преобразует результат сравнения окружений
+func FromEnvironmentComparison(comparison *service.EnvironmentComparison) EnvironmentComparison {
+	return EnvironmentComparison{
+		From:     FromDeployment(comparison.From),
+		To:       FromDeployment(comparison.To),
+		InSync:   comparison.InSync(),
+		AheadBy:  len(comparison.Commits),
+		Behind:   comparison.Behind(),
+		BehindBy: comparison.BehindBy,
+		Diverged: comparison.Diverged(),
+		Commits:  FromCommits(comparison.Commits),
+		JiraKeys: comparison.JiraKeys,
+	}
+}
+
+// FromDeployment преобразует информацию о деплое
+func FromDeployment(info *adapter.DeploymentInfo) Deployment {
+	return Deployment{
+		Environment:    info.EnvironmentName,
+		Status:         info.DeployStatus,
+		DeployedAt:     ParseTime(info.DeploymentDate),
+		Ref:            info.Ref,
+		SHA:            info.SHA,
+		PipelineID:     info.PipelineID,
+		PipelineURL:    info.PipelineURL,
+		JobID:          info.JobID,
+		JobURL:         info.JobURL,
+		BuildVersion:   info.BuildVersion,
+		BuildCreatedAt: ParseTime(info.BuildCreatedAt),
+	}
+}
+
+// FromCommits преобразует коммиты; jira_keys всегда массив, а не null
+func FromCommits(commits []adapter.CommitInfo) []Commit {
+	result := make([]Commit, 0, len(commits))
+	for _, commit := range commits {
+		keys := commit.JiraKeys
+		if keys == nil {
+			keys = []string{}
+		}
+		result = append(result, Commit{
+			ID:          commit.ID,
+			Message:     commit.Message,
+			CreatedAt:   ParseTime(commit.CreatedAt),
+			AuthorName:  commit.AuthorName,
+			AuthorEmail: commit.AuthorEmail,
+			WebURL:      commit.WebURL,
+			JiraKeys:    keys,
+		})
+	}
+	return result
+}
+
+// FromMergeRequestRefs преобразует merge request'ы коммита в ссылки
+func FromMergeRequestRefs(mergeRequests []adapter.MergeRequest) []MergeRequestRef {
+	result := make([]MergeRequestRef, 0, len(mergeRequests))
+	for _, mr := range mergeRequests {
+		result = append(result, MergeRequestRef{IID: mr.IID, Title: mr.Title, State: mr.State, WebURL: mr.WebURL})
+	}
+	return result
+}
+
+// FromReleaseNotes преобразует изменения сборки, сгруппированные по merge request
+func FromReleaseNotes(notes []service.ReleaseNote) []ReleaseNote {
+	result := make([]ReleaseNote, 0, len(notes))
+	for _, note := range notes {
+		item := ReleaseNote{Commits: FromCommits(note.Commits), JiraKeys: note.JiraKeys}
+		if item.JiraKeys == nil {
+			item.JiraKeys = []string{}
+		}
+		if mr := note.MergeRequest; mr != nil {
+			labels := mr.Labels
+			if labels == nil {
+				labels = []string{}
+			}
+			approvers := make([]Person, 0, len(note.Approvers))
+			for _, user := range note.Approvers {
+				approvers = append(approvers, Person{Username: user.Username, Name: user.Name})
+			}
+			item.MergeRequest = &MergeRequest{
+				IID:          mr.IID,
+				Title:        mr.Title,
+				State:        mr.State,
+				Author:       Person{Username: mr.Author.Username, Name: mr.Author.Name},
+				Labels:       labels,
+				Approvers:    approvers,
+				SourceBranch: mr.SourceBranch,
+				TargetBranch: mr.TargetBranch,
+				MergedAt:     NewTime(mr.MergedAt),
+				WebURL:       mr.WebURL,
+			}
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+// FromDeployJobs преобразует deploy-джобы с их окружениями и разрешёнными ветками (refs[i] - для jobs[i])
+func FromDeployJobs(jobs []adapter.JobInfo, refs []service.DeployJobRef) []DeployJob {
+	result := make([]DeployJob, 0, len(jobs))
+	for i, job := range jobs {
+		allowedRefs := refs[i].AllowedRefs
+		if allowedRefs == nil {
+			allowedRefs = []string{}
+		}
+		result = append(result, DeployJob{
+			ID:          job.ID,
+			Name:        job.Stand,
+			Stage:       job.Stage,
+			Status:      job.Status,
+			FinishedAt:  NewTime(job.FinishedAt),
+			WebURL:      job.WebURL,
+			Ref:         job.Ref,
+			Environment: refs[i].Environment,
+			AllowedRefs: allowedRefs,
+			RefAllowed:  refs[i].Allowed,
+		})
+	}
+	return result
+}
+
+// FromTriggeredJob преобразует запущенную джобу
+func FromTriggeredJob(job *adapter.TriggeredJob) TriggeredJob {
+	result := TriggeredJob{
+		ID:         job.ID,
+		Name:       job.Name,
+		Stage:      job.Stage,
+		Status:     job.Status,
+		CreatedAt:  NewTime(job.CreatedAt),
+		StartedAt:  NewTime(job.StartedAt),
+		FinishedAt: NewTime(job.FinishedAt),
+		WebURL:     job.WebURL,
+	}
+	if job.User != nil {
+		result.TriggeredBy = job.User.Username
+	}
+	return result
+}
+
+// FromGateResults преобразует результаты проверок качества
+func FromGateResults(gates []service.GateResult) []QualityGate {
+	if len(gates) == 0 {
+		return nil
+	}
+	result := make([]QualityGate, 0, len(gates))
+	for _, gate := range gates {
+		result = append(result, QualityGate{Gate: gate.Gate, Passed: gate.Passed, Message: gate.Message})
+	}
+	return result
+}
+
+// FromJobRun преобразует итог выполнения джобы, запущенной после проверок качества gates
+func FromJobRun(run *service.JobRun, gates []service.GateResult) JobRun {
+	result := JobRun{
+		Job:       FromTriggeredJob(run.Job),
+		Duration:  run.Duration.Seconds(),
+		TraceTail: run.TraceTail,
+	}
+	result.Job.Gates = FromGateResults(gates)
+	return result
+}
+
+// FromOperation преобразует операцию ожидания (без ошибки - её заполняет обработчик)
+func FromOperation(op *operation.Operation) Operation {
+	result := Operation{
+		ID:         op.ID,
+		Project:    op.Project,
+		JobID:      op.JobID,
+		Status:     op.Status,
+		Gates:      FromGateResults(op.Gates),
+		CreatedBy:  op.CreatedBy,
+		CreatedAt:  NewTime(op.CreatedAt),
+		FinishedAt: NewTime(op.FinishedAt),
+	}
+	if op.Run != nil {
+		run := FromJobRun(op.Run, nil)
+		result.Result = &run
+	}
+	return result
+}
+
+// FromDeployPreview преобразует результат пробного запуска
+func FromDeployPreview(preview *service.DeployPreview) DeployPreview {
+	result := DeployPreview{
+		DryRun:       true,
+		Environment:  preview.Environment,
+		Job:          FromTriggeredJob(preview.Job),
+		PipelineID:   preview.PipelineID,
+		Ref:          preview.Ref,
+		SHA:          preview.SHA,
+		BuildVersion: preview.BuildVersion,
+		Commits:      FromCommits(preview.Commits),
+		JiraKeys:     preview.JiraKeys,
+		Gates:        FromGateResults(preview.Gates),
+	}
+	if preview.Current != nil {
+		current := FromDeployment(preview.Current)
+		result.Current = &current
+	}
+	return result
+}
+
+// FromReleaseStepPreviews преобразует результат пробного запуска плана релиза
+func FromReleaseStepPreviews(previews []release.StepPreview) []ReleaseStepPreview {
+	result := make([]ReleaseStepPreview, 0, len(previews))
+	for _, preview := range previews {
+		result = append(result, ReleaseStepPreview{
+			StepID:  preview.StepID,
+			Project: preview.Project,
+			Preview: FromDeployPreview(preview.Preview),
+		})
+	}
+	return result
+}
+
+// FromPromotion преобразует запись о продвижении
+func FromPromotion(promotion *service.Promotion) Promotion {
+	return Promotion{
+		ID:           promotion.ID,
+		Project:      promotion.Project,
+		FromEnv:      promotion.FromEnv,
+		ToEnv:        promotion.ToEnv,
+		Ref:          promotion.Ref,
+		SHA:          promotion.SHA,
+		BuildVersion: promotion.BuildVersion,
+		PipelineID:   promotion.PipelineID,
+		JobID:        promotion.JobID,
+		JobName:      promotion.JobName,
+		JobURL:       promotion.JobURL,
+		JobStatus:    promotion.JobStatus,
+		Status:       promotion.Status,
+		Error:        promotion.Error,
+		Gates:        FromGateResults(promotion.Gates),
+		TriggeredBy:  promotion.TriggeredBy,
+		CreatedAt:    NewTime(promotion.CreatedAt),
+	}
+}
+
+// FromPromotions преобразует список записей о продвижениях
+func FromPromotions(promotions []*service.Promotion) []Promotion {
+	result := make([]Promotion, 0, len(promotions))
+	for _, promotion := range promotions {
+		result = append(result, FromPromotion(promotion))
+	}
+	return result
+}
+
+// FromSchedule преобразует расписание
+func FromSchedule(schedule scheduler.Schedule) Schedule {
+	return Schedule{
+		ID:             schedule.ID,
+		Type:           schedule.Type,
+		Project:        schedule.Project,
+		Environment:    schedule.Environment,
+		PipelineID:     schedule.PipelineID,
+		JobID:          schedule.JobID,
+		FromEnv:        schedule.FromEnv,
+		ToEnv:          schedule.ToEnv,
+		RunAt:          NewTime(schedule.RunAt),
+		Status:         schedule.Status,
+		Error:          schedule.Error,
+		ExecutedAt:     NewTime(schedule.ExecutedAt),
+		TriggeredJobID: schedule.TriggeredJobID,
+		JobName:        schedule.JobName,
+		JobStatus:      schedule.JobStatus,
+		JobURL:         schedule.JobURL,
+		PromotionID:    schedule.PromotionID,
+		CreatedBy:      schedule.CreatedBy,
+		RunAs:          schedule.RunAs,
+		CreatedAt:      NewTime(schedule.CreatedAt),
+	}
+}
+
+// FromSchedules преобразует список расписаний
+func FromSchedules(schedules []scheduler.Schedule) []Schedule {
+	result := make([]Schedule, 0, len(schedules))
+	for _, schedule := range schedules {
+		result = append(result, FromSchedule(schedule))
+	}
+	return result
+}
+
+// FromReleasePlan преобразует план релиза; depends_on всегда массив, а не null
+func FromReleasePlan(plan release.Plan) ReleasePlan {
+	steps := make([]ReleaseStep, 0, len(plan.Steps))
+	for _, step := range plan.Steps {
+		dependsOn := step.DependsOn
+		if dependsOn == nil {
+			dependsOn = []string{}
+		}
+		steps = append(steps, ReleaseStep{
+			ID:            step.ID,
+			Project:       step.Project,
+			Environment:   step.Environment,
+			PipelineID:    step.PipelineID,
+			Ref:           step.Ref,
+			DependsOn:     dependsOn,
+			Status:        step.Status,
+			Error:         step.Error,
+			JobID:         step.JobID,
+			JobURL:        step.JobURL,
+			JobStatus:     step.JobStatus,
+			PreviousJobID: step.PreviousJobID,
+			RollbackJobID: step.RollbackJobID,
+			StartedAt:     NewTime(step.StartedAt),
+			FinishedAt:    NewTime(step.FinishedAt),
+			RolledBackAt:  NewTime(step.RolledBackAt),
+		})
+	}
+
+	return ReleasePlan{
+		ID:            plan.ID,
+		Name:          plan.Name,
+		FailurePolicy: plan.Policy,
+		StepTimeout:   plan.StepTimeout.String(),
+		Status:        plan.Status,
+		Steps:         steps,
+		CreatedBy:     plan.CreatedBy,
+		RunAs:         plan.RunAs,
+		CreatedAt:     NewTime(plan.CreatedAt),
+		FinishedAt:    NewTime(plan.FinishedAt),
+	}
+}
+
+// FromReleasePlans преобразует список планов релиза
+func FromReleasePlans(plans []release.Plan) []ReleasePlan {
+	result := make([]ReleasePlan, 0, len(plans))
+	for _, plan := range plans {
+		result = append(result, FromReleasePlan(plan))
+	}
+	return result
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/auth"
+)
+
+// AdminHandler - административные эндпоинты сервиса
+type AdminHandler struct {
+	store    *config.Store
+	reloader *config.Reloader
+}
+
+// NewAdminHandler создаёт административный обработчик
+func NewAdminHandler(store *config.Store, reloader *config.Reloader) *AdminHandler {
+	return &AdminHandler{store: store, reloader: reloader}
+}
+
+// ConfigStatusResponse - состояние конфигурации и история перезагрузок
+type ConfigStatusResponse struct {
+	Source   string                `json:"source"`
+	Projects []string              `json:"projects"`
+	Reloads  []config.ReloadResult `json:"reloads"`
+}
+
+// GetConfigStatus возвращает источник и проекты действующей конфигурации и последние перезагрузки
+func (h *AdminHandler) GetConfigStatus(c *fiber.Ctx) error {
+	cfg := h.store.Current()
+
+	response := ConfigStatusResponse{Source: cfg.Source, Reloads: h.reloader.History()}
+	for _, project := range cfg.ProjectList() {
+		response.Projects = append(response.Projects, project.Name)
+	}
+
+	return c.JSON(response)
+}
+
+// ReloadConfig перечитывает конфигурацию; некорректная конфигурация отклоняется с 422
+func (h *AdminHandler) ReloadConfig(c *fiber.Ctx) error {
+	result := h.reloader.Reload(config.TriggerAPI + ":" + auth.Identity(c))
+	if result.Status != config.ReloadApplied {
+		return c.Status(http.StatusUnprocessableEntity).JSON(result)
+	}
+	return c.JSON(result)
+}
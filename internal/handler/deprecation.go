@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/metrics"
+)
+
+// Deprecated помечает неверсионированные маршруты устаревшими: добавляет заголовки
+// Deprecation, Sunset и Link на маршрут /api/v1, а после отключения (api.disable_legacy)
+// отвечает 404 с указанием нового маршрута
+func Deprecated(settings func() config.APIConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		cfg := settings()
+		successor := V1Prefix + c.Path()
+
+		if cfg.DisableLegacy {
+			return respondError(c, apperror.NotFound("маршрут %s отключён, используйте %s", c.Path(), successor))
+		}
+
+		c.Set("Deprecation", "true")
+		if !cfg.LegacySunset.IsZero() {
+			c.Set("Sunset", cfg.LegacySunset.UTC().Format(http.TimeFormat))
+		}
+		c.Append(fiber.HeaderLink, fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+
+		err := c.Next()
+		metrics.DeprecatedRequests.WithLabelValues(c.Route().Path).Inc()
+		return err
+	}
+}
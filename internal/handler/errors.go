@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/dto"
+)
+
+// ErrorResponse - формат ответа сервиса с ошибкой
+type ErrorResponse struct {
+	Error          string `json:"error"`                     // Локализованное описание
+	Code           string `json:"code"`                      // Стабильный машиночитаемый код
+	Message        string `json:"message,omitempty"`         // Подробности (в т.ч. сообщение GitLab)
+	UpstreamStatus int    `json:"upstream_status,omitempty"` // HTTP-статус ответа GitLab
+	RequestID      string `json:"request_id,omitempty"`      // X-Request-ID для поиска в логах
+	Details        any    `json:"details,omitempty"`         // Данные об ошибке (например, итог ожидания джобы)
+}
+
+// respondError отправляет клиенту ошибку с HTTP-статусом, соответствующим её категории
+func respondError(c *fiber.Ctx, err error) error {
+	appErr := apperror.From(err)
+
+	if appErr.RetryAfter != "" {
+		c.Set(fiber.HeaderRetryAfter, appErr.RetryAfter)
+	}
+
+	return c.Status(appErr.HTTPStatus()).JSON(ErrorResponse{
+		Error:          apperror.Localize(appErr.Kind, requestLang(c)),
+		Code:           appErr.Code(),
+		Message:        errorDetails(appErr),
+		UpstreamStatus: appErr.UpstreamStatus,
+		RequestID:      c.GetRespHeader(fiber.HeaderXRequestID),
+		Details:        appErr.Details,
+	})
+}
+
+// itemError описывает ошибку по одному элементу частичного ответа
+func itemError(err error) *dto.ItemError {
+	appErr := apperror.From(err)
+	return &dto.ItemError{Code: appErr.Code(), Message: errorDetails(appErr)}
+}
+
+// errorDetails возвращает подробности ошибки, не раскрывая внутренние ошибки сервиса
+func errorDetails(appErr *apperror.Error) string {
+	if appErr.Kind == apperror.KindInternal {
+		return ""
+	}
+
+	if appErr.Message == "" && appErr.Err != nil {
+		return appErr.Err.Error()
+	}
+	return appErr.Message
+}
+
+// requestLang определяет язык ответа по параметру lang или заголовку Accept-Language
+func requestLang(c *fiber.Ctx) string {
+	if lang := strings.ToLower(c.Query("lang")); lang == apperror.LangRU || lang == apperror.LangEN {
+		return lang
+	}
+
+	for _, part := range strings.Split(c.Get(fiber.HeaderAcceptLanguage), ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		primary := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+		if primary == apperror.LangRU || primary == apperror.LangEN {
+			return primary
+		}
+	}
+
+	return apperror.LangRU
+}
+
+// ErrorHandler - обработчик ошибок Fiber, отвечающий в едином формате (неизвестный маршрут, паника и т.п.)
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		kind := apperror.KindInternal
+		switch fiberErr.Code {
+		case fiber.StatusNotFound:
+			kind = apperror.KindNotFound
+		case fiber.StatusBadRequest, fiber.StatusMethodNotAllowed, fiber.StatusRequestEntityTooLarge:
+			kind = apperror.KindValidation
+		case fiber.StatusUnauthorized:
+			kind = apperror.KindUnauthorized
+		case fiber.StatusForbidden:
+			kind = apperror.KindForbidden
+		}
+
+		return c.Status(fiberErr.Code).JSON(ErrorResponse{
+			Error:     apperror.Localize(kind, requestLang(c)),
+			Code:      string(kind),
+			Message:   fiberErr.Message,
+			RequestID: c.GetRespHeader(fiber.HeaderXRequestID),
+		})
+	}
+
+	return respondError(c, err)
+}
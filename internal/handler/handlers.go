@@ -2,128 +2,214 @@ package handler
 
 import (
 	"context"
-	"net/http"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/rs/zerolog/log"
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/auth"
+	"github.com/vkr-mtuci/gitlab-service/internal/dto"
+	"github.com/vkr-mtuci/gitlab-service/internal/operation"
 	"github.com/vkr-mtuci/gitlab-service/internal/service"
 )
 
 // GitLabHandler - обработчик запросов для GitLab
 type GitLabHandler struct {
-	service *service.GitLabService
+	services   *service.Registry
+	operations *operation.Manager
 }
 
-// NewGitLabHandler создаёт новый обработчик
-func NewGitLabHandler(service *service.GitLabService) *GitLabHandler {
-	return &GitLabHandler{service: service}
+// NewGitLabHandler создаёт новый обработчик; operations ожидают завершения запущенных джоб
+func NewGitLabHandler(services *service.Registry, operations *operation.Manager) *GitLabHandler {
+	return &GitLabHandler{services: services, operations: operations}
+}
+
+// projectService возвращает сервис проекта из параметра ?project= (по умолчанию - основной проект)
+func (h *GitLabHandler) projectService(c *fiber.Ctx) (*service.GitLabService, error) {
+	return h.services.Get(c.Query("project"))
 }
 
 // GetEnvironments обрабатывает запрос списка окружений
 func (h *GitLabHandler) GetEnvironments(c *fiber.Ctx) error {
-	environments, err := h.service.GetEnvironments()
+	svc, err := h.projectService(c)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	environments, err := svc.GetEnvironments(c.UserContext())
 	if err != nil {
 		log.Error().Err(err).Msg("❌ Ошибка получения окружений GitLab")
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Ошибка при получении окружений",
-		})
+		return respondError(c, err)
+	}
+
+	// Без page/per_page отдаём весь список (как раньше), но с фильтрами и сортировкой
+	environments, _, err = applyList(c, environmentList, environments, 0)
+	if err != nil {
+		return respondError(c, err)
 	}
 	return c.JSON(fiber.Map{"environments": environments})
 }
 
 // GetEnvironmentDetails обрабатывает запрос детальной информации об окружении
 func (h *GitLabHandler) GetEnvironmentDetails(c *fiber.Ctx) error {
+	svc, err := h.projectService(c)
+	if err != nil {
+		return respondError(c, err)
+	}
+
 	environmentID := c.Params("id")
 	if environmentID == "" {
 		log.Warn().Msg("⚠️ Не указан ID окружения")
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Необходимо указать environment_id",
-		})
+		return respondError(c, apperror.BadRequest("Необходимо указать environment_id"))
 	}
 
-	envDetails, err := h.service.GetEnvironmentDetails(environmentID)
+	envDetails, err := svc.GetEnvironmentDetails(c.UserContext(), environmentID)
 	if err != nil {
 		log.Error().Err(err).Msgf("❌ Ошибка получения деталей окружения %s", environmentID)
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Ошибка при получении данных окружения",
-		})
+		return respondError(c, err)
 	}
 
 	return c.JSON(envDetails)
 }
 
-// GetCommitsInBuild обрабатывает запрос на получение списка коммитов в сборке
+// GetCommitsInBuild обрабатывает запрос на получение списка коммитов в сборке.
+// С ?with_merge_requests=true к коммитам добавляются их merge request'ы.
 func (h *GitLabHandler) GetCommitsInBuild(c *fiber.Ctx) error {
+	svc, err := h.projectService(c)
+	if err != nil {
+		return respondError(c, err)
+	}
+
 	ref := c.Params("ref")
 	sha := c.Params("sha")
 
 	if ref == "" || sha == "" {
 		log.Warn().Msg("⚠️ Не указаны ref и sha")
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Необходимо указать ref и sha",
-		})
+		return respondError(c, apperror.BadRequest("Необходимо указать ref и sha"))
 	}
 
-	commits, err := h.service.GetCommitsInBuild(ref, sha)
+	commits, err := svc.GetCommitsInBuild(c.UserContext(), ref, sha)
 	if err != nil {
 		log.Error().Err(err).Msgf("❌ Ошибка получения коммитов для сборки %s", sha)
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Ошибка при получении коммитов",
-		})
+		return respondError(c, err)
+	}
+
+	commits, _, err = applyList(c, commitList, commits, 0)
+	if err != nil {
+		return respondError(c, err)
 	}
 
-	return c.JSON(fiber.Map{"commits": commits})
+	result := make([]CommitResponse, len(commits))
+	for i := range commits {
+		result[i] = CommitResponse{CommitInfo: commits[i]}
+	}
+	if c.QueryBool("with_merge_requests") {
+		mergeRequests, err := svc.CommitMergeRequests(c.UserContext(), commits)
+		if err != nil {
+			return respondError(c, err)
+		}
+		for i := range result {
+			result[i].MergeRequests = dto.FromMergeRequestRefs(mergeRequests[i])
+		}
+	}
+
+	return c.JSON(fiber.Map{"commits": result})
+}
+
+// CommitResponse - коммит сборки в ответе устаревшего маршрута и его merge request'ы
+type CommitResponse struct {
+	adapter.CommitInfo
+	MergeRequests []dto.MergeRequestRef `json:"merge_requests,omitempty"` // ?with_merge_requests=true
 }
 
 // GetDeployJobs обрабатывает запрос на получение списка deploy-джоб
 func (h *GitLabHandler) GetDeployJobs(c *fiber.Ctx) error {
+	svc, err := h.projectService(c)
+	if err != nil {
+		return respondError(c, err)
+	}
+
 	pipelineID := c.Params("pipeline_id")
 
 	if pipelineID == "" {
 		log.Warn().Msg("⚠️ Не указан pipeline_id")
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Необходимо указать pipeline_id",
-		})
+		return respondError(c, apperror.BadRequest("Необходимо указать pipeline_id"))
 	}
 
 	// Создаём контекст с таймаутом
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
-	deployJobs, err := h.service.GetDeployJobs(ctx, pipelineID)
+	deployJobs, err := svc.GetDeployJobs(ctx, pipelineID)
 	if err != nil {
 		log.Error().Err(err).Msgf("❌ Ошибка получения deploy-джоб для pipelineID=%s", pipelineID)
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Ошибка при получении deploy-джоб",
-		})
+		return respondError(c, err)
+	}
+
+	deployJobs, _, err = applyList(c, deployJobList, deployJobs, 0)
+	if err != nil {
+		return respondError(c, err)
 	}
 
 	return c.JSON(fiber.Map{"deploy_jobs": deployJobs})
 }
 
-// TriggerDeployJob запускает deploy-джобу
+// TriggerDeployJob запускает deploy-джобу. Параметры wait, async, timeout и dry_run работают
+// как в /api/v1/jobs/:job_id/play, ответ - без конверта.
 func (h *GitLabHandler) TriggerDeployJob(c *fiber.Ctx) error {
+	svc, err := h.projectService(c)
+	if err != nil {
+		return respondError(c, err)
+	}
+
 	jobID := c.Params("job_id")
 
 	if jobID == "" {
 		log.Warn().Msg("⚠️ Не указан job_id")
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Необходимо указать job_id",
-		})
+		return respondError(c, apperror.BadRequest("Необходимо указать job_id"))
 	}
 
-	// Создаём контекст с таймаутом
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	if c.QueryBool("dry_run") {
+		preview, err := previewDeployJob(c, svc, jobID)
+		if err != nil {
+			return respondError(c, err)
+		}
+		return c.JSON(preview)
+	}
 
-	jobInfo, err := h.service.TriggerDeployJob(ctx, jobID)
+	async := c.QueryBool("async")
+	wait := c.QueryBool("wait") || async
+	timeout, err := waitTimeout(c.Query("timeout"))
 	if err != nil {
-		log.Error().Err(err).Msgf("❌ Ошибка запуска deploy-джобы jobID=%s", jobID)
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Ошибка при запуске deploy-джобы",
-		})
+		return respondError(c, err)
 	}
 
-	return c.JSON(jobInfo)
+	// Проверки и запуск ограничены своими таймаутами в сервисе (см. service.TriggerDeployJob)
+	jobInfo, gates, err := svc.TriggerDeployJob(c.UserContext(), jobID)
+	if err != nil {
+		log.Error().Err(err).Str("identity", auth.Identity(c)).Msgf("❌ Ошибка запуска deploy-джобы jobID=%s", jobID)
+		return respondError(c, err)
+	}
+
+	log.Info().Str("identity", auth.Identity(c)).Msgf("🚀 Deploy-джоба jobID=%s запущена по запросу клиента", jobID)
+	if !wait {
+		return c.JSON(TriggeredJobResponse{TriggeredJob: jobInfo, Gates: gates})
+	}
+
+	if async {
+		return c.Status(fiber.StatusAccepted).JSON(startWaitOperation(c, h.operations, svc, jobInfo.ID, gates, timeout))
+	}
+
+	run, err := awaitDeployJob(c, h.operations, svc, jobInfo.ID, gates, timeout)
+	if err != nil {
+		return respondError(c, err)
+	}
+	return c.JSON(run)
+}
+
+// TriggeredJobResponse - ответ устаревшего маршрута запуска: джоба GitLab и результаты проверок качества
+type TriggeredJobResponse struct {
+	*adapter.TriggeredJob
+	Gates []service.GateResult `json:"gates,omitempty"`
 }
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"github.com/vkr-mtuci/gitlab-service/internal/health"
+)
+
+// HealthHandler - обработчик проверок живости и готовности сервиса
+type HealthHandler struct {
+	checks *health.Registry
+}
+
+// NewHealthHandler создаёт обработчик проверок
+func NewHealthHandler(checks *health.Registry) *HealthHandler {
+	return &HealthHandler{checks: checks}
+}
+
+// Liveness отвечает, что процесс жив (без обращения к зависимостям)
+func (h *HealthHandler) Liveness(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"status": health.StatusOK})
+}
+
+// Readiness проверяет доступность GitLab, токен, проект и хранилище
+func (h *HealthHandler) Readiness(c *fiber.Ctx) error {
+	report := h.checks.Run(c.UserContext())
+	if !report.Healthy() {
+		log.Warn().Interface("checks", report.Checks).Msg("⚠️ Сервис не готов")
+		return c.Status(http.StatusServiceUnavailable).JSON(report)
+	}
+	return c.JSON(report)
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/dto"
+	"github.com/vkr-mtuci/gitlab-service/internal/listing"
+)
+
+// environmentList - фильтры и сортировка списка окружений
+var environmentList = listing.Spec[adapter.Environment]{
+	Name:   func(e adapter.Environment) string { return e.Name },
+	State:  func(e adapter.Environment) string { return e.State },
+	States: []string{"available", "stopping", "stopped"},
+	Search: func(e adapter.Environment) []string { return []string{e.Name} },
+	Sort: map[string]func(a, b adapter.Environment) int{
+		"id":   listing.Compare(func(e adapter.Environment) int { return e.ID }),
+		"name": listing.Compare(func(e adapter.Environment) string { return e.Name }),
+	},
+}
+
+// deployJobList - фильтры и сортировка списка deploy-джоб (name - имя джобы, т.е. стенд)
+var deployJobList = listing.Spec[adapter.JobInfo]{
+	Name:  func(j adapter.JobInfo) string { return j.Stand },
+	State: func(j adapter.JobInfo) string { return j.Status },
+	States: []string{
+		"created", "pending", "preparing", "waiting_for_resource", "running",
+		"success", "failed", "canceled", "skipped", "manual", "scheduled",
+	},
+	Search: func(j adapter.JobInfo) []string { return []string{j.Stand, j.Stage} },
+	Sort: map[string]func(a, b adapter.JobInfo) int{
+		"id":          listing.Compare(func(j adapter.JobInfo) int { return j.ID }),
+		"name":        listing.Compare(func(j adapter.JobInfo) string { return j.Stand }),
+		"status":      listing.Compare(func(j adapter.JobInfo) string { return j.Status }),
+		"finished_at": listing.Compare(func(j adapter.JobInfo) int64 { return j.FinishedAt.UnixNano() }),
+	},
+}
+
+// commitList - фильтры и сортировка списка коммитов (без name и states)
+var commitList = listing.Spec[adapter.CommitInfo]{
+	Search: func(c adapter.CommitInfo) []string {
+		return append([]string{c.ID, c.Message, c.AuthorName, c.AuthorEmail}, c.JiraKeys...)
+	},
+	Sort: map[string]func(a, b adapter.CommitInfo) int{
+		"created_at":  listing.Compare(func(c adapter.CommitInfo) int64 { return dto.ParseTime(c.CreatedAt).UnixNano() }),
+		"author_name": listing.Compare(func(c adapter.CommitInfo) string { return c.AuthorName }),
+	},
+}
+
+// listQuery разбирает параметры выдачи списка из запроса
+func listQuery(c *fiber.Ctx, defaultPerPage int) (listing.Query, error) {
+	return listing.Parse(func(key string) string { return c.Query(key) }, defaultPerPage)
+}
+
+// applyList применяет параметры выдачи к списку и проставляет заголовки пагинации в стиле GitLab
+func applyList[T any](c *fiber.Ctx, spec listing.Spec[T], items []T, defaultPerPage int) ([]T, *dto.Pagination, error) {
+	query, err := listQuery(c, defaultPerPage)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	page, pagination, err := spec.Apply(items, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Список целиком (устаревшие маршруты без page/per_page) - без заголовков пагинации
+	if query.PerPage > 0 {
+		setPaginationHeaders(c, pagination)
+	}
+	return page, pagination, nil
+}
+
+// setPaginationHeaders выставляет X-Total, X-Total-Pages, X-Page, X-Per-Page, X-Next-Page
+// (только если есть следующая страница) и Link
+func setPaginationHeaders(c *fiber.Ctx, p *dto.Pagination) {
+	c.Set("X-Total", strconv.Itoa(p.Total))
+	c.Set("X-Total-Pages", strconv.Itoa(p.TotalPages))
+	c.Set("X-Page", strconv.Itoa(p.Page))
+	c.Set("X-Per-Page", strconv.Itoa(p.PerPage))
+	if p.NextPage != 0 {
+		c.Set("X-Next-Page", strconv.Itoa(p.NextPage))
+	}
+
+	links := []string{pageLink(c, 1, "first"), pageLink(c, p.TotalPages, "last")}
+	if p.Page > 1 {
+		links = append(links, pageLink(c, min(p.Page-1, p.TotalPages), "prev"))
+	}
+	if p.NextPage != 0 {
+		links = append(links, pageLink(c, p.NextPage, "next"))
+	}
+	// Append: Link может уже содержать rel="successor-version" от Deprecated
+	c.Append(fiber.HeaderLink, strings.Join(links, ", "))
+}
+
+// pageLink строит ссылку на страницу текущего списка с сохранением остальных параметров
+func pageLink(c *fiber.Ctx, page int, rel string) string {
+	query, _ := url.ParseQuery(string(c.Request().URI().QueryString()))
+	query.Set("page", strconv.Itoa(page))
+	return "<" + c.Path() + "?" + query.Encode() + `>; rel="` + rel + `"`
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/auth"
+	"github.com/vkr-mtuci/gitlab-service/internal/dto"
+	"github.com/vkr-mtuci/gitlab-service/internal/service"
+)
+
+// PromotionHandler - продвижение сборок между окружениями (API v1)
+type PromotionHandler struct {
+	services *service.Registry
+	store    *service.PromotionStore
+}
+
+// NewPromotionHandler создаёт обработчик продвижений; записи хранятся в store
+// (он переживает перезагрузку конфигурации, в отличие от сервисов проектов)
+func NewPromotionHandler(services *service.Registry, store *service.PromotionStore) *PromotionHandler {
+	return &PromotionHandler{services: services, store: store}
+}
+
+// PromotionRequest - тело запроса на продвижение сборки
+type PromotionRequest struct {
+	FromEnv string `json:"from_env"` // Окружение, сборку из которого продвигаем
+	ToEnv   string `json:"to_env"`   // Следующее окружение
+}
+
+// Create продвигает сборку, развёрнутую в from_env, в окружение to_env.
+// С ?dry_run=true только проверяет продвижение и возвращает, что будет запущено.
+func (h *PromotionHandler) Create(c *fiber.Ctx) error {
+	svc, err := h.services.Get(c.Query("project"))
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	var request PromotionRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, apperror.BadRequest("Некорректное тело запроса: %v", err))
+	}
+	request.FromEnv, request.ToEnv = strings.TrimSpace(request.FromEnv), strings.TrimSpace(request.ToEnv)
+	if request.FromEnv == "" || request.ToEnv == "" {
+		return respondError(c, apperror.BadRequest("Необходимо указать окружения from_env и to_env"))
+	}
+
+	if c.QueryBool("dry_run") {
+		preview, err := svc.PreviewPromotion(c.UserContext(), request.FromEnv, request.ToEnv, time.Now())
+		if err != nil {
+			return respondError(c, err)
+		}
+		return respond(c, dto.FromDeployPreview(preview), nil)
+	}
+
+	promotion, err := svc.Promote(c.UserContext(), request.FromEnv, request.ToEnv)
+	if promotion != nil {
+		// Неудачный запуск тоже сохраняем: по нему видно, какую джобу пытались запустить
+		promotion.TriggeredBy = auth.Identity(c)
+		h.store.Add(promotion)
+	}
+	if err != nil {
+		log.Error().Err(err).Str("identity", auth.Identity(c)).Msgf("❌ Ошибка продвижения сборки из %s в %s", request.FromEnv, request.ToEnv)
+		return respondError(c, err)
+	}
+
+	log.Info().Str("identity", auth.Identity(c)).Msgf("⏩ Продвижение %s: %s → %s", promotion.ID, promotion.FromEnv, promotion.ToEnv)
+	return c.Status(fiber.StatusCreated).JSON(dto.Envelope{
+		Data: dto.FromPromotion(promotion),
+		Meta: dto.Meta{APIVersion: dto.APIVersion, RequestID: c.GetRespHeader(fiber.HeaderXRequestID)},
+	})
+}
+
+// List возвращает последние продвижения (проекта из ?project=, иначе всех)
+func (h *PromotionHandler) List(c *fiber.Ctx) error {
+	promotions := h.store.List(c.Query("project"))
+	return respond(c, dto.FromPromotions(promotions), dto.SinglePage(len(promotions)))
+}
+
+// Get возвращает продвижение по идентификатору
+func (h *PromotionHandler) Get(c *fiber.Ctx) error {
+	promotion, err := h.store.Get(c.Params("id"))
+	if err != nil {
+		return respondError(c, err)
+	}
+	return respond(c, dto.FromPromotion(promotion), nil)
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/auth"
+	"github.com/vkr-mtuci/gitlab-service/internal/credentials"
+	"github.com/vkr-mtuci/gitlab-service/internal/dto"
+	"github.com/vkr-mtuci/gitlab-service/internal/release"
+)
+
+// ReleaseHandler - планы релиза нескольких проектов (API v1)
+type ReleaseHandler struct {
+	releases *release.Manager
+}
+
+// NewReleaseHandler создаёт обработчик планов релиза
+func NewReleaseHandler(releases *release.Manager) *ReleaseHandler {
+	return &ReleaseHandler{releases: releases}
+}
+
+// ReleasePlanRequest - тело запроса на запуск плана релиза
+type ReleasePlanRequest struct {
+	Name          string               `json:"name"`
+	FailurePolicy string               `json:"failure_policy"` // stop (по умолчанию) | continue | rollback
+	StepTimeout   string               `json:"step_timeout"`   // Сколько ждать deploy-джобу шага, например 30m
+	Steps         []ReleaseStepRequest `json:"steps"`
+}
+
+// ReleaseStepRequest - шаг плана релиза
+type ReleaseStepRequest struct {
+	ID          string   `json:"id"`
+	Project     string   `json:"project"` // Пусто - проект по умолчанию
+	Environment string   `json:"environment"`
+	PipelineID  int      `json:"pipeline_id"` // Пайплайн сборки
+	Ref         string   `json:"ref"`         // Или ветка/тег версии: берётся последний пайплайн
+	DependsOn   []string `json:"depends_on"`
+}
+
+// Create проверяет план и запускает его; ход выполнения - в GET /release-plans/{id}.
+// С ?dry_run=true только проверяет шаги и возвращает, что каждый из них запустит.
+func (h *ReleaseHandler) Create(c *fiber.Ctx) error {
+	var request ReleasePlanRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, apperror.BadRequest("Некорректное тело запроса: %v", err))
+	}
+
+	plan := release.Plan{Name: request.Name, Policy: request.FailurePolicy, CreatedBy: auth.Identity(c)}
+	// Джобы запускаются от имени пользователя, создавшего план (режим sudo)
+	if actor, ok := credentials.ActorFrom(c.UserContext()); ok {
+		plan.RunAs = actor.Sudo
+	}
+	if request.StepTimeout != "" {
+		timeout, err := time.ParseDuration(request.StepTimeout)
+		if err != nil {
+			return respondError(c, apperror.BadRequest("Некорректный step_timeout %q", request.StepTimeout))
+		}
+		plan.StepTimeout = timeout
+	}
+	for _, step := range request.Steps {
+		plan.Steps = append(plan.Steps, release.Step{
+			ID:          step.ID,
+			Project:     step.Project,
+			Environment: step.Environment,
+			PipelineID:  step.PipelineID,
+			Ref:         step.Ref,
+			DependsOn:   step.DependsOn,
+		})
+	}
+
+	if c.QueryBool("dry_run") {
+		previews, err := h.releases.Preview(c.UserContext(), plan)
+		if err != nil {
+			return respondError(c, err)
+		}
+		return respond(c, dto.FromReleaseStepPreviews(previews), dto.SinglePage(len(previews)))
+	}
+
+	plan, err := h.releases.Create(plan, time.Now())
+	if err != nil {
+		log.Error().Err(err).Str("identity", auth.Identity(c)).Msg("❌ Ошибка запуска плана релиза")
+		return respondError(c, err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(dto.Envelope{
+		Data: dto.FromReleasePlan(plan),
+		Meta: dto.Meta{APIVersion: dto.APIVersion, RequestID: c.GetRespHeader(fiber.HeaderXRequestID)},
+	})
+}
+
+// List возвращает последние планы релиза
+func (h *ReleaseHandler) List(c *fiber.Ctx) error {
+	plans := h.releases.List()
+	return respond(c, dto.FromReleasePlans(plans), dto.SinglePage(len(plans)))
+}
+
+// Get возвращает состояние плана и его шагов
+func (h *ReleaseHandler) Get(c *fiber.Ctx) error {
+	plan, err := h.releases.Get(c.Params("id"))
+	if err != nil {
+		return respondError(c, err)
+	}
+	return respond(c, dto.FromReleasePlan(plan), nil)
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/auth"
+	"github.com/vkr-mtuci/gitlab-service/internal/credentials"
+	"github.com/vkr-mtuci/gitlab-service/internal/dto"
+	"github.com/vkr-mtuci/gitlab-service/internal/scheduler"
+)
+
+// ScheduleHandler - отложенные деплои и продвижения (API v1)
+type ScheduleHandler struct {
+	scheduler *scheduler.Scheduler
+}
+
+// NewScheduleHandler создаёт обработчик расписаний
+func NewScheduleHandler(scheduler *scheduler.Scheduler) *ScheduleHandler {
+	return &ScheduleHandler{scheduler: scheduler}
+}
+
+// ScheduleRequest - тело запроса на создание расписания
+type ScheduleRequest struct {
+	Type        string    `json:"type"`        // deploy | promotion
+	RunAt       time.Time `json:"run_at"`      // Время запуска (RFC 3339)
+	Environment string    `json:"environment"` // deploy: целевое окружение
+	PipelineID  int       `json:"pipeline_id"` // deploy: пайплайн, в котором искать джобу окружения
+	JobID       int       `json:"job_id"`      // deploy: конкретная джоба (вместо поиска по пайплайну)
+	FromEnv     string    `json:"from_env"`    // promotion: исходное окружение
+	ToEnv       string    `json:"to_env"`      // promotion: следующее окружение
+}
+
+// Create планирует деплой или продвижение на заданное время.
+// С ?dry_run=true только проверяет расписание и возвращает, что будет запущено в run_at.
+func (h *ScheduleHandler) Create(c *fiber.Ctx) error {
+	var request ScheduleRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, apperror.BadRequest("Некорректное тело запроса: %v", err))
+	}
+
+	schedule := scheduler.Schedule{
+		Type:        request.Type,
+		Project:     c.Query("project"),
+		Environment: request.Environment,
+		PipelineID:  request.PipelineID,
+		JobID:       request.JobID,
+		FromEnv:     request.FromEnv,
+		ToEnv:       request.ToEnv,
+		RunAt:       request.RunAt,
+		CreatedBy:   auth.Identity(c),
+	}
+	// Запуск выполняется от имени пользователя, создавшего расписание (режим sudo)
+	if actor, ok := credentials.ActorFrom(c.UserContext()); ok {
+		schedule.RunAs = actor.Sudo
+	}
+	if c.QueryBool("dry_run") {
+		preview, err := h.scheduler.Preview(c.UserContext(), schedule, time.Now())
+		if err != nil {
+			return respondError(c, err)
+		}
+		return respond(c, dto.FromDeployPreview(preview), nil)
+	}
+
+	schedule, err := h.scheduler.Create(schedule, time.Now())
+	if err != nil {
+		log.Error().Err(err).Str("identity", auth.Identity(c)).Msg("❌ Ошибка создания расписания")
+		return respondError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.Envelope{
+		Data: dto.FromSchedule(schedule),
+		Meta: dto.Meta{APIVersion: dto.APIVersion, RequestID: c.GetRespHeader(fiber.HeaderXRequestID)},
+	})
+}
+
+// List возвращает расписания (проекта из ?project=, иначе всех) по времени запуска
+func (h *ScheduleHandler) List(c *fiber.Ctx) error {
+	schedules := h.scheduler.List(c.Query("project"))
+	return respond(c, dto.FromSchedules(schedules), dto.SinglePage(len(schedules)))
+}
+
+// Get возвращает расписание с результатом запуска
+func (h *ScheduleHandler) Get(c *fiber.Ctx) error {
+	schedule, err := h.scheduler.Get(c.Params("id"))
+	if err != nil {
+		return respondError(c, err)
+	}
+	return respond(c, dto.FromSchedule(schedule), nil)
+}
+
+// Cancel отменяет расписание, которое ещё не запускалось (иначе 409).
+// С ?dry_run=true только проверяет, что расписание можно отменить.
+func (h *ScheduleHandler) Cancel(c *fiber.Ctx) error {
+	if c.QueryBool("dry_run") {
+		schedule, err := h.scheduler.PreviewCancel(c.Params("id"))
+		if err != nil {
+			return respondError(c, err)
+		}
+		return respond(c, dto.FromSchedule(schedule), nil)
+	}
+
+	schedule, err := h.scheduler.Cancel(c.Params("id"))
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	log.Info().Str("identity", auth.Identity(c)).Msgf("🗓 Расписание %s отменено по запросу клиента", schedule.ID)
+	return respond(c, dto.FromSchedule(schedule), nil)
+}
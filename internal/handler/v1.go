@@ -0,0 +1,391 @@
+package handler
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/auth"
+	"github.com/vkr-mtuci/gitlab-service/internal/dto"
+	"github.com/vkr-mtuci/gitlab-service/internal/listing"
+	"github.com/vkr-mtuci/gitlab-service/internal/operation"
+	"github.com/vkr-mtuci/gitlab-service/internal/service"
+)
+
+// V1Prefix - префикс маршрутов API v1 (преемников неверсионированных маршрутов)
+const V1Prefix = "/api/v1"
+
+// V1Handler - обработчик версионированного API /api/v1: ответы в конверте dto.Envelope
+// со стабильными моделями из internal/dto
+type V1Handler struct {
+	services   *service.Registry
+	operations *operation.Manager
+}
+
+// NewV1Handler создаёт обработчик API v1; operations ожидают завершения запущенных джоб
+func NewV1Handler(services *service.Registry, operations *operation.Manager) *V1Handler {
+	return &V1Handler{services: services, operations: operations}
+}
+
+// respond отдаёт данные в конверте API v1
+func respond(c *fiber.Ctx, data any, pagination *dto.Pagination) error {
+	return c.JSON(dto.Envelope{
+		Data: data,
+		Meta: dto.Meta{
+			APIVersion: dto.APIVersion,
+			RequestID:  c.GetRespHeader(fiber.HeaderXRequestID),
+			Pagination: pagination,
+		},
+	})
+}
+
+// ListEnvironments возвращает окружения проекта
+func (h *V1Handler) ListEnvironments(c *fiber.Ctx) error {
+	svc, err := h.services.Get(c.Query("project"))
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	environments, err := svc.GetEnvironments(c.UserContext())
+	if err != nil {
+		log.Error().Err(err).Msg("❌ Ошибка получения окружений GitLab")
+		return respondError(c, err)
+	}
+
+	environments, pagination, err := applyList(c, environmentList, environments, listing.DefaultPerPage)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return respond(c, dto.FromEnvironments(environments), pagination)
+}
+
+// EnvironmentsOverview возвращает все окружения с последним деплоем, версией сборки,
+// блокировкой и заморозкой одним ответом. Фильтры и пагинация - как у списка окружений;
+// ошибки по отдельным окружениям попадают в элементы, а meta.partial = true.
+func (h *V1Handler) EnvironmentsOverview(c *fiber.Ctx) error {
+	svc, err := h.services.Get(c.Query("project"))
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	environments, err := svc.GetEnvironments(c.UserContext())
+	if err != nil {
+		log.Error().Err(err).Msg("❌ Ошибка получения окружений GitLab")
+		return respondError(c, err)
+	}
+
+	// Фильтруем до запросов деплоев, чтобы не ходить в GitLab за лишними окружениями
+	environments, pagination, err := applyList(c, environmentList, environments, listing.DefaultPerPage)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	overview := svc.DescribeEnvironments(c.UserContext(), environments)
+	items := make([]dto.EnvironmentOverview, 0, len(overview))
+	partial := false
+	for _, item := range overview {
+		result := dto.FromEnvironmentOverview(item)
+		if item.Err != nil {
+			result.Error = itemError(item.Err)
+			partial = true
+		}
+		items = append(items, result)
+	}
+
+	return c.JSON(dto.Envelope{
+		Data: items,
+		Meta: dto.Meta{
+			APIVersion: dto.APIVersion,
+			RequestID:  c.GetRespHeader(fiber.HeaderXRequestID),
+			Pagination: pagination,
+			Partial:    partial,
+		},
+	})
+}
+
+// CompareEnvironments показывает, какие коммиты и задачи Jira есть в окружении from,
+// но ещё не доехали до to, и не отстаёт ли from от to
+func (h *V1Handler) CompareEnvironments(c *fiber.Ctx) error {
+	svc, err := h.services.Get(c.Query("project"))
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	from, to := c.Query("from"), c.Query("to")
+	if from == "" || to == "" {
+		return respondError(c, apperror.BadRequest("Необходимо указать окружения from и to"))
+	}
+
+	comparison, err := svc.CompareEnvironments(c.UserContext(), from, to)
+	if err != nil {
+		log.Error().Err(err).Msgf("❌ Ошибка сравнения окружений %s и %s", from, to)
+		return respondError(c, err)
+	}
+
+	return respond(c, dto.FromEnvironmentComparison(comparison), nil)
+}
+
+// GetEnvironment возвращает последний деплой в окружение
+func (h *V1Handler) GetEnvironment(c *fiber.Ctx) error {
+	svc, err := h.services.Get(c.Query("project"))
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	environmentID := c.Params("id")
+	if environmentID == "" {
+		return respondError(c, apperror.BadRequest("Необходимо указать environment_id"))
+	}
+
+	info, err := svc.GetEnvironmentDetails(c.UserContext(), environmentID)
+	if err != nil {
+		log.Error().Err(err).Msgf("❌ Ошибка получения деталей окружения %s", environmentID)
+		return respondError(c, err)
+	}
+
+	return respond(c, dto.FromDeployment(info), nil)
+}
+
+// ListCommits возвращает коммиты сборки. С ?with_merge_requests=true к коммитам страницы
+// добавляются их merge request'ы.
+func (h *V1Handler) ListCommits(c *fiber.Ctx) error {
+	svc, err := h.services.Get(c.Query("project"))
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	ref, sha := c.Params("ref"), c.Params("sha")
+	if ref == "" || sha == "" {
+		return respondError(c, apperror.BadRequest("Необходимо указать ref и sha"))
+	}
+
+	commits, err := svc.GetCommitsInBuild(c.UserContext(), ref, sha)
+	if err != nil {
+		log.Error().Err(err).Msgf("❌ Ошибка получения коммитов для сборки %s", sha)
+		return respondError(c, err)
+	}
+
+	commits, pagination, err := applyList(c, commitList, commits, listing.DefaultPerPage)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	result := dto.FromCommits(commits)
+	if c.QueryBool("with_merge_requests") {
+		mergeRequests, err := svc.CommitMergeRequests(c.UserContext(), commits)
+		if err != nil {
+			return respondError(c, err)
+		}
+		for i := range result {
+			result[i].MergeRequests = dto.FromMergeRequestRefs(mergeRequests[i])
+		}
+	}
+
+	return respond(c, result, pagination)
+}
+
+// ReleaseNotes возвращает изменения сборки, сгруппированные по merge request
+func (h *V1Handler) ReleaseNotes(c *fiber.Ctx) error {
+	svc, err := h.services.Get(c.Query("project"))
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	ref, sha := c.Params("ref"), c.Params("sha")
+	if ref == "" || sha == "" {
+		return respondError(c, apperror.BadRequest("Необходимо указать ref и sha"))
+	}
+
+	notes, err := svc.ReleaseNotes(c.UserContext(), ref, sha)
+	if err != nil {
+		log.Error().Err(err).Msgf("❌ Ошибка сборки release notes для сборки %s", sha)
+		return respondError(c, err)
+	}
+
+	return respond(c, dto.FromReleaseNotes(notes), dto.SinglePage(len(notes)))
+}
+
+// ListDeployJobs возвращает deploy-джобы пайплайна с окружениями и разрешёнными для них ветками
+func (h *V1Handler) ListDeployJobs(c *fiber.Ctx) error {
+	svc, err := h.services.Get(c.Query("project"))
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	pipelineID := c.Params("pipeline_id")
+	if pipelineID == "" {
+		return respondError(c, apperror.BadRequest("Необходимо указать pipeline_id"))
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	jobs, err := svc.GetDeployJobs(ctx, pipelineID)
+	if err != nil {
+		log.Error().Err(err).Msgf("❌ Ошибка получения deploy-джоб для pipelineID=%s", pipelineID)
+		return respondError(c, err)
+	}
+
+	jobs, pagination, err := applyList(c, deployJobList, jobs, listing.DefaultPerPage)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	refs, err := svc.DescribeDeployJobRefs(ctx, jobs)
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	return respond(c, dto.FromDeployJobs(jobs, refs), pagination)
+}
+
+// Ожидание завершения джобы (?wait=true, ?async=true). Таймауты сервера его не ограничивают:
+// fasthttp ставит дедлайн записи (server.write_timeout) только после возврата из обработчика,
+// а дедлайн чтения (server.read_timeout) - на чтение запроса. Открытое соединение могут оборвать
+// прокси перед сервисом, поэтому долгие деплои лучше ждать операцией (?async=true).
+const (
+	DefaultWaitTimeout = 10 * time.Minute
+	MaxWaitTimeout     = 6 * time.Hour
+)
+
+// PlayJob запускает deploy-джобу. С ?wait=true ждёт её завершения (не дольше ?timeout=)
+// и возвращает итог, а с ?async=true - сразу отвечает 202 с операцией ожидания.
+// С ?dry_run=true только проверяет запуск и возвращает, что будет запущено.
+func (h *V1Handler) PlayJob(c *fiber.Ctx) error {
+	svc, err := h.services.Get(c.Query("project"))
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	jobID := c.Params("job_id")
+	if jobID == "" {
+		return respondError(c, apperror.BadRequest("Необходимо указать job_id"))
+	}
+
+	if c.QueryBool("dry_run") {
+		return h.previewJob(c, svc, jobID)
+	}
+
+	async := c.QueryBool("async")
+	wait := c.QueryBool("wait") || async
+	timeout, err := waitTimeout(c.Query("timeout"))
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	// Проверки и запуск ограничены своими таймаутами в сервисе (см. service.TriggerDeployJob)
+	job, gates, err := svc.TriggerDeployJob(c.UserContext(), jobID)
+	if err != nil {
+		log.Error().Err(err).Str("identity", auth.Identity(c)).Msgf("❌ Ошибка запуска deploy-джобы jobID=%s", jobID)
+		return respondError(c, err)
+	}
+
+	log.Info().Str("identity", auth.Identity(c)).Msgf("🚀 Deploy-джоба jobID=%s запущена по запросу клиента", jobID)
+	if !wait {
+		triggered := dto.FromTriggeredJob(job)
+		triggered.Gates = dto.FromGateResults(gates)
+		return respond(c, triggered, nil)
+	}
+
+	if async {
+		op := startWaitOperation(c, h.operations, svc, job.ID, gates, timeout)
+		return c.Status(fiber.StatusAccepted).JSON(dto.Envelope{
+			Data: op,
+			Meta: dto.Meta{APIVersion: dto.APIVersion, RequestID: c.GetRespHeader(fiber.HeaderXRequestID)},
+		})
+	}
+
+	run, err := awaitDeployJob(c, h.operations, svc, job.ID, gates, timeout)
+	if err != nil {
+		return respondError(c, err)
+	}
+	return respond(c, run, nil)
+}
+
+// startWaitOperation запускает фоновое ожидание джобы (?async=true) и указывает адрес операции в Location
+func startWaitOperation(c *fiber.Ctx, operations *operation.Manager, svc *service.GitLabService, jobID int, gates []service.GateResult, timeout time.Duration) dto.Operation {
+	op := operations.Start(svc, operation.Operation{
+		Project:   c.Query("project"),
+		JobID:     jobID,
+		Gates:     gates,
+		CreatedBy: auth.Identity(c),
+	}, timeout, time.Now())
+
+	c.Location(V1Prefix + "/operations/" + op.ID)
+	return dto.FromOperation(&op)
+}
+
+// awaitDeployJob ждёт завершения запущенной джобы не дольше timeout (?wait=true).
+// Итог неуспешной или не дождавшейся джобы передаётся в details ошибки.
+func awaitDeployJob(c *fiber.Ctx, operations *operation.Manager, svc *service.GitLabService, jobID int, gates []service.GateResult, timeout time.Duration) (dto.JobRun, error) {
+	ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+	defer cancel()
+
+	run, err := operations.Await(ctx, svc, jobID)
+	if err != nil {
+		log.Warn().Err(err).Str("identity", auth.Identity(c)).Msgf("⚠️ Deploy-джоба jobID=%d не завершилась успешно", jobID)
+		if run != nil {
+			err = apperror.WithDetails(err, dto.FromJobRun(run, gates))
+		}
+		return dto.JobRun{}, err
+	}
+	return dto.FromJobRun(run, gates), nil
+}
+
+// previewJob - пробный запуск джобы: все проверки без вызова play в GitLab
+func (h *V1Handler) previewJob(c *fiber.Ctx, svc *service.GitLabService, jobID string) error {
+	preview, err := previewDeployJob(c, svc, jobID)
+	if err != nil {
+		return respondError(c, err)
+	}
+	return respond(c, preview, nil)
+}
+
+// previewDeployJob проверяет запуск джобы jobID (?dry_run=true), не вызывая play в GitLab
+func previewDeployJob(c *fiber.Ctx, svc *service.GitLabService, jobID string) (dto.DeployPreview, error) {
+	id, err := strconv.Atoi(jobID)
+	if err != nil {
+		return dto.DeployPreview{}, apperror.BadRequest("Некорректный job_id %q", jobID)
+	}
+
+	preview, err := svc.PreviewJob(c.UserContext(), id)
+	if err != nil {
+		log.Warn().Err(err).Str("identity", auth.Identity(c)).Msgf("⚠️ Пробный запуск deploy-джобы jobID=%s не прошёл проверки", jobID)
+		return dto.DeployPreview{}, err
+	}
+	return dto.FromDeployPreview(preview), nil
+}
+
+// GetOperation возвращает состояние операции ожидания джобы
+func (h *V1Handler) GetOperation(c *fiber.Ctx) error {
+	op, err := h.operations.Get(c.Params("id"), auth.Identity(c))
+	if err != nil {
+		return respondError(c, err)
+	}
+
+	result := dto.FromOperation(&op)
+	if op.Err != nil {
+		result.Error = itemError(op.Err)
+	}
+	return respond(c, result, nil)
+}
+
+// waitTimeout разбирает ?timeout= (по умолчанию DefaultWaitTimeout, не больше MaxWaitTimeout)
+func waitTimeout(value string) (time.Duration, error) {
+	if value == "" {
+		return DefaultWaitTimeout, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return 0, apperror.BadRequest("Некорректный timeout %q (ожидается длительность, например 10m)", value)
+	}
+	if timeout > MaxWaitTimeout {
+		return 0, apperror.Validation("timeout не может превышать %s", MaxWaitTimeout)
+	}
+	return timeout, nil
+}
@@ -0,0 +1,78 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+)
+
+// requiredScope - scope токена, необходимый для запуска джоб
+const requiredScope = "api"
+
+// GitLabProber - методы клиента GitLab, используемые проверками готовности
+type GitLabProber interface {
+	GetCurrentUser(ctx context.Context) (*adapter.User, error)
+	GetProject(ctx context.Context) (*adapter.Project, error)
+	GetTokenInfo(ctx context.Context) (*adapter.TokenInfo, error)
+	CredentialKind() string
+}
+
+// RegisterGitLabChecks добавляет проверки токена, проекта и прав доступа к GitLab.
+// project добавляется к именам проверок, если настроено несколько проектов.
+func RegisterGitLabChecks(r *Registry, project string, client GitLabProber) {
+	name := func(check string) string {
+		if project == "" {
+			return check
+		}
+		return check + ":" + project
+	}
+
+	r.Register(name("gitlab_token"), func(ctx context.Context) error {
+		// CI job token не даёт доступа к /user; его проверяет gitlab_project
+		if client.CredentialKind() == config.CredentialJob {
+			return nil
+		}
+		user, err := client.GetCurrentUser(ctx)
+		if err != nil {
+			return err
+		}
+		if user.State != "" && user.State != "active" {
+			return fmt.Errorf("пользователь токена %s в состоянии %s", user.Username, user.State)
+		}
+		return nil
+	})
+
+	r.Register(name("gitlab_project"), func(ctx context.Context) error {
+		project, err := client.GetProject(ctx)
+		if err != nil {
+			return err
+		}
+		if project.AccessLevel() < adapter.AccessLevelDeveloper {
+			return fmt.Errorf("недостаточный уровень доступа к проекту %s: %d (нужен Developer или выше)",
+				project.PathWithNamespace, project.AccessLevel())
+		}
+		return nil
+	})
+
+	r.Register(name("gitlab_token_scopes"), func(ctx context.Context) error {
+		token, err := client.GetTokenInfo(ctx)
+		if err != nil {
+			// Старые версии GitLab и OAuth-токены не поддерживают /personal_access_tokens/self
+			if apperror.From(err).Kind == apperror.KindNotFound {
+				return nil
+			}
+			return err
+		}
+		if !token.Active || token.Revoked {
+			return fmt.Errorf("токен %s неактивен или отозван", token.Name)
+		}
+		if !token.HasScope(requiredScope) {
+			return fmt.Errorf("у токена %s нет scope %q, необходимого для запуска джоб (есть: %v)",
+				token.Name, requiredScope, token.Scopes)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,144 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Статусы проверок
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// CheckFunc - функция проверки зависимости; nil означает, что зависимость в порядке
+type CheckFunc func(ctx context.Context) error
+
+// Result - результат одной проверки
+type Result struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Report - итог проверки готовности сервиса
+type Report struct {
+	Status    string    `json:"status"`
+	CheckedAt time.Time `json:"checked_at"`
+	Checks    []Result  `json:"checks"`
+}
+
+// Healthy возвращает true, если все проверки прошли
+func (r Report) Healthy() bool {
+	return r.Status == StatusOK
+}
+
+// check - зарегистрированная проверка
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+// Registry - набор проверок готовности с кэшированием результата,
+// чтобы частые пробы Kubernetes не нагружали GitLab
+type Registry struct {
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	mu     sync.Mutex
+	checks []check
+	last   *Report
+}
+
+// NewRegistry создаёт набор проверок; timeout ограничивает каждую проверку,
+// cacheTTL - время, в течение которого отдаётся предыдущий результат
+func NewRegistry(timeout, cacheTTL time.Duration) *Registry {
+	return &Registry{timeout: timeout, cacheTTL: cacheTTL}
+}
+
+// Register добавляет проверку (например, хранилища, если оно настроено)
+func (r *Registry) Register(name string, fn CheckFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checks = append(r.checks, check{name: name, fn: fn})
+	r.last = nil
+}
+
+// Replace заменяет все проверки проверками next одним шагом (после перезагрузки конфигурации),
+// чтобы Run не увидел промежуточный набор без проверок
+func (r *Registry) Replace(next *Registry) {
+	next.mu.Lock()
+	checks := append([]check(nil), next.checks...)
+	next.mu.Unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checks = checks
+	r.last = nil
+}
+
+// Run выполняет все проверки параллельно и возвращает отчёт
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.Lock()
+	if r.last != nil && time.Since(r.last.CheckedAt) < r.cacheTTL {
+		report := *r.last
+		r.mu.Unlock()
+		return report
+	}
+	checks := append([]check(nil), r.checks...)
+	r.mu.Unlock()
+
+	report := Report{
+		Status:    StatusOK,
+		CheckedAt: time.Now(),
+		Checks:    make([]Result, len(checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c check) {
+			defer wg.Done()
+			report.Checks[i] = r.runCheck(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != StatusOK {
+			report.Status = StatusFail
+			break
+		}
+	}
+
+	r.mu.Lock()
+	r.last = &report
+	r.mu.Unlock()
+
+	return report
+}
+
+// runCheck выполняет одну проверку с таймаутом и замером времени
+func (r *Registry) runCheck(ctx context.Context, c check) Result {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.fn(ctx)
+
+	result := Result{
+		Name:      c.name,
+		Status:    StatusOK,
+		LatencyMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	return result
+}
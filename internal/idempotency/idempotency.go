@@ -0,0 +1,196 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/auth"
+)
+
+// Заголовки идемпотентных запросов
+const (
+	HeaderKey      = "Idempotency-Key"     // Ключ, под которым клиент повторяет запрос
+	HeaderReplayed = "Idempotent-Replayed" // Ответ повторён из сохранённого
+)
+
+// MaxKeyLength - максимальная длина ключа идемпотентности
+const MaxKeyLength = 255
+
+// sweepInterval - как часто удалять ответы с истёкшим сроком хранения
+const sweepInterval = time.Minute
+
+// response - сохранённый ответ на запрос
+type response struct {
+	status      int
+	contentType string
+	location    string
+	body        []byte
+}
+
+// entry - запрос с ключом идемпотентности: выполняется (response == nil) или уже выполнен
+type entry struct {
+	fingerprint string
+	response    *response
+	expires     time.Time
+}
+
+// store - ответы на запросы с ключами идемпотентности (в памяти)
+type store struct {
+	mu        sync.Mutex
+	entries   map[string]*entry
+	lastSweep time.Time
+}
+
+// begin занимает ключ под новый запрос или возвращает сохранённый ответ.
+// Ключ с другим запросом даёт ошибку валидации, ключ выполняющегося запроса - конфликт.
+func (s *store) begin(key, fingerprint string, now time.Time) (*response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	e, ok := s.entries[key]
+	if !ok || e.expired(now) {
+		s.entries[key] = &entry{fingerprint: fingerprint}
+		return nil, nil
+	}
+	if e.fingerprint != fingerprint {
+		return nil, apperror.Validation("ключ идемпотентности уже использован с другим запросом")
+	}
+	if e.response == nil {
+		return nil, apperror.Conflict("запрос с этим ключом идемпотентности ещё выполняется")
+	}
+	return e.response, nil
+}
+
+// complete сохраняет ответ до expires
+func (s *store) complete(key string, resp *response, expires time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok {
+		e.response = resp
+		e.expires = expires
+	}
+}
+
+// release освобождает ключ: запрос можно повторить
+func (s *store) release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+}
+
+// sweep удаляет ответы с истёкшим сроком хранения не чаще sweepInterval (вызывается под блокировкой)
+func (s *store) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, e := range s.entries {
+		if e.expired(now) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// expired проверяет, истёк ли срок хранения ответа
+func (e *entry) expired(now time.Time) bool {
+	return e.response != nil && now.After(e.expires)
+}
+
+// Middleware повторяет сохранённый ответ на POST с заголовком Idempotency-Key вместо
+// повторного выполнения (например, двойной запуск джобы при повторе клиента).
+// Ключи различаются для разных клиентов. Без аутентификации клиентов (auth не настроена) все клиенты
+// анонимны и делят общую область ключей; при включённой аутентификации анонимный ключ отклоняется (401).
+// Ответы хранятся api.idempotency_ttl.
+// Сохраняются только успешные ответы (2xx) и 422: остальные ошибки (блокировка окружения,
+// статус джобы, сбой GitLab) со временем проходят, и запрос можно повторить с тем же ключом.
+func Middleware(settings func() config.APIConfig, authSettings func() config.AuthConfig, onError fiber.ErrorHandler) fiber.Handler {
+	responses := &store{entries: map[string]*entry{}}
+
+	return func(c *fiber.Ctx) error {
+		key := c.Get(HeaderKey)
+		if c.Method() != fiber.MethodPost || key == "" {
+			return c.Next()
+		}
+		if len(key) > MaxKeyLength {
+			return onError(c, apperror.BadRequest("ключ идемпотентности длиннее %d символов", MaxKeyLength))
+		}
+		if auth.Identity(c) == auth.Anonymous && authSettings().Enabled() {
+			return onError(c, apperror.Unauthorized("заголовок %s принимается только от аутентифицированных клиентов", HeaderKey))
+		}
+
+		scoped := auth.Identity(c) + "\x00" + key
+		stored, err := responses.begin(scoped, fingerprint(c), time.Now())
+		if err != nil {
+			return onError(c, err)
+		}
+		if stored != nil {
+			log.Info().Str("identity", auth.Identity(c)).Msgf("🔁 Повтор ответа по ключу идемпотентности для %s %s", c.Method(), c.Path())
+			return replay(c, stored)
+		}
+
+		completed := false
+		defer func() {
+			if !completed {
+				responses.release(scoped) // Ошибка или паника: запрос не выполнен, ключ свободен
+			}
+		}()
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if !storable(status) {
+			return nil
+		}
+
+		responses.complete(scoped, &response{
+			status:      status,
+			contentType: string(c.Response().Header.ContentType()),
+			location:    string(c.Response().Header.Peek(fiber.HeaderLocation)),
+			body:        append([]byte(nil), c.Response().Body()...),
+		}, time.Now().Add(settings().IdempotencyTTL))
+		completed = true
+		return nil
+	}
+}
+
+// storable проверяет, сохраняется ли ответ со статусом status для повтора
+func storable(status int) bool {
+	return status >= http.StatusOK && status < http.StatusMultipleChoices || status == http.StatusBadRequest || status == http.StatusUnprocessableEntity
+}
+
+// fingerprint - отпечаток запроса: метод, путь с параметрами и тело
+func fingerprint(c *fiber.Ctx) string {
+	h := sha256.New()
+	h.Write([]byte(c.Method()))
+	h.Write([]byte{0})
+	h.Write([]byte(c.OriginalURL()))
+	h.Write([]byte{0})
+	h.Write(c.Body())
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// replay отправляет сохранённый ответ
+func replay(c *fiber.Ctx, resp *response) error {
+	c.Set(HeaderReplayed, "true")
+	if resp.contentType != "" {
+		c.Set(fiber.HeaderContentType, resp.contentType)
+	}
+	if resp.location != "" {
+		c.Location(resp.location)
+	}
+	return c.Status(resp.status).Send(resp.body)
+}
@@ -0,0 +1,198 @@
+// Package listing - постраничная выдача, фильтрация и сортировка списков API
+// (окружения, deploy-джобы, коммиты). Списки целиком выгружаются из GitLab
+// адаптером, а здесь обрезаются под параметры запроса клиента.
+package listing
+
+import (
+	"cmp"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/dto"
+)
+
+// Размеры страниц
+const (
+	DefaultPerPage = 20
+	MaxPerPage     = 100
+)
+
+// Query - параметры выдачи списка из запроса клиента
+type Query struct {
+	Page    int      // Номер страницы, с 1
+	PerPage int      // Размер страницы; 0 - весь список одной страницей
+	Search  string   // Подстрока (без учёта регистра) в текстовых полях элемента
+	States  []string // Допустимые состояния элемента
+	Name    string   // Префикс имени (review/ или review/*) либо glob-шаблон (review/*-api)
+	Sort    string   // Поле сортировки; "-" в начале - по убыванию
+}
+
+// Parse разбирает параметры page, per_page, search, states, name и sort.
+// defaultPerPage - размер страницы, если клиент не указал ни page, ни per_page
+// (0 - отдать весь список, как неверсионированные маршруты до появления пагинации).
+func Parse(get func(key string) string, defaultPerPage int) (Query, error) {
+	q := Query{
+		Page:    1,
+		PerPage: defaultPerPage,
+		Search:  strings.TrimSpace(get("search")),
+		Name:    strings.TrimSpace(get("name")),
+		Sort:    strings.TrimSpace(get("sort")),
+	}
+
+	if value := get("page"); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 {
+			return Query{}, apperror.BadRequest("Параметр page должен быть целым числом больше 0")
+		}
+		q.Page = page
+		if q.PerPage == 0 {
+			q.PerPage = DefaultPerPage
+		}
+	}
+
+	if value := get("per_page"); value != "" {
+		perPage, err := strconv.Atoi(value)
+		if err != nil || perPage < 1 || perPage > MaxPerPage {
+			return Query{}, apperror.BadRequest("Параметр per_page должен быть целым числом от 1 до %d", MaxPerPage)
+		}
+		q.PerPage = perPage
+	}
+
+	for _, state := range strings.Split(get("states"), ",") {
+		if state = strings.TrimSpace(state); state != "" {
+			q.States = append(q.States, state)
+		}
+	}
+
+	return q, nil
+}
+
+// Spec - описание списка: какие поля участвуют в фильтрах и сортировке.
+// Неуказанный аксессор означает, что соответствующий фильтр для списка не поддерживается.
+type Spec[T any] struct {
+	Name   func(T) string              // Имя для фильтра name
+	State  func(T) string              // Состояние для фильтра states
+	States []string                    // Допустимые значения states
+	Search func(T) []string            // Поля для поиска search
+	Sort   map[string]func(a, b T) int // Поля сортировки
+}
+
+// Apply фильтрует, сортирует и обрезает список по параметрам запроса
+func (s Spec[T]) Apply(items []T, q Query) ([]T, *dto.Pagination, error) {
+	filtered, err := s.filter(items, q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if q.Sort != "" {
+		field, desc := strings.CutPrefix(q.Sort, "-")
+		compare, ok := s.Sort[field]
+		if !ok {
+			return nil, nil, apperror.Validation("Сортировка по полю %q не поддерживается (доступно: %s)", field, strings.Join(s.sortFields(), ", "))
+		}
+		slices.SortStableFunc(filtered, func(a, b T) int {
+			if desc {
+				return compare(b, a)
+			}
+			return compare(a, b)
+		})
+	}
+
+	return paginate(filtered, q)
+}
+
+// filter оставляет элементы, подходящие под search, states и name
+func (s Spec[T]) filter(items []T, q Query) ([]T, error) {
+	if q.Name != "" && s.Name == nil {
+		return nil, apperror.Validation("Фильтр name для этого списка не поддерживается")
+	}
+	if len(q.States) > 0 {
+		if s.State == nil {
+			return nil, apperror.Validation("Фильтр states для этого списка не поддерживается")
+		}
+		for _, state := range q.States {
+			if !slices.Contains(s.States, state) {
+				return nil, apperror.Validation("Недопустимое значение states %q (доступно: %s)", state, strings.Join(s.States, ", "))
+			}
+		}
+	}
+	if q.Search != "" && s.Search == nil {
+		return nil, apperror.Validation("Поиск search для этого списка не поддерживается")
+	}
+
+	match, err := nameMatcher(q.Name)
+	if err != nil {
+		return nil, err
+	}
+	search := strings.ToLower(q.Search)
+
+	result := make([]T, 0, len(items))
+	for _, item := range items {
+		if q.Name != "" && !match(s.Name(item)) {
+			continue
+		}
+		if len(q.States) > 0 && !slices.Contains(q.States, s.State(item)) {
+			continue
+		}
+		if search != "" && !slices.ContainsFunc(s.Search(item), func(field string) bool {
+			return strings.Contains(strings.ToLower(field), search)
+		}) {
+			continue
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+// sortFields возвращает поля сортировки для сообщения об ошибке
+func (s Spec[T]) sortFields() []string {
+	fields := make([]string, 0, len(s.Sort))
+	for field := range s.Sort {
+		fields = append(fields, field)
+	}
+	slices.Sort(fields)
+	return fields
+}
+
+// nameMatcher строит проверку имени: "review/" и "review/*" - префикс,
+// шаблон с другими метасимволами ("review/*-api", "stage-?") - path.Match
+func nameMatcher(pattern string) (func(string) bool, error) {
+	prefix := strings.TrimSuffix(pattern, "*")
+	if !strings.ContainsAny(prefix, `*?[\`) {
+		return func(name string) bool { return strings.HasPrefix(name, prefix) }, nil
+	}
+
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, apperror.Validation("Некорректный шаблон name %q", pattern)
+	}
+	return func(name string) bool {
+		ok, _ := path.Match(pattern, name)
+		return ok
+	}, nil
+}
+
+// paginate вырезает запрошенную страницу
+func paginate[T any](items []T, q Query) ([]T, *dto.Pagination, error) {
+	total := len(items)
+	if q.PerPage == 0 {
+		return items, dto.SinglePage(total), nil
+	}
+
+	totalPages := max((total+q.PerPage-1)/q.PerPage, 1)
+	pagination := &dto.Pagination{Page: q.Page, PerPage: q.PerPage, Total: total, TotalPages: totalPages}
+	if q.Page < totalPages {
+		pagination.NextPage = q.Page + 1
+	}
+
+	start := min((q.Page-1)*q.PerPage, total)
+	end := min(start+q.PerPage, total)
+	return items[start:end], pagination, nil
+}
+
+// Compare - сравнение для Spec.Sort по ключу элемента
+func Compare[T any, K cmp.Ordered](key func(T) K) func(a, b T) int {
+	return func(a, b T) int { return cmp.Compare(key(a), key(b)) }
+}
@@ -0,0 +1,31 @@
+package metrics
+
+import "sync"
+
+// cacheStats - счётчики попаданий для расчёта доли попаданий в кэш
+var cacheStats = struct {
+	sync.Mutex
+	hits   map[string]float64
+	misses map[string]float64
+}{hits: map[string]float64{}, misses: map[string]float64{}}
+
+// ObserveCache фиксирует обращение к кэшу и пересчитывает долю попаданий
+func ObserveCache(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	CacheRequests.WithLabelValues(cache, result).Inc()
+
+	cacheStats.Lock()
+	defer cacheStats.Unlock()
+
+	if hit {
+		cacheStats.hits[cache]++
+	} else {
+		cacheStats.misses[cache]++
+	}
+
+	total := cacheStats.hits[cache] + cacheStats.misses[cache]
+	CacheHitRatio.WithLabelValues(cache).Set(cacheStats.hits[cache] / total)
+}
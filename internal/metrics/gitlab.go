@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// InstrumentClient подключает к resty-клиенту сбор метрик запросов к GitLab API
+func InstrumentClient(client *resty.Client) {
+	client.OnAfterResponse(func(_ *resty.Client, resp *resty.Response) error {
+		observeGitLabRequest(resp.Request, strconv.Itoa(resp.StatusCode()), resp.Time())
+		return nil
+	})
+
+	client.OnError(func(req *resty.Request, _ error) {
+		observeGitLabRequest(req, "error", time.Since(req.Time))
+	})
+}
+
+// observeGitLabRequest записывает метрики одного запроса к GitLab
+func observeGitLabRequest(req *resty.Request, statusCode string, duration time.Duration) {
+	labels := []string{req.Method, Endpoint(req.URL), statusCode}
+	GitLabRequests.WithLabelValues(labels...).Inc()
+	GitLabRequestDuration.WithLabelValues(labels...).Observe(duration.Seconds())
+}
+
+// Endpoint приводит URL запроса к шаблону эндпоинта GitLab без идентификаторов,
+// например /api/v4/projects/1/jobs/201/trace -> /projects/:project/jobs/:id/trace
+func Endpoint(rawURL string) string {
+	path := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		path = u.EscapedPath()
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	// Отбрасываем префикс API (/api/v4)
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "v") && i > 0 && segments[i-1] == "api" {
+			segments = segments[i+1:]
+			break
+		}
+	}
+
+	for i, segment := range segments {
+		switch {
+		case i > 0 && segments[i-1] == "projects":
+			segments[i] = ":project"
+		case isNumeric(segment):
+			segments[i] = ":id"
+		case isSHA(segment):
+			segments[i] = ":sha"
+		}
+	}
+
+	return "/" + strings.Join(segments, "/")
+}
+
+// isNumeric проверяет, состоит ли строка только из цифр
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isSHA проверяет, похожа ли строка на SHA коммита
+func isSHA(s string) bool {
+	if len(s) != 40 {
+		return false
+	}
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,142 @@
+package metrics
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace - общий префикс всех метрик сервиса
+const namespace = "gitlab_service"
+
+// Значения меток для метрики запусков deploy-джоб
+const (
+	OutcomeTriggered   = "triggered" // GitLab принял запуск джобы
+	UnknownEnvironment = "unknown"   // Окружение не удалось определить
+)
+
+// Registry - реестр метрик сервиса (отдельный от глобального, чтобы не тянуть чужие метрики)
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests - количество обработанных HTTP-запросов по маршруту и статусу
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Количество обработанных HTTP-запросов.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestDuration - длительность обработки HTTP-запросов
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Длительность обработки HTTP-запросов.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// GitLabRequests - количество запросов к GitLab API по эндпоинту и коду ответа
+	GitLabRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "gitlab_requests_total",
+		Help:      "Количество запросов к GitLab API.",
+	}, []string{"method", "endpoint", "status_code"})
+
+	// GitLabRequestDuration - длительность запросов к GitLab API
+	GitLabRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "gitlab_request_duration_seconds",
+		Help:      "Длительность запросов к GitLab API.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"method", "endpoint", "status_code"})
+
+	// CacheRequests - обращения к кэшам сервиса (result = hit|miss)
+	CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Количество обращений к кэшу.",
+	}, []string{"cache", "result"})
+
+	// CacheHitRatio - доля попаданий в кэш с момента запуска
+	CacheHitRatio = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "cache_hit_ratio",
+		Help:      "Доля попаданий в кэш с момента запуска сервиса.",
+	}, []string{"cache"})
+
+	// DeployJobsTriggered - количество запущенных deploy-джоб по окружению и результату
+	DeployJobsTriggered = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "deploy_jobs_triggered_total",
+		Help:      "Количество запусков deploy-джоб (outcome - triggered или код ошибки).",
+	}, []string{"environment", "outcome"})
+
+	// CommitPagesFetched - количество страниц, загруженных за один вызов GetCommitsBetweenSHAs
+	CommitPagesFetched = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "commits_pages_fetched",
+		Help:      "Количество страниц коммитов, загруженных за один поиск коммитов между SHA.",
+		Buckets:   []float64{1, 2, 3, 5, 10, 20, 50, 100},
+	})
+
+	// DeprecatedRequests - запросы к устаревшим неверсионированным маршрутам
+	DeprecatedRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "deprecated_requests_total",
+		Help:      "Количество запросов к устаревшим маршрутам без версии.",
+	}, []string{"route"})
+
+	// PaginationTruncated - обходы списков GitLab, прерванные ограничением числа страниц
+	PaginationTruncated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "gitlab_pagination_truncated_total",
+		Help:      "Количество обходов списков GitLab, прерванных ограничением max_pages.",
+	}, []string{"list"})
+
+	// ScheduledRuns - выполненные отложенные деплои и продвижения по результату
+	ScheduledRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "scheduled_runs_total",
+		Help:      "Количество выполненных расписаний (outcome - triggered, failed, blocked или missed).",
+	}, []string{"type", "outcome"})
+
+	// TokenFailovers - переключения на резервные учётные данные GitLab после ответа 401
+	TokenFailovers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "gitlab_token_failovers_total",
+		Help:      "Количество переключений на резервные учётные данные GitLab.",
+	}, []string{"project", "kind"})
+
+	// TokenExpiresIn - сколько секунд осталось до истечения токена GitLab
+	TokenExpiresIn = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "gitlab_token_expires_in_seconds",
+		Help:      "Время до истечения токена GitLab (по /personal_access_tokens/self).",
+	}, []string{"project"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		GitLabRequests,
+		GitLabRequestDuration,
+		CacheRequests,
+		CacheHitRatio,
+		DeployJobsTriggered,
+		CommitPagesFetched,
+		TokenFailovers,
+		TokenExpiresIn,
+		DeprecatedRequests,
+		PaginationTruncated,
+		ScheduledRuns,
+	)
+}
+
+// Handler возвращает Fiber-обработчик эндпоинта /metrics
+func Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry}))
+}
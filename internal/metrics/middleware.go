@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+)
+
+// unmatchedRoute - метка для запросов, не попавших ни в один маршрут (чтобы не раздувать кардинальность)
+const unmatchedRoute = "unmatched"
+
+// Middleware собирает количество и длительность HTTP-запросов по шаблону маршрута
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		own := c.Route() // Если после c.Next() маршрут не сменился - ни один обработчик не подошёл
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			// Ошибка ещё не записана в ответ - статус определит ErrorHandler, вычисляем его так же
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			} else {
+				status = apperror.From(err).HTTPStatus()
+			}
+		}
+
+		route := unmatchedRoute
+		if r := c.Route(); r != own {
+			route = r.Path
+		}
+
+		labels := []string{c.Method(), route, strconv.Itoa(status)}
+		HTTPRequests.WithLabelValues(labels...).Inc()
+		HTTPRequestDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
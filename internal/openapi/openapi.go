@@ -0,0 +1,173 @@
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+)
+
+// specYAML - спецификация API сервиса; тест test/openapi_test.go сверяет её с маршрутами и моделями
+//
+//go:embed openapi.yaml
+var specYAML []byte
+
+var (
+	loadOnce sync.Once
+	spec     *openapi3.T
+	specJSON []byte
+	router   routers.Router
+	loadErr  error
+)
+
+// load разбирает и проверяет спецификацию (один раз)
+func load() error {
+	loadOnce.Do(func() {
+		loader := openapi3.NewLoader()
+		doc, err := loader.LoadFromData(specYAML)
+		if err != nil {
+			loadErr = fmt.Errorf("ошибка разбора спецификации OpenAPI: %w", err)
+			return
+		}
+		if err := doc.Validate(context.Background()); err != nil {
+			loadErr = fmt.Errorf("некорректная спецификация OpenAPI: %w", err)
+			return
+		}
+		if specJSON, err = json.Marshal(doc); err != nil {
+			loadErr = fmt.Errorf("ошибка сериализации спецификации OpenAPI: %w", err)
+			return
+		}
+		if router, err = gorillamux.NewRouter(doc); err != nil {
+			loadErr = fmt.Errorf("ошибка построения маршрутов по спецификации OpenAPI: %w", err)
+			return
+		}
+		spec = doc
+	})
+	return loadErr
+}
+
+// Spec возвращает разобранную спецификацию
+func Spec() (*openapi3.T, error) {
+	if err := load(); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// JSONHandler отдаёт спецификацию в формате JSON (/openapi.json)
+func JSONHandler(c *fiber.Ctx) error {
+	if err := load(); err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	return c.Send(specJSON)
+}
+
+// UIHandler отдаёт страницу Swagger UI со спецификацией сервиса (/docs)
+func UIHandler(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.SendString(swaggerUI)
+}
+
+// Middleware проверяет параметры и тело запроса по спецификации. Запросы к маршрутам,
+// которых нет в спецификации, пропускаются (их обработает Fiber). Аутентификация здесь
+// не проверяется - за неё отвечает internal/auth. onError формирует ответ об ошибке.
+func Middleware(onError fiber.ErrorHandler) fiber.Handler {
+	options := &openapi3filter.Options{
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		MultiError:         true,
+	}
+
+	return func(c *fiber.Ctx) error {
+		if err := load(); err != nil {
+			return onError(c, err)
+		}
+
+		httpReq, err := adaptor.ConvertRequest(c, false)
+		if err != nil {
+			return onError(c, err)
+		}
+
+		route, pathParams, err := router.FindRoute(httpReq)
+		if err != nil {
+			return c.Next()
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    httpReq,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    options,
+		}
+		if err := openapi3filter.ValidateRequest(c.UserContext(), input); err != nil {
+			return onError(c, apperror.BadRequest("%s", describe(err)))
+		}
+
+		return c.Next()
+	}
+}
+
+// describe формирует краткое описание ошибок проверки запроса
+func describe(err error) string {
+	var multi openapi3.MultiError
+	if errors.As(err, &multi) && len(multi) > 0 {
+		message := describe(multi[0])
+		for _, e := range multi[1:] {
+			message += "; " + describe(e)
+		}
+		return message
+	}
+
+	var reqErr *openapi3filter.RequestError
+	if errors.As(err, &reqErr) {
+		if reqErr.Parameter != nil {
+			return fmt.Sprintf("параметр %q (%s): %s", reqErr.Parameter.Name, reqErr.Parameter.In, reason(reqErr))
+		}
+		return reason(reqErr)
+	}
+
+	return err.Error()
+}
+
+// reason возвращает причину ошибки без служебных префиксов библиотеки
+func reason(err *openapi3filter.RequestError) string {
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err.Err, &schemaErr) {
+		return schemaErr.Reason
+	}
+	if err.Reason != "" {
+		return err.Reason
+	}
+	if err.Err != nil {
+		return err.Err.Error()
+	}
+	return err.Error()
+}
+
+// swaggerUI - страница Swagger UI (статика загружается с CDN)
+const swaggerUI = `<!DOCTYPE html>
+<html lang="ru">
+<head>
+  <meta charset="utf-8">
+  <title>GitLab Service API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
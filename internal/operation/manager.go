@@ -0,0 +1,165 @@
+package operation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/service"
+)
+
+// HistoryLimit - сколько последних операций помнит сервис
+const HistoryLimit = 100
+
+// Статусы операции
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Operation - ожидание завершения джобы в фоне: клиент запускает джобу и опрашивает
+// операцию вместо того, чтобы держать соединение открытым
+type Operation struct {
+	ID         string
+	Project    string
+	JobID      int
+	Status     string
+	Gates      []service.GateResult // Проверки качества окружения перед запуском джобы
+	Run        *service.JobRun      // Итог джобы (если её состояние известно)
+	Err        error                // Почему операция не удалась
+	CreatedBy  string
+	CreatedAt  time.Time
+	FinishedAt time.Time
+}
+
+// Manager - ожидает завершения джоб и хранит состояние операций (в памяти)
+type Manager struct {
+	mu         sync.RWMutex
+	operations []*Operation // Новые первыми
+	poll       time.Duration
+	ctx        context.Context // При отмене ожидание джоб прерывается (см. Drain)
+	running    sync.WaitGroup  // Операции, ожидающие джобу
+}
+
+// NewManager создаёт менеджер операций; poll - интервал опроса джоб.
+// ctx отменяется при остановке сервиса после Drain: операции, не дождавшиеся джобы, прерываются.
+func NewManager(ctx context.Context, poll time.Duration) *Manager {
+	return &Manager{ctx: ctx, poll: poll}
+}
+
+// Await ждёт завершения джобы в рамках запроса клиента
+func (m *Manager) Await(ctx context.Context, svc *service.GitLabService, jobID int) (*service.JobRun, error) {
+	return svc.AwaitJob(ctx, jobID, m.poll)
+}
+
+// Start запускает ожидание джобы в фоне не дольше timeout
+func (m *Manager) Start(svc *service.GitLabService, op Operation, timeout time.Duration, now time.Time) Operation {
+	op.ID = newID()
+	op.Status = StatusRunning
+	op.CreatedAt = now
+
+	m.mu.Lock()
+	m.operations = append([]*Operation{&op}, m.operations...)
+	if len(m.operations) > HistoryLimit {
+		m.evictOldest()
+	}
+	snapshot := op
+	m.mu.Unlock()
+
+	log.Info().Str("identity", op.CreatedBy).Msgf("⏳ Операция %s: ожидание джобы %d (до %s)", op.ID, op.JobID, timeout)
+	m.running.Add(1)
+	go m.run(svc, op.ID, op.JobID, timeout)
+	return snapshot
+}
+
+// run ждёт джобу и записывает итог операции
+func (m *Manager) run(svc *service.GitLabService, id string, jobID int, timeout time.Duration) {
+	defer m.running.Done()
+
+	ctx, cancel := context.WithTimeout(m.ctx, timeout)
+	defer cancel()
+
+	run, err := svc.AwaitJob(ctx, jobID, m.poll)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	op, findErr := m.find(id)
+	if findErr != nil {
+		return
+	}
+	op.Run = run
+	op.Err = err
+	op.FinishedAt = time.Now()
+	op.Status = StatusSucceeded
+	if err != nil {
+		op.Status = StatusFailed
+		log.Warn().Err(err).Msgf("⚠️ Операция %s: джоба %d не завершилась успешно", id, jobID)
+		return
+	}
+	log.Info().Msgf("✅ Операция %s: джоба %d завершилась успешно", id, jobID)
+}
+
+// evictOldest удаляет самую старую завершённую операцию (вызывается под блокировкой)
+func (m *Manager) evictOldest() {
+	for i := len(m.operations) - 1; i >= 0; i-- {
+		if m.operations[i].Status != StatusRunning {
+			m.operations = append(m.operations[:i], m.operations[i+1:]...)
+			return
+		}
+	}
+}
+
+// Drain ждёт завершения операций (или отмены ctx) - вызывается при остановке сервиса
+func (m *Manager) Drain(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		m.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Get возвращает состояние операции, запущенной identity. Чужая операция не видна (404):
+// в ней лог джобы, и по ID нельзя узнать даже о её существовании.
+func (m *Manager) Get(id, identity string) (Operation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	op, err := m.find(id)
+	if err != nil {
+		return Operation{}, err
+	}
+	if op.CreatedBy != identity {
+		return Operation{}, apperror.NotFound("операция %q не найдена", id)
+	}
+	return *op, nil
+}
+
+// find ищет операцию по ID (вызывается под блокировкой)
+func (m *Manager) find(id string) (*Operation, error) {
+	for _, op := range m.operations {
+		if op.ID == id {
+			return op, nil
+		}
+	}
+	return nil, apperror.NotFound("операция %q не найдена", id)
+}
+
+// newID генерирует идентификатор операции
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,488 @@
+package release
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/credentials"
+	"github.com/vkr-mtuci/gitlab-service/internal/service"
+)
+
+// HistoryLimit - сколько последних планов помнит сервис
+const HistoryLimit = 50
+
+// Manager - выполняет планы релиза в фоне и хранит их состояние (только в памяти:
+// после перезапуска сервиса планы не восстанавливаются)
+type Manager struct {
+	mu       sync.RWMutex
+	plans    []*Plan // Новые первыми
+	done     map[string]chan struct{}
+	services *service.Registry
+	poll     time.Duration
+	ctx      context.Context // При отмене ожидание deploy-джоб прерывается (см. Drain)
+}
+
+// NewManager создаёт менеджер планов; poll - интервал опроса запущенных deploy-джоб.
+// ctx отменяется при остановке сервиса после Drain: планы, не успевшие завершиться, прерываются.
+func NewManager(ctx context.Context, services *service.Registry, poll time.Duration) *Manager {
+	return &Manager{ctx: ctx, services: services, poll: poll, done: map[string]chan struct{}{}}
+}
+
+// Create проверяет план и запускает его выполнение в фоне
+func (m *Manager) Create(plan Plan, now time.Time) (Plan, error) {
+	if err := validate(&plan, m.services); err != nil {
+		return Plan{}, err
+	}
+
+	plan.ID = newID()
+	plan.Status = PlanRunning
+	plan.CreatedAt = now
+
+	m.mu.Lock()
+	m.plans = append([]*Plan{&plan}, m.plans...)
+	if len(m.plans) > HistoryLimit {
+		m.evictOldest()
+	}
+	done := make(chan struct{})
+	m.done[plan.ID] = done
+	snapshot := plan.clone()
+	m.mu.Unlock()
+
+	log.Info().Str("identity", plan.CreatedBy).Msgf("📦 План релиза %s (%s): %d шагов, политика %s", plan.ID, plan.Name, len(plan.Steps), plan.Policy)
+	go func() {
+		defer close(done)
+		m.run(plan.ID)
+	}()
+	return snapshot, nil
+}
+
+// Preview проверяет план без запуска (пробный запуск, ?dry_run=true): для каждого шага -
+// что будет запущено, если выполнить его сейчас. Зависимости шагов не учитываются.
+func (m *Manager) Preview(ctx context.Context, plan Plan) ([]StepPreview, error) {
+	if err := validate(&plan, m.services); err != nil {
+		return nil, err
+	}
+
+	previews := make([]StepPreview, 0, len(plan.Steps))
+	for _, step := range plan.Steps {
+		preview, err := m.previewStep(ctx, step)
+		if err != nil {
+			e := apperror.From(err)
+			return nil, &apperror.Error{Kind: e.Kind, Message: fmt.Sprintf("шаг %q", step.ID), UpstreamStatus: e.UpstreamStatus, Err: err}
+		}
+		previews = append(previews, StepPreview{StepID: step.ID, Project: step.Project, Preview: preview})
+	}
+	return previews, nil
+}
+
+// previewStep проверяет деплой шага (см. executeStep)
+func (m *Manager) previewStep(ctx context.Context, step Step) (*service.DeployPreview, error) {
+	svc, err := m.services.Get(step.Project)
+	if err != nil {
+		return nil, err
+	}
+
+	pipelineID := step.PipelineID
+	if step.Ref != "" {
+		pipeline, err := svc.ResolvePipeline(ctx, step.Ref)
+		if err != nil {
+			return nil, err
+		}
+		pipelineID = pipeline.ID
+	}
+	return svc.PreviewDeploy(ctx, step.Environment, pipelineID, 0, time.Now())
+}
+
+// evictOldest удаляет самый старый завершённый план (вызывается под блокировкой)
+func (m *Manager) evictOldest() {
+	for i := len(m.plans) - 1; i >= 0; i-- {
+		if m.plans[i].Status != PlanRunning {
+			delete(m.done, m.plans[i].ID)
+			m.plans = append(m.plans[:i], m.plans[i+1:]...)
+			return
+		}
+	}
+}
+
+// Get возвращает состояние плана
+func (m *Manager) Get(id string) (Plan, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	plan, err := m.find(id)
+	if err != nil {
+		return Plan{}, err
+	}
+	return plan.clone(), nil
+}
+
+// List возвращает последние планы, новые первыми
+func (m *Manager) List() []Plan {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]Plan, 0, len(m.plans))
+	for _, plan := range m.plans {
+		result = append(result, plan.clone())
+	}
+	return result
+}
+
+// Wait ждёт завершения плана (или отмены ctx) и возвращает его состояние
+func (m *Manager) Wait(ctx context.Context, id string) (Plan, error) {
+	m.mu.RLock()
+	done, ok := m.done[id]
+	m.mu.RUnlock()
+	if !ok {
+		return Plan{}, apperror.NotFound("план релиза %q не найден", id)
+	}
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+	return m.Get(id)
+}
+
+// Drain ждёт завершения выполняющихся планов (или отмены ctx) - вызывается при остановке сервиса
+func (m *Manager) Drain(ctx context.Context) error {
+	m.mu.RLock()
+	var running []chan struct{}
+	for _, plan := range m.plans {
+		if plan.Status == PlanRunning {
+			running = append(running, m.done[plan.ID])
+		}
+	}
+	m.mu.RUnlock()
+
+	if len(running) > 0 {
+		log.Info().Msgf("📦 Ждём завершения планов релиза: %d", len(running))
+	}
+	for _, done := range running {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// find ищет план по ID (вызывается под блокировкой)
+func (m *Manager) find(id string) (*Plan, error) {
+	for _, plan := range m.plans {
+		if plan.ID == id {
+			return plan, nil
+		}
+	}
+	return nil, apperror.NotFound("план релиза %q не найден", id)
+}
+
+// update изменяет план под блокировкой
+func (m *Manager) update(id string, change func(plan *Plan)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if plan, err := m.find(id); err == nil {
+		change(plan)
+	}
+}
+
+// stepResult - результат выполнения шага
+type stepResult struct {
+	index int
+	step  Step
+}
+
+// run выполняет план: запускает готовые шаги параллельно, пропускает шаги с неудачными
+// зависимостями и по политике останавливается или откатывает выполненные шаги
+func (m *Manager) run(id string) {
+	plan, err := m.Get(id)
+	if err != nil {
+		return
+	}
+
+	results := make(chan stepResult)
+	running := 0
+	stopped := false
+
+	for {
+		var started []int
+		m.update(id, func(p *Plan) {
+			skipBlocked(p, stopped)
+			if stopped {
+				return
+			}
+			for i := range p.Steps {
+				if p.Steps[i].Status == StepPending && dependenciesSucceeded(p, p.Steps[i]) {
+					p.Steps[i].Status = StepRunning
+					p.Steps[i].StartedAt = time.Now()
+					started = append(started, i)
+				}
+			}
+		})
+
+		for _, i := range started {
+			running++
+			step, _ := m.step(id, i)
+			go func() {
+				results <- stepResult{index: i, step: m.executeStep(step, plan.RunAs, plan.StepTimeout)}
+			}()
+		}
+
+		if running == 0 {
+			break
+		}
+
+		result := <-results
+		running--
+		m.update(id, func(p *Plan) { p.Steps[result.index] = result.step })
+		if result.step.Status == StepFailed && plan.Policy != PolicyContinue {
+			stopped = true
+		}
+	}
+
+	rolledBack := false
+	switch {
+	case !stopped || plan.Policy != PolicyRollback:
+	case m.ctx.Err() != nil:
+		// Сервис останавливается: состояние прерванных джоб неизвестно, откат не начинаем
+		log.Warn().Msgf("⚠️ План релиза %s прерван остановкой сервиса, откат не выполняется", id)
+	default:
+		m.rollback(id, plan.RunAs, plan.StepTimeout)
+		rolledBack = true
+	}
+
+	m.update(id, func(p *Plan) {
+		p.Status = finalStatus(p, rolledBack)
+		p.FinishedAt = time.Now()
+	})
+
+	final, _ := m.Get(id)
+	log.Info().Msgf("📦 План релиза %s завершён: %s", id, final.Status)
+}
+
+// step возвращает копию шага плана
+func (m *Manager) step(id string, index int) (Step, error) {
+	plan, err := m.Get(id)
+	if err != nil {
+		return Step{}, err
+	}
+	return plan.Steps[index], nil
+}
+
+// executeStep запускает deploy-джобу шага от имени runAs и ждёт её завершения
+func (m *Manager) executeStep(step Step, runAs string, timeout time.Duration) Step {
+	ctx, cancel := context.WithTimeout(withActor(m.ctx, runAs), timeout)
+	defer cancel()
+
+	fail := func(err error) Step {
+		if m.ctx.Err() != nil {
+			err = apperror.Unavailable(err, "шаг прерван остановкой сервиса")
+		}
+		step.Status = StepFailed
+		step.Error = err.Error()
+		step.FinishedAt = time.Now()
+		log.Error().Err(err).Msgf("❌ Шаг %s плана релиза не выполнен", step.ID)
+		return step
+	}
+
+	svc, err := m.services.Get(step.Project)
+	if err != nil {
+		return fail(err)
+	}
+
+	// Запоминаем текущий деплой окружения, чтобы было к чему откатиться
+	if previous, err := svc.CurrentDeployment(ctx, step.Environment); err == nil {
+		step.PreviousJobID = previous.JobID
+	}
+
+	pipelineID := step.PipelineID
+	if step.Ref != "" {
+		pipeline, err := svc.ResolvePipeline(ctx, step.Ref)
+		if err != nil {
+			return fail(err)
+		}
+		pipelineID = pipeline.ID
+	}
+
+	job, err := svc.DeployToEnvironment(ctx, step.Environment, pipelineID, 0)
+	if err != nil {
+		return fail(err)
+	}
+	step.JobID, step.JobURL, step.JobStatus = job.ID, job.WebURL, job.Status
+	log.Info().Msgf("🚀 Шаг %s: деплой %s в %s, jobID=%d", step.ID, step.Project, step.Environment, job.ID)
+
+	final, err := svc.WaitForJob(ctx, job.ID, m.poll)
+	if final != nil {
+		step.JobStatus = final.Status
+	}
+	if err != nil {
+		return fail(err)
+	}
+	if final.Status != "success" {
+		return fail(apperror.Conflict("deploy-джоба %d завершилась со статусом %s", job.ID, final.Status))
+	}
+
+	step.Status = StepSucceeded
+	step.FinishedAt = time.Now()
+	return step
+}
+
+// rollback откатывает успешно выполненные шаги в порядке, обратном завершению:
+// перезапускает deploy-джобу предыдущего деплоя в окружение
+func (m *Manager) rollback(id, runAs string, timeout time.Duration) {
+	plan, err := m.Get(id)
+	if err != nil {
+		return
+	}
+
+	var completed []int
+	for i, step := range plan.Steps {
+		if step.Status == StepSucceeded {
+			completed = append(completed, i)
+		}
+	}
+	sort.SliceStable(completed, func(a, b int) bool {
+		return plan.Steps[completed[a]].FinishedAt.After(plan.Steps[completed[b]].FinishedAt)
+	})
+
+	for _, i := range completed {
+		step := m.rollbackStep(plan.Steps[i], runAs, timeout)
+		m.update(id, func(p *Plan) { p.Steps[i] = step })
+	}
+}
+
+// rollbackStep возвращает окружение шага к предыдущей сборке
+func (m *Manager) rollbackStep(step Step, runAs string, timeout time.Duration) Step {
+	fail := func(err error) Step {
+		step.Status = StepRollbackFailed
+		step.Error = err.Error()
+		log.Error().Err(err).Msgf("❌ Не удалось откатить шаг %s плана релиза", step.ID)
+		return step
+	}
+
+	if step.PreviousJobID == 0 {
+		return fail(apperror.Conflict("в окружение %s раньше ничего не деплоилось, откатываться не к чему", step.Environment))
+	}
+
+	svc, err := m.services.Get(step.Project)
+	if err != nil {
+		return fail(err)
+	}
+
+	// Начатый откат доводится до конца и при остановке сервиса: ограничен только таймаутом шага
+	ctx, cancel := context.WithTimeout(withActor(context.WithoutCancel(m.ctx), runAs), timeout)
+	defer cancel()
+
+	job, err := svc.RedeployJob(ctx, step.PreviousJobID)
+	if err != nil {
+		return fail(err)
+	}
+	step.RollbackJobID = job.ID
+
+	final, err := svc.WaitForJob(ctx, job.ID, m.poll)
+	if err != nil {
+		return fail(err)
+	}
+	if final.Status != "success" {
+		return fail(apperror.Conflict("джоба отката %d завершилась со статусом %s", job.ID, final.Status))
+	}
+
+	step.Status = StepRolledBack
+	step.RolledBackAt = time.Now()
+	log.Info().Msgf("↩️ Шаг %s откатан: %s в %s, jobID=%d", step.ID, step.Project, step.Environment, job.ID)
+	return step
+}
+
+// withActor возвращает контекст запросов к GitLab от имени пользователя runAs (Sudo);
+// "" - от имени сервисного аккаунта
+func withActor(ctx context.Context, runAs string) context.Context {
+	if runAs == "" {
+		return ctx
+	}
+	return credentials.WithSudo(ctx, runAs)
+}
+
+// skipBlocked пропускает ожидающие шаги, которые уже не смогут выполниться:
+// упала (или пропущена) зависимость либо план остановлен
+func skipBlocked(plan *Plan, stopped bool) {
+	for changed := true; changed; {
+		changed = false
+		for i := range plan.Steps {
+			step := &plan.Steps[i]
+			if step.Status != StepPending {
+				continue
+			}
+			if stopped {
+				step.Status, step.Error = StepSkipped, "план остановлен после неудачного шага"
+				changed = true
+				continue
+			}
+			for _, dep := range step.DependsOn {
+				if status := stepStatus(plan, dep); status == StepFailed || status == StepSkipped {
+					step.Status, step.Error = StepSkipped, "зависимость "+dep+" не выполнена"
+					changed = true
+					break
+				}
+			}
+		}
+	}
+}
+
+// dependenciesSucceeded проверяет, что все зависимости шага выполнены
+func dependenciesSucceeded(plan *Plan, step Step) bool {
+	for _, dep := range step.DependsOn {
+		if stepStatus(plan, dep) != StepSucceeded {
+			return false
+		}
+	}
+	return true
+}
+
+// stepStatus возвращает статус шага по ID
+func stepStatus(plan *Plan, id string) string {
+	for _, step := range plan.Steps {
+		if step.ID == id {
+			return step.Status
+		}
+	}
+	return ""
+}
+
+// finalStatus вычисляет итоговый статус плана по статусам шагов; rolledBack - выполнялся откат
+func finalStatus(plan *Plan, rolledBack bool) string {
+	failed, rollbackFailed := false, false
+	for _, step := range plan.Steps {
+		switch step.Status {
+		case StepFailed:
+			failed = true
+		case StepRollbackFailed:
+			rollbackFailed = true
+		}
+	}
+
+	switch {
+	case !failed:
+		return PlanSucceeded
+	case rolledBack && !rollbackFailed:
+		return PlanRolledBack
+	default:
+		return PlanFailed
+	}
+}
+
+// newID генерирует случайный идентификатор плана
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
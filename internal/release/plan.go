@@ -0,0 +1,193 @@
+// Package release - планы релиза: деплой нескольких проектов в порядке зависимостей.
+// Шаги плана образуют DAG; шаг запускается, когда все его зависимости успешно завершились.
+package release
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/service"
+)
+
+// Политики обработки неудачного шага
+const (
+	PolicyStop     = "stop"     // Не запускать новые шаги (по умолчанию)
+	PolicyContinue = "continue" // Продолжать шаги, не зависящие от неудачного
+	PolicyRollback = "rollback" // Остановиться и откатить выполненные шаги
+)
+
+// Статусы шага
+const (
+	StepPending        = "pending"
+	StepRunning        = "running"
+	StepSucceeded      = "succeeded"
+	StepFailed         = "failed"
+	StepSkipped        = "skipped"         // Не запускался: упала зависимость или план остановлен
+	StepRolledBack     = "rolled_back"     // Окружение возвращено к предыдущей сборке
+	StepRollbackFailed = "rollback_failed" // Откатить не удалось
+)
+
+// Статусы плана
+const (
+	PlanRunning    = "running"
+	PlanSucceeded  = "succeeded"
+	PlanFailed     = "failed"
+	PlanRolledBack = "rolled_back"
+)
+
+// DefaultStepTimeout - сколько ждать завершения deploy-джобы одного шага
+const DefaultStepTimeout = 30 * time.Minute
+
+// Step - шаг плана: деплой сборки проекта в окружение
+type Step struct {
+	ID          string
+	Project     string
+	Environment string
+	PipelineID  int    // Пайплайн сборки
+	Ref         string // Или ветка/тег версии: берётся последний пайплайн
+	DependsOn   []string
+
+	Status        string
+	Error         string
+	JobID         int    // Запущенная deploy-джоба
+	JobURL        string // Ссылка на неё
+	JobStatus     string // Последний известный статус джобы
+	PreviousJobID int    // Deploy-джоба предыдущего деплоя в окружение (для отката)
+	RollbackJobID int
+	StartedAt     time.Time
+	FinishedAt    time.Time
+	RolledBackAt  time.Time
+}
+
+// StepPreview - что будет запущено шагом плана (пробный запуск)
+type StepPreview struct {
+	StepID  string
+	Project string
+	Preview *service.DeployPreview
+}
+
+// Plan - план релиза
+type Plan struct {
+	ID          string
+	Name        string
+	Policy      string
+	StepTimeout time.Duration
+	Steps       []Step
+	Status      string
+	CreatedBy   string
+	RunAs       string // Пользователь GitLab (Sudo), от имени которого запускаются джобы
+	CreatedAt   time.Time
+	FinishedAt  time.Time
+}
+
+// clone возвращает копию плана, не разделяющую шаги с оригиналом
+func (p *Plan) clone() Plan {
+	plan := *p
+	plan.Steps = make([]Step, len(p.Steps))
+	for i, step := range p.Steps {
+		step.DependsOn = append([]string(nil), step.DependsOn...)
+		plan.Steps[i] = step
+	}
+	return plan
+}
+
+// validate проверяет план и заполняет значения по умолчанию
+func validate(plan *Plan, services *service.Registry) error {
+	if len(plan.Steps) == 0 {
+		return apperror.Validation("План релиза должен содержать хотя бы один шаг")
+	}
+
+	switch plan.Policy {
+	case "":
+		plan.Policy = PolicyStop
+	case PolicyStop, PolicyContinue, PolicyRollback:
+	default:
+		return apperror.Validation("Неизвестная политика %q (ожидается stop, continue или rollback)", plan.Policy)
+	}
+	if plan.StepTimeout == 0 {
+		plan.StepTimeout = DefaultStepTimeout
+	}
+	if plan.StepTimeout < 0 {
+		return apperror.Validation("step_timeout должен быть больше нуля")
+	}
+
+	index := map[string]int{}
+	for i := range plan.Steps {
+		step := &plan.Steps[i]
+		step.ID = strings.TrimSpace(step.ID)
+		field := fmt.Sprintf("steps[%d]", i)
+
+		switch {
+		case step.ID == "":
+			return apperror.Validation("%s: необходимо указать id шага", field)
+		case index[step.ID] > 0:
+			return apperror.Validation("%s: шаг %q уже описан", field, step.ID)
+		case step.Environment == "":
+			return apperror.Validation("%s: необходимо указать окружение environment", field)
+		case step.PipelineID == 0 && step.Ref == "":
+			return apperror.Validation("%s: необходимо указать pipeline_id или ref", field)
+		case step.PipelineID != 0 && step.Ref != "":
+			return apperror.Validation("%s: pipeline_id и ref взаимоисключающие", field)
+		}
+		if _, err := services.Get(step.Project); err != nil {
+			return apperror.Validation("%s: %v", field, err)
+		}
+
+		index[step.ID] = i + 1
+		step.Status = StepPending
+	}
+
+	for i, step := range plan.Steps {
+		for _, dep := range step.DependsOn {
+			if index[dep] == 0 {
+				return apperror.Validation("steps[%d]: неизвестная зависимость %q", i, dep)
+			}
+			if dep == step.ID {
+				return apperror.Validation("steps[%d]: шаг не может зависеть от себя", i)
+			}
+		}
+	}
+
+	if cycle := findCycle(plan.Steps); cycle != "" {
+		return apperror.Validation("Зависимости шагов образуют цикл (через шаг %q)", cycle)
+	}
+	return nil
+}
+
+// findCycle возвращает шаг, входящий в цикл зависимостей (пусто - циклов нет); алгоритм Кана
+func findCycle(steps []Step) string {
+	pending := map[string]int{}
+	dependants := map[string][]string{}
+	for _, step := range steps {
+		pending[step.ID] = len(step.DependsOn)
+		for _, dep := range step.DependsOn {
+			dependants[dep] = append(dependants[dep], step.ID)
+		}
+	}
+
+	var queue []string
+	for _, step := range steps {
+		if pending[step.ID] == 0 {
+			queue = append(queue, step.ID)
+		}
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		delete(pending, id)
+		for _, next := range dependants[id] {
+			if pending[next]--; pending[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	for _, step := range steps {
+		if _, ok := pending[step.ID]; ok {
+			return step.ID
+		}
+	}
+	return ""
+}
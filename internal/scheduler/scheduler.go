@@ -0,0 +1,315 @@
+// Package scheduler - отложенные деплои и продвижения сборок (например, на окно обслуживания в 02:00).
+// Расписания хранятся в файле и переживают перезапуск; блокировки и заморозки окружений
+// проверяются в момент запуска, а не при создании расписания.
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/credentials"
+	"github.com/vkr-mtuci/gitlab-service/internal/metrics"
+	"github.com/vkr-mtuci/gitlab-service/internal/service"
+)
+
+// Типы расписаний
+const (
+	TypeDeploy    = "deploy"    // Запуск deploy-джобы окружения
+	TypePromotion = "promotion" // Продвижение сборки в следующее окружение
+)
+
+// Статусы расписания
+const (
+	StatusScheduled = "scheduled" // Ожидает времени запуска
+	StatusRunning   = "running"   // Выполняется
+	StatusTriggered = "triggered" // Deploy-джоба запущена в GitLab
+	StatusFailed    = "failed"    // Запуск завершился ошибкой
+	StatusBlocked   = "blocked"   // Окружение заблокировано или заморожено в момент запуска
+	StatusMissed    = "missed"    // Время запуска прошло больше чем на max_delay (сервис был остановлен)
+	StatusCancelled = "cancelled" // Отменено до запуска
+)
+
+// executionTimeout - сколько может длиться один запуск (несколько запросов к GitLab)
+const executionTimeout = time.Minute
+
+// Schedule - отложенный деплой или продвижение и результат его запуска
+type Schedule struct {
+	ID          string    `json:"id"`
+	Type        string    `json:"type"`
+	Project     string    `json:"project,omitempty"`
+	Environment string    `json:"environment,omitempty"` // deploy: целевое окружение
+	PipelineID  int       `json:"pipeline_id,omitempty"` // deploy: пайплайн, в котором искать джобу окружения
+	JobID       int       `json:"job_id,omitempty"`      // deploy: конкретная джоба
+	FromEnv     string    `json:"from_env,omitempty"`    // promotion
+	ToEnv       string    `json:"to_env,omitempty"`      // promotion
+	RunAt       time.Time `json:"run_at"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+	RunAs       string    `json:"run_as,omitempty"` // Пользователь GitLab (Sudo), от имени которого выполняется запуск
+	CreatedAt   time.Time `json:"created_at"`
+
+	Status         string    `json:"status"`
+	Error          string    `json:"error,omitempty"`
+	ExecutedAt     time.Time `json:"executed_at"`
+	TriggeredJobID int       `json:"triggered_job_id,omitempty"`
+	JobName        string    `json:"job_name,omitempty"`
+	JobStatus      string    `json:"job_status,omitempty"` // Статус джобы по ответу GitLab на запуск
+	JobURL         string    `json:"job_url,omitempty"`
+	PromotionID    string    `json:"promotion_id,omitempty"`
+}
+
+// Target возвращает окружение, в которое деплоит расписание
+func (s Schedule) Target() string {
+	if s.Type == TypePromotion {
+		return s.ToEnv
+	}
+	return s.Environment
+}
+
+// Scheduler - планировщик: создаёт, отменяет и в срок выполняет расписания
+type Scheduler struct {
+	store      *Store
+	services   *service.Registry
+	promotions *service.PromotionStore
+	maxDelay   time.Duration
+}
+
+// New создаёт планировщик; продвижения по расписанию записываются в promotions
+func New(store *Store, services *service.Registry, promotions *service.PromotionStore, maxDelay time.Duration) *Scheduler {
+	return &Scheduler{store: store, services: services, promotions: promotions, maxDelay: maxDelay}
+}
+
+// Create проверяет и сохраняет новое расписание
+func (s *Scheduler) Create(schedule Schedule, now time.Time) (Schedule, error) {
+	if _, err := s.validate(&schedule, now); err != nil {
+		return Schedule{}, err
+	}
+
+	schedule.ID = newID()
+	schedule.Status = StatusScheduled
+	schedule.CreatedAt = now
+	if err := s.store.Add(schedule); err != nil {
+		return Schedule{}, err
+	}
+
+	log.Info().Str("identity", schedule.CreatedBy).Msgf("🗓 Запланирован %s в %s на %s (id=%s)",
+		schedule.Type, schedule.Target(), schedule.RunAt.Format(time.RFC3339), schedule.ID)
+	return schedule, nil
+}
+
+// Preview проверяет расписание без сохранения (пробный запуск, ?dry_run=true): что будет
+// запущено в момент run_at, с учётом блокировок и заморозок, действующих на это время по настройкам
+func (s *Scheduler) Preview(ctx context.Context, schedule Schedule, now time.Time) (*service.DeployPreview, error) {
+	svc, err := s.validate(&schedule, now)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, executionTimeout)
+	defer cancel()
+
+	if schedule.Type == TypePromotion {
+		return svc.PreviewPromotion(ctx, schedule.FromEnv, schedule.ToEnv, schedule.RunAt)
+	}
+	return svc.PreviewDeploy(ctx, schedule.Environment, schedule.PipelineID, schedule.JobID, schedule.RunAt)
+}
+
+// validate проверяет расписание, нормализует окружения и возвращает сервис его проекта
+func (s *Scheduler) validate(schedule *Schedule, now time.Time) (*service.GitLabService, error) {
+	svc, err := s.services.Get(schedule.Project)
+	if err != nil {
+		return nil, err
+	}
+
+	if schedule.RunAt.IsZero() {
+		return nil, apperror.Validation("Необходимо указать время запуска run_at")
+	}
+	if !schedule.RunAt.After(now) {
+		return nil, apperror.Validation("Время запуска run_at уже прошло")
+	}
+
+	switch schedule.Type {
+	case TypeDeploy:
+		schedule.Environment = strings.TrimSpace(schedule.Environment)
+		if schedule.Environment == "" {
+			return nil, apperror.Validation("Необходимо указать окружение environment")
+		}
+		if schedule.PipelineID == 0 && schedule.JobID == 0 {
+			return nil, apperror.Validation("Необходимо указать pipeline_id или job_id")
+		}
+	case TypePromotion:
+		schedule.FromEnv, schedule.ToEnv = strings.TrimSpace(schedule.FromEnv), strings.TrimSpace(schedule.ToEnv)
+		if schedule.FromEnv == "" || schedule.ToEnv == "" {
+			return nil, apperror.Validation("Необходимо указать окружения from_env и to_env")
+		}
+		if err := svc.CheckPromotionPath(schedule.FromEnv, schedule.ToEnv); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, apperror.Validation("Неизвестный тип расписания %q (ожидается deploy или promotion)", schedule.Type)
+	}
+	return svc, nil
+}
+
+// Cancel отменяет расписание, которое ещё не запускалось
+func (s *Scheduler) Cancel(id string) (Schedule, error) {
+	schedule, err := s.store.Update(id, func(schedule *Schedule) error {
+		if err := checkCancellable(*schedule); err != nil {
+			return err
+		}
+		schedule.Status = StatusCancelled
+		return nil
+	})
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	return schedule, nil
+}
+
+// PreviewCancel проверяет отмену расписания без отмены (пробный запуск, ?dry_run=true)
+func (s *Scheduler) PreviewCancel(id string) (Schedule, error) {
+	schedule, err := s.store.Get(id)
+	if err != nil {
+		return Schedule{}, err
+	}
+	if err := checkCancellable(schedule); err != nil {
+		return Schedule{}, err
+	}
+	return schedule, nil
+}
+
+// checkCancellable проверяет, что расписание ещё не запускалось
+func checkCancellable(schedule Schedule) error {
+	if schedule.Status != StatusScheduled {
+		return apperror.Conflict("расписание %q уже в статусе %s и не может быть отменено", schedule.ID, schedule.Status)
+	}
+	return nil
+}
+
+// Get возвращает расписание по ID
+func (s *Scheduler) Get(id string) (Schedule, error) {
+	return s.store.Get(id)
+}
+
+// List возвращает расписания проекта (пустое имя - всех проектов)
+func (s *Scheduler) List(project string) []Schedule {
+	return s.store.List(project)
+}
+
+// Run выполняет наступившие расписания каждые interval, пока не отменён ctx
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.RunDue(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue выполняет расписания, время которых наступило к моменту now
+func (s *Scheduler) RunDue(ctx context.Context, now time.Time) {
+	due, err := s.store.claimDue(now)
+	if err != nil {
+		log.Error().Err(err).Msg("❌ Ошибка сохранения расписаний")
+		return
+	}
+
+	for _, schedule := range due {
+		result := s.execute(ctx, schedule, now)
+		result.ExecutedAt = time.Now()
+		metrics.ScheduledRuns.WithLabelValues(result.Type, result.Status).Inc()
+
+		if _, err := s.store.Update(schedule.ID, func(item *Schedule) error {
+			*item = result
+			return nil
+		}); err != nil {
+			log.Error().Err(err).Msgf("❌ Ошибка сохранения результата расписания %s", schedule.ID)
+		}
+	}
+}
+
+// execute запускает деплой или продвижение и возвращает расписание с результатом
+func (s *Scheduler) execute(ctx context.Context, schedule Schedule, now time.Time) Schedule {
+	if delay := now.Sub(schedule.RunAt); delay > s.maxDelay {
+		schedule.Status = StatusMissed
+		schedule.Error = "время запуска пропущено на " + delay.Round(time.Second).String()
+		log.Warn().Msgf("⚠️ Расписание %s пропущено: %s", schedule.ID, schedule.Error)
+		return schedule
+	}
+
+	svc, err := s.services.Get(schedule.Project)
+	if err != nil {
+		return failed(schedule, err)
+	}
+
+	// Блокировки и заморозки проверяются в момент запуска: за время ожидания они могли измениться
+	if err := svc.CheckDeployAllowed(schedule.Target(), now); err != nil {
+		schedule.Status = StatusBlocked
+		schedule.Error = apperror.From(err).Message
+		log.Warn().Msgf("⛔ Расписание %s не выполнено: %s", schedule.ID, schedule.Error)
+		return schedule
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, executionTimeout)
+	defer cancel()
+	if schedule.RunAs != "" {
+		ctx = credentials.WithSudo(ctx, schedule.RunAs)
+	}
+
+	switch schedule.Type {
+	case TypePromotion:
+		promotion, err := svc.Promote(ctx, schedule.FromEnv, schedule.ToEnv)
+		if promotion != nil {
+			promotion.TriggeredBy = schedule.CreatedBy
+			s.promotions.Add(promotion)
+			schedule.PromotionID = promotion.ID
+			schedule.TriggeredJobID = promotion.JobID
+			schedule.JobName = promotion.JobName
+			schedule.JobStatus = promotion.JobStatus
+			schedule.JobURL = promotion.JobURL
+		}
+		if err != nil {
+			return failed(schedule, err)
+		}
+	default:
+		job, err := svc.DeployToEnvironment(ctx, schedule.Environment, schedule.PipelineID, schedule.JobID)
+		if err != nil {
+			return failed(schedule, err)
+		}
+		schedule.TriggeredJobID = job.ID
+		schedule.JobName = job.Name
+		schedule.JobStatus = job.Status
+		schedule.JobURL = job.WebURL
+	}
+
+	schedule.Status = StatusTriggered
+	log.Info().Msgf("🚀 Расписание %s выполнено: %s в %s, jobID=%d", schedule.ID, schedule.Type, schedule.Target(), schedule.TriggeredJobID)
+	return schedule
+}
+
+// failed отмечает расписание неудачным
+func failed(schedule Schedule, err error) Schedule {
+	schedule.Status = StatusFailed
+	schedule.Error = err.Error()
+	log.Error().Err(err).Msgf("❌ Расписание %s не выполнено", schedule.ID)
+	return schedule
+}
+
+// newID генерирует случайный идентификатор расписания
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,183 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+)
+
+// Store - расписания, сохраняемые в JSON-файл после каждого изменения,
+// чтобы они переживали перезапуск сервиса
+type Store struct {
+	mu    sync.Mutex
+	path  string
+	items []*Schedule
+}
+
+// OpenStore читает расписания из файла (если его нет - начинает с пустого списка).
+// Запуски, прерванные остановкой сервиса, помечаются неудачными: повторять их вслепую опасно.
+func OpenStore(path string) (*Store, error) {
+	s := &Store{path: path}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return s, nil
+	case err != nil:
+		return nil, fmt.Errorf("ошибка чтения расписаний %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, &s.items); err != nil {
+		return nil, fmt.Errorf("ошибка разбора расписаний %s: %w", path, err)
+	}
+
+	interrupted := 0
+	for _, item := range s.items {
+		if item.Status == StatusRunning {
+			item.Status = StatusFailed
+			item.Error = "запуск прерван остановкой сервиса"
+			item.ExecutedAt = time.Now()
+			interrupted++
+		}
+	}
+	if interrupted > 0 {
+		log.Warn().Msgf("⚠️ %d отложенных запусков прервано остановкой сервиса", interrupted)
+		if err := s.save(); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Info().Msgf("🗓 Загружено расписаний: %d (%s)", len(s.items), path)
+	return s, nil
+}
+
+// Add сохраняет новое расписание
+func (s *Store) Add(schedule Schedule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items = append(s.items, &schedule)
+	if err := s.save(); err != nil {
+		s.items = s.items[:len(s.items)-1]
+		return err
+	}
+	return nil
+}
+
+// Get возвращает расписание по ID
+func (s *Store) Get(id string) (Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, err := s.find(id)
+	if err != nil {
+		return Schedule{}, err
+	}
+	return *item, nil
+}
+
+// List возвращает расписания проекта (пустое имя - всех проектов) по времени запуска
+func (s *Store) List(project string) []Schedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]Schedule, 0, len(s.items))
+	for _, item := range s.items {
+		if project == "" || item.Project == project {
+			result = append(result, *item)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].RunAt.Before(result[j].RunAt) })
+	return result
+}
+
+// Update изменяет расписание; change возвращает ошибку, если изменение недопустимо
+func (s *Store) Update(id string, change func(*Schedule) error) (Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, err := s.find(id)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	previous := *item
+	if err := change(item); err != nil {
+		return Schedule{}, err
+	}
+	if err := s.save(); err != nil {
+		*item = previous
+		return Schedule{}, err
+	}
+	return *item, nil
+}
+
+// claimDue переводит наступившие расписания в статус running и возвращает их
+func (s *Store) claimDue(now time.Time) ([]Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []Schedule
+	for _, item := range s.items {
+		if item.Status == StatusScheduled && !item.RunAt.After(now) {
+			item.Status = StatusRunning
+			due = append(due, *item)
+		}
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+
+	if err := s.save(); err != nil {
+		for _, item := range s.items {
+			if item.Status == StatusRunning {
+				item.Status = StatusScheduled
+			}
+		}
+		return nil, err
+	}
+	return due, nil
+}
+
+// find ищет расписание по ID (вызывается под блокировкой)
+func (s *Store) find(id string) (*Schedule, error) {
+	for _, item := range s.items {
+		if item.ID == id {
+			return item, nil
+		}
+	}
+	return nil, apperror.NotFound("расписание %q не найдено", id)
+}
+
+// save атомарно записывает расписания в файл (вызывается под блокировкой)
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.items, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации расписаний: %w", err)
+	}
+
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("ошибка создания каталога расписаний %s: %w", dir, err)
+		}
+	}
+
+	// Пишем во временный файл и переименовываем, чтобы не оставить файл обрезанным при сбое
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("ошибка записи расписаний %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("ошибка сохранения расписаний %s: %w", s.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/vkr-mtuci/gitlab-service/internal/handler"
+	"github.com/vkr-mtuci/gitlab-service/internal/openapi"
+)
+
+// Routes - обработчики и промежуточные слои, из которых собираются маршруты сервиса.
+// Незаданные промежуточные слои пропускаются.
+type Routes struct {
+	GitLab     *handler.GitLabHandler
+	V1         *handler.V1Handler
+	Promotions *handler.PromotionHandler
+	Schedules  *handler.ScheduleHandler
+	Releases   *handler.ReleaseHandler
+	Health     *handler.HealthHandler
+	Admin      *handler.AdminHandler
+	Metrics    fiber.Handler
+
+	Auth        fiber.Handler // Аутентификация клиентов API
+	AdminOnly   fiber.Handler // Доступ к административным эндпоинтам только для администраторов
+	Validate    fiber.Handler // Проверка запросов по спецификации OpenAPI
+	Impersonate fiber.Handler // Запуск джоб от имени пользователя
+	Deferred    fiber.Handler // Запоминание пользователя для отложенного запуска (расписания, планы релиза)
+	Idempotency fiber.Handler // Повтор сохранённого ответа на POST с Idempotency-Key
+	Deprecated  fiber.Handler // Заголовки устаревания для маршрутов без версии
+}
+
+// Register регистрирует все маршруты сервиса. Спецификация internal/openapi/openapi.yaml
+// должна описывать те же маршруты (это проверяет test/openapi_test.go).
+func (r Routes) Register(app *fiber.App) {
+	// Проверка запуска сервиса
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"message": "✅ GitLab-service is running"})
+	})
+
+	// Проверки для Kubernetes
+	app.Get("/healthz", r.Health.Liveness)        // Процесс жив
+	app.Get("/readyz", r.Health.Readiness)        // GitLab доступен, токен и проект в порядке
+	app.Get("/metrics", orNext(r.Metrics))        // 📊 Метрики Prometheus
+	app.Get("/openapi.json", openapi.JSONHandler) // 📘 Спецификация OpenAPI
+	app.Get("/docs", openapi.UIHandler)           // 📘 Swagger UI
+
+	// 🔑 Аутентификация клиентов (маршруты выше доступны без неё)
+	app.Use(orNext(r.Auth))
+
+	// 📘 Параметры запросов проверяются по спецификации
+	app.Use(orNext(r.Validate))
+
+	// 🔁 Повторный POST с тем же Idempotency-Key получает сохранённый ответ
+	app.Use(orNext(r.Idempotency))
+
+	// ✅ API v1: стабильные модели в едином конверте
+	v1 := app.Group(handler.V1Prefix)
+	v1.Get("/environments", r.V1.ListEnvironments)                     // Список окружений
+	v1.Get("/environments/overview", r.V1.EnvironmentsOverview)        // Сводка по всем окружениям
+	v1.Get("/environments/compare", r.V1.CompareEnvironments)          // Что есть в from и ещё не доехало до to
+	v1.Get("/environments/:id", r.V1.GetEnvironment)                   // Последний деплой в окружение
+	v1.Get("/commits/:ref/:sha", r.V1.ListCommits)                     // Коммиты сборки
+	v1.Get("/commits/:ref/:sha/release-notes", r.V1.ReleaseNotes)      // Изменения сборки по merge request
+	v1.Get("/pipelines/:pipeline_id/deploy-jobs", r.V1.ListDeployJobs) // Deploy-джобы пайплайна
+	v1.Post("/jobs/:job_id/play", orNext(r.Impersonate), r.V1.PlayJob) // Запуск deploy-джобы
+	v1.Get("/operations/:id", r.V1.GetOperation)                       // Ожидание завершения джобы (?async=true)
+	v1.Post("/promotions", orNext(r.Impersonate), r.Promotions.Create) // Продвижение сборки в следующее окружение
+	v1.Get("/promotions", r.Promotions.List)                           // Последние продвижения
+	v1.Get("/promotions/:id", r.Promotions.Get)                        // Продвижение по ID
+	v1.Post("/schedules", orNext(r.Deferred), r.Schedules.Create)      // Отложенный деплой или продвижение
+	v1.Get("/schedules", r.Schedules.List)                             // Расписания
+	v1.Get("/schedules/:id", r.Schedules.Get)                          // Расписание и результат запуска
+	v1.Delete("/schedules/:id", r.Schedules.Cancel)                    // Отмена расписания
+	v1.Post("/release-plans", orNext(r.Deferred), r.Releases.Create)   // Запуск плана релиза нескольких проектов
+	v1.Get("/release-plans", r.Releases.List)                          // Последние планы релиза
+	v1.Get("/release-plans/:id", r.Releases.Get)                       // Состояние плана и шагов
+
+	// ⚠️ Маршруты без версии (устаревшие, работают в переходный период)
+	legacy := orNext(r.Deprecated)
+	app.Get("/environments", legacy, r.GitLab.GetEnvironments)                               // Получить список окружений
+	app.Get("/environments/:id", legacy, r.GitLab.GetEnvironmentDetails)                     // Получить детали окружения
+	app.Get("/commits/:ref/:sha", legacy, r.GitLab.GetCommitsInBuild)                        // Получить коммиты сборки
+	app.Get("/pipelines/:pipeline_id/deploy-jobs", legacy, r.GitLab.GetDeployJobs)           // Получить deploy-джобы
+	app.Post("/jobs/:job_id/play", legacy, orNext(r.Impersonate), r.GitLab.TriggerDeployJob) // ✅ Запуск deploy-джобы (от имени пользователя, если настроено)
+
+	// 🛠 Административные эндпоинты (только для auth.admins)
+	admin := app.Group("/admin", orNext(r.AdminOnly))
+	admin.Get("/config", r.Admin.GetConfigStatus)      // Действующая конфигурация и история перезагрузок
+	admin.Post("/config/reload", r.Admin.ReloadConfig) // Перечитать конфигурацию
+}
+
+// orNext возвращает обработчик или, если он не задан, пропускающий слой
+func orNext(h fiber.Handler) fiber.Handler {
+	if h != nil {
+		return h
+	}
+	return func(c *fiber.Ctx) error { return c.Next() }
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// certCheckInterval - как часто при TLS-рукопожатии проверять, не обновились ли файлы сертификата
+const certCheckInterval = 10 * time.Second
+
+// CertReloader отдаёт TLS-сертификат и перечитывает его с диска при изменении файлов
+// (например, после продления cert-manager), без перезапуска сервиса
+type CertReloader struct {
+	certFile string
+	keyFile  string
+
+	mu          sync.RWMutex
+	cert        *tls.Certificate
+	modTime     time.Time
+	lastChecked time.Time
+}
+
+// NewCertReloader загружает сертификат и ключ; ошибка возвращается, если их не удалось прочитать
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// TLSConfig возвращает конфигурацию TLS, использующую актуальный сертификат
+func (r *CertReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: r.GetCertificate,
+	}
+}
+
+// GetCertificate вызывается при каждом TLS-рукопожатии
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	cert, due := r.cert, time.Since(r.lastChecked) >= certCheckInterval
+	r.mu.RUnlock()
+
+	if due {
+		if err := r.reloadIfChanged(); err != nil {
+			// Оставляем прежний сертификат: лучше отдать старый, чем оборвать соединения
+			log.Error().Err(err).Msg("❌ Ошибка перечитывания TLS-сертификата")
+		}
+		r.mu.RLock()
+		cert = r.cert
+		r.mu.RUnlock()
+	}
+
+	return cert, nil
+}
+
+// reloadIfChanged перечитывает сертификат, если файлы изменились с прошлой загрузки
+func (r *CertReloader) reloadIfChanged() error {
+	modTime, err := r.latestModTime()
+
+	r.mu.Lock()
+	r.lastChecked = time.Now()
+	unchanged := err == nil && !modTime.After(r.modTime)
+	r.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	if unchanged {
+		return nil
+	}
+	return r.reload()
+}
+
+// reload загружает пару сертификат/ключ с диска
+func (r *CertReloader) reload() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки TLS-сертификата %s: %w", r.certFile, err)
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.modTime = modTime
+	r.lastChecked = time.Now()
+	r.mu.Unlock()
+
+	log.Info().Msgf("🔐 TLS-сертификат загружен: %s", r.certFile)
+	return nil
+}
+
+// latestModTime возвращает время последнего изменения сертификата или ключа
+func (r *CertReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("ошибка чтения %s: %w", file, err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/tracing"
+)
+
+// EnvironmentComparison - расхождение между двумя окружениями (стендами)
+type EnvironmentComparison struct {
+	From     *adapter.DeploymentInfo // Последний деплой в нижнее окружение (например staging)
+	To       *adapter.DeploymentInfo // Последний деплой в верхнее окружение (например production)
+	Commits  []adapter.CommitInfo    // Коммиты, которые есть в From, но ещё не доехали до To
+	JiraKeys []string                // Jira-ключи этих коммитов (уникальные, по алфавиту)
+	BehindBy int                     // Сколько коммитов To отсутствует в From
+}
+
+// InSync - в окружениях развёрнут один и тот же коммит
+func (c *EnvironmentComparison) InSync() bool {
+	return c.From.SHA == c.To.SHA
+}
+
+// Behind - нижнее окружение отстаёт от верхнего: в To есть коммиты, которых нет в From
+func (c *EnvironmentComparison) Behind() bool {
+	return c.BehindBy > 0
+}
+
+// Diverged - окружения разошлись: у каждого есть коммиты, которых нет у другого
+func (c *EnvironmentComparison) Diverged() bool {
+	return len(c.Commits) > 0 && c.Behind()
+}
+
+// FindEnvironment ищет окружение проекта по имени
+func (s *GitLabService) FindEnvironment(ctx context.Context, name string) (*adapter.Environment, error) {
+	environments, err := s.GetEnvironments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range environments {
+		if environments[i].Name == name {
+			return &environments[i], nil
+		}
+	}
+	return nil, apperror.NotFound("окружение %q не найдено", name)
+}
+
+// CurrentDeployment возвращает последний деплой в окружение с именем name
+func (s *GitLabService) CurrentDeployment(ctx context.Context, name string) (*adapter.DeploymentInfo, error) {
+	env, err := s.FindEnvironment(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	deployment, err := s.GetEnvironmentDetails(ctx, strconv.Itoa(env.ID))
+	if err != nil {
+		return nil, err
+	}
+	if deployment.SHA == "" {
+		return nil, apperror.Conflict("в окружение %q ещё ничего не деплоилось", name)
+	}
+	return deployment, nil
+}
+
+// CompareEnvironments сравнивает развёрнутые сборки окружений from и to: какие коммиты
+// из from ещё не попали в to и не отстаёт ли from от to
+func (s *GitLabService) CompareEnvironments(ctx context.Context, from, to string) (comparison *EnvironmentComparison, err error) {
+	ctx, span := tracing.Start(ctx, "GitLabService.CompareEnvironments",
+		attribute.String("gitlab.environment.from", from),
+		attribute.String("gitlab.environment.to", to))
+	defer func() { tracing.End(span, err) }()
+
+	if from == to {
+		return nil, apperror.Validation("Окружения from и to должны различаться")
+	}
+
+	comparison = &EnvironmentComparison{}
+	if comparison.From, err = s.CurrentDeployment(ctx, from); err != nil {
+		return nil, err
+	}
+	if comparison.To, err = s.CurrentDeployment(ctx, to); err != nil {
+		return nil, err
+	}
+
+	if comparison.InSync() {
+		comparison.JiraKeys = []string{}
+		return comparison, nil
+	}
+
+	// Сравниваем через общего предка: окружения могут стоять на разных ветках (release/* и main),
+	// и история ветки одного окружения не обязана содержать коммит другого.
+	// Что поедет в to: коммиты from, которых нет в to
+	comparison.Commits, err = s.client.CompareCommits(ctx, comparison.To.SHA, comparison.From.SHA)
+	if err != nil {
+		return nil, err
+	}
+
+	// Отставание: коммиты to, которых нет в from (например хотфикс, выкаченный сразу в production)
+	behind, err := s.client.CompareCommits(ctx, comparison.From.SHA, comparison.To.SHA)
+	if err != nil {
+		return nil, err
+	}
+	comparison.BehindBy = len(behind)
+
+	comparison.JiraKeys = jiraKeys(comparison.Commits)
+
+	span.SetAttributes(
+		attribute.Int("gitlab.commits.ahead", len(comparison.Commits)),
+		attribute.Int("gitlab.commits.behind", comparison.BehindBy),
+	)
+	log.Info().Msgf("🔀 Сравнение %s → %s: впереди на %d коммит(ов), отстаёт на %d", from, to, len(comparison.Commits), comparison.BehindBy)
+	return comparison, nil
+}
+
+// commitsBetween возвращает коммиты ветки ref после fromSHA до toSHA включительно;
+// "новых коммитов нет" - не ошибка, а пустой список
+func (s *GitLabService) commitsBetween(ctx context.Context, ref, fromSHA, toSHA string) ([]adapter.CommitInfo, error) {
+	commits, err := s.client.GetCommitsBetweenSHAs(ctx, ref, fromSHA, toSHA)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return []adapter.CommitInfo{}, nil
+	}
+	return commits, err
+}
+
+// jiraKeys собирает уникальные Jira-ключи коммитов
+func jiraKeys(commits []adapter.CommitInfo) []string {
+	keys := []string{}
+	for _, commit := range commits {
+		for _, key := range commit.JiraKeys {
+			if !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/tracing"
+)
+
+// DeployToEnvironment запускает деплой в окружение, если оно не заблокировано и не заморожено.
+// Джоба задаётся явно (jobID) или ищется среди deploy-джоб пайплайна pipelineID.
+func (s *GitLabService) DeployToEnvironment(ctx context.Context, environment string, pipelineID, jobID int) (job *adapter.TriggeredJob, err error) {
+	ctx, span := tracing.Start(ctx, "GitLabService.DeployToEnvironment",
+		attribute.String("gitlab.environment", environment),
+		attribute.Int("gitlab.pipeline.id", pipelineID),
+		attribute.Int("gitlab.job.id", jobID))
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.CheckDeployAllowed(environment, time.Now()); err != nil {
+		return nil, err
+	}
+
+	jobID, err = s.resolveDeployJob(ctx, environment, pipelineID, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	job, _, err = s.TriggerDeployJob(ctx, strconv.Itoa(jobID))
+	return job, err
+}
+
+// resolveDeployJob возвращает jobID, а если он не задан - deploy-джобу окружения из пайплайна pipelineID.
+// Явно заданная джоба должна деплоить именно в environment (иначе 422): блокировки и заморозки
+// проверяются для environment, и джоба другого окружения обошла бы их.
+func (s *GitLabService) resolveDeployJob(ctx context.Context, environment string, pipelineID, jobID int) (int, error) {
+	if jobID != 0 {
+		job, err := s.client.GetJob(ctx, strconv.Itoa(jobID))
+		if err != nil {
+			return 0, err
+		}
+		jobEnvironment, err := s.EnvironmentForJob(ctx, job.Name)
+		if err != nil {
+			return 0, err
+		}
+		if jobEnvironment != environment {
+			if jobEnvironment == "" {
+				return 0, apperror.Validation("джоба %d (%s) не относится к окружению %q", job.ID, job.Name, environment)
+			}
+			return 0, apperror.Validation("джоба %d (%s) деплоит в окружение %q, а не в %q", job.ID, job.Name, jobEnvironment, environment)
+		}
+		return jobID, nil
+	}
+	if pipelineID == 0 {
+		return 0, apperror.Validation("Необходимо указать pipeline_id или job_id")
+	}
+
+	jobs, err := s.GetDeployJobs(ctx, strconv.Itoa(pipelineID))
+	if err != nil {
+		return 0, err
+	}
+	deployJob, err := s.FindDeployJob(jobs, environment)
+	if err != nil {
+		return 0, err
+	}
+	return deployJob.ID, nil
+}
+
+// CheckJob проверяет, что джобу можно запустить: она ждёт ручного запуска (иначе 409)
+// и относится к deploy-стадии проекта (иначе 422). Ветка сборки проверяется отдельно (см. CheckRef).
+func (s *GitLabService) CheckJob(job *adapter.TriggeredJob) error {
+	switch job.Status {
+	case "manual":
+	case "created", "waiting_for_resource", "scheduled":
+		return apperror.Conflict("джоба %d (%s) ещё не готова к запуску: статус %s", job.ID, job.Name, job.Status)
+	case "pending", "preparing", "running":
+		return apperror.Conflict("джоба %d (%s) уже выполняется: статус %s", job.ID, job.Name, job.Status)
+	default:
+		return apperror.Conflict("джоба %d (%s) не ожидает ручного запуска: статус %s (для повторного деплоя используйте откат)", job.ID, job.Name, job.Status)
+	}
+
+	stages := s.project.DeployStages
+	if len(stages) == 0 {
+		stages = config.DefaultDeployStages
+	}
+	if !slices.ContainsFunc(stages, func(pattern string) bool {
+		matched, _ := path.Match(pattern, job.Stage)
+		return matched
+	}) {
+		return apperror.Validation("джоба %d (%s) в стадии %q, а не в deploy-стадии (%s)", job.ID, job.Name, job.Stage, strings.Join(stages, ", "))
+	}
+	return nil
+}
+
+// finalJobStatuses - статусы джобы GitLab, после которых она уже не изменится
+var finalJobStatuses = map[string]bool{"success": true, "failed": true, "canceled": true, "skipped": true}
+
+// IsFinalJobStatus проверяет, завершилась ли джоба
+func IsFinalJobStatus(status string) bool {
+	return finalJobStatuses[status]
+}
+
+// WaitForJob опрашивает джобу каждые poll, пока она не завершится или не отменён ctx.
+// При отмене ctx возвращается последнее известное состояние джобы вместе с ошибкой.
+func (s *GitLabService) WaitForJob(ctx context.Context, jobID int, poll time.Duration) (job *adapter.TriggeredJob, err error) {
+	ctx, span := tracing.Start(ctx, "GitLabService.WaitForJob", attribute.Int("gitlab.job.id", jobID))
+	defer func() { tracing.End(span, err) }()
+
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	for {
+		current, err := s.client.GetJob(ctx, strconv.Itoa(jobID))
+		switch {
+		case err == nil:
+			job = current
+			if IsFinalJobStatus(job.Status) {
+				span.SetAttributes(attribute.String("gitlab.job.status", job.Status))
+				return job, nil
+			}
+		case ctx.Err() == nil:
+			// Временная ошибка GitLab не прерывает ожидание
+			log.Warn().Err(err).Msgf("⚠️ Не удалось получить состояние джобы %d, повторим", jobID)
+		}
+
+		select {
+		case <-ctx.Done():
+			return job, apperror.Unavailable(ctx.Err(), "джоба %d не завершилась за отведённое время", jobID)
+		case <-ticker.C:
+		}
+	}
+}
+
+// TraceTailLines - сколько последних строк лога джобы отдаётся клиенту
+const TraceTailLines = 50
+
+// JobRun - итог выполнения джобы
+type JobRun struct {
+	Job       *adapter.TriggeredJob
+	Duration  time.Duration
+	TraceTail string // Последние строки лога без управляющих последовательностей терминала
+}
+
+// AwaitJob ждёт завершения джобы и собирает её итог: состояние, длительность и конец лога.
+// Если джоба завершилась неуспешно, возвращается ошибка apperror.KindJobFailed, если не дождались -
+// ошибка WaitForJob; итог в обоих случаях тоже возвращается (если состояние джобы известно).
+func (s *GitLabService) AwaitJob(ctx context.Context, jobID int, poll time.Duration) (run *JobRun, err error) {
+	ctx, span := tracing.Start(ctx, "GitLabService.AwaitJob", attribute.Int("gitlab.job.id", jobID))
+	defer func() { tracing.End(span, err) }()
+
+	started := time.Now()
+	job, err := s.WaitForJob(ctx, jobID, poll)
+	if job == nil {
+		return nil, err
+	}
+
+	run = &JobRun{Job: job, Duration: jobDuration(job, time.Since(started))}
+	if err != nil {
+		return run, err
+	}
+
+	trace, traceErr := s.client.GetJobTrace(ctx, strconv.Itoa(jobID))
+	if traceErr != nil {
+		log.Warn().Err(traceErr).Msgf("⚠️ Не удалось получить лог джобы %d", jobID)
+	} else {
+		run.TraceTail = TraceTail(trace, TraceTailLines)
+	}
+
+	if job.Status != "success" {
+		return run, apperror.JobFailed("джоба %d завершилась со статусом %s", jobID, job.Status)
+	}
+	return run, nil
+}
+
+// jobDuration возвращает длительность джобы по данным GitLab, а если их нет - время ожидания
+func jobDuration(job *adapter.TriggeredJob, waited time.Duration) time.Duration {
+	switch {
+	case job.Duration > 0:
+		return time.Duration(job.Duration * float64(time.Second))
+	case !job.StartedAt.IsZero() && !job.FinishedAt.IsZero():
+		return job.FinishedAt.Sub(job.StartedAt)
+	default:
+		return waited
+	}
+}
+
+// Управляющие последовательности в логах GitLab: цвета терминала и маркеры сворачиваемых секций
+var (
+	ansiEscape     = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+	sectionMarker  = regexp.MustCompile(`section_(?:start|end):[0-9]+:[^\r\n]+\r?`)
+	carriageReturn = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+)
+
+// TraceTail возвращает последние lines строк лога джобы без управляющих последовательностей
+func TraceTail(trace string, lines int) string {
+	trace = ansiEscape.ReplaceAllString(trace, "")
+	trace = sectionMarker.ReplaceAllString(trace, "")
+	trace = strings.TrimRight(carriageReturn.Replace(trace), "\n")
+	if trace == "" {
+		return ""
+	}
+
+	all := strings.Split(trace, "\n")
+	if len(all) > lines {
+		all = all[len(all)-lines:]
+	}
+	return strings.Join(all, "\n")
+}
+
+// ResolvePipeline возвращает последний пайплайн ветки или тега (версии сборки)
+func (s *GitLabService) ResolvePipeline(ctx context.Context, ref string) (pipeline *adapter.Pipeline, err error) {
+	ctx, span := tracing.Start(ctx, "GitLabService.ResolvePipeline", attribute.String("gitlab.ref", ref))
+	defer func() { tracing.End(span, err) }()
+
+	return s.client.GetLatestPipeline(ctx, ref)
+}
+
+// RedeployJob перезапускает ранее выполненную deploy-джобу, например, чтобы вернуть окружение
+// к предыдущей сборке. Блокировки и заморозки не проверяются: откат восстанавливает прежнее состояние.
+// Ветка сборки проверяется (см. CheckRef): откатиться на сборку из запрещённой ветки нельзя.
+func (s *GitLabService) RedeployJob(ctx context.Context, jobID int) (job *adapter.TriggeredJob, err error) {
+	ctx, span := tracing.Start(ctx, "GitLabService.RedeployJob", attribute.Int("gitlab.job.id", jobID))
+	defer func() { tracing.End(span, err) }()
+
+	if s.hasRefPolicy() {
+		previous, err := s.client.GetJob(ctx, strconv.Itoa(jobID))
+		if err != nil {
+			return nil, err
+		}
+		environment, err := s.jobEnvironment(ctx, previous)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.checkJobRef(environment, previous); err != nil {
+			log.Warn().Err(err).Msgf("⚠️ Откат на deploy-джобу jobID=%d запрещён", jobID)
+			return nil, err
+		}
+	}
+
+	job, err = s.client.RetryJob(ctx, strconv.Itoa(jobID))
+	if err != nil {
+		log.Error().Err(err).Msgf("❌ Ошибка перезапуска deploy-джобы jobID=%d", jobID)
+		return nil, err
+	}
+	return job, nil
+}
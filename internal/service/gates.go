@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/tracing"
+)
+
+// Проверки качества сборки перед деплоем (GateResult.Gate)
+const (
+	GatePipelineSuccess = "pipeline_success" // Все джобы пайплайна вне deploy-стадий успешны
+	GateTestPassRate    = "test_pass_rate"   // Доля успешных тестов не ниже порога
+	GateVulnerabilities = "vulnerabilities"  // Нет открытых уязвимостей заданного уровня
+	GateProtectedBranch = "protected_branch" // Коммит есть в защищённой ветке
+)
+
+// GateResult - результат проверки качества сборки. Отдаётся клиенту как есть
+// в details ошибки, если деплой отклонён.
+type GateResult struct {
+	Gate    string `json:"gate"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message"`
+}
+
+// CheckQualityGates выполняет проверки качества (quality_gates) окружения environment для сборки
+// джобы job. Проверки запрашивают GitLab параллельно (не более s.concurrency запросов), чтобы
+// уложиться в таймаут запуска джобы. Результат каждой проверки пишется в журнал аудита. Если хотя бы
+// одна проверка не пройдена - 409 с результатами всех проверок в details. Без проверок возвращается nil.
+func (s *GitLabService) CheckQualityGates(ctx context.Context, environment string, job *adapter.TriggeredJob) (results []GateResult, err error) {
+	gates := s.EnvironmentSettings(environment).QualityGates
+	if environment == "" || !gates.Enabled() {
+		return nil, nil
+	}
+	if job.Pipeline == nil {
+		return nil, apperror.Conflict("у джобы %d (%s) нет пайплайна, проверки качества окружения %q невозможны", job.ID, job.Name, environment)
+	}
+
+	ctx, span := tracing.Start(ctx, "GitLabService.CheckQualityGates",
+		attribute.String("gitlab.environment", environment),
+		attribute.Int("gitlab.pipeline.id", job.Pipeline.ID))
+	defer func() { tracing.End(span, err) }()
+
+	pipelineID := strconv.Itoa(job.Pipeline.ID)
+	var checks []func(ctx context.Context) (GateResult, error)
+	if gates.PipelineSuccess {
+		checks = append(checks, func(ctx context.Context) (GateResult, error) { return s.gatePipelineSuccess(ctx, pipelineID) })
+	}
+	if gates.MinTestPassRate > 0 {
+		checks = append(checks, func(ctx context.Context) (GateResult, error) {
+			return s.gateTestPassRate(ctx, pipelineID, gates.MinTestPassRate)
+		})
+	}
+	if gates.VulnerabilitySeverity != "" {
+		checks = append(checks, func(ctx context.Context) (GateResult, error) {
+			return s.gateVulnerabilities(ctx, pipelineID, gates.VulnerabilitySeverity)
+		})
+	}
+	if gates.ProtectedBranch {
+		checks = append(checks, func(ctx context.Context) (GateResult, error) { return s.gateProtectedBranch(ctx, job.Pipeline.SHA) })
+	}
+
+	results = make([]GateResult, len(checks))
+	err = s.parallel(ctx, len(checks), func(ctx context.Context, i int) error {
+		result, err := checks[i](ctx)
+		results[i] = result
+		return err
+	})
+	if err != nil {
+		log.Error().Err(err).Msgf("❌ Не удалось выполнить проверки качества для деплоя в %s", environment)
+		return nil, err
+	}
+
+	var failed []string
+	for _, result := range results {
+		if !result.Passed {
+			failed = append(failed, result.Gate)
+		}
+
+		log.Info().
+			Str("audit", "quality_gate").
+			Str("project", s.project.Name).
+			Str("environment", environment).
+			Int("job_id", job.ID).
+			Int("pipeline_id", job.Pipeline.ID).
+			Str("gate", result.Gate).
+			Bool("passed", result.Passed).
+			Msgf("🚦 Проверка %s для деплоя в %s: %s", result.Gate, environment, result.Message)
+	}
+
+	if len(failed) > 0 {
+		return results, apperror.WithDetails(apperror.Conflict("сборка пайплайна %d не прошла проверки качества окружения %q: %s",
+			job.Pipeline.ID, environment, strings.Join(failed, ", ")), results)
+	}
+	return results, nil
+}
+
+// gatePipelineSuccess проверяет, что все джобы пайплайна вне deploy-стадий завершились успешно
+// (упавшие джобы с allow_failure не учитываются)
+func (s *GitLabService) gatePipelineSuccess(ctx context.Context, pipelineID string) (GateResult, error) {
+	jobs, err := s.client.GetPipelineBuildJobs(ctx, pipelineID)
+	if err != nil {
+		return GateResult{}, err
+	}
+
+	var unfinished []string
+	for _, job := range jobs {
+		if job.Status == "success" || job.Status == "skipped" || (job.Status == "failed" && job.AllowFailure) {
+			continue
+		}
+		unfinished = append(unfinished, fmt.Sprintf("%s (%s)", job.Stand, job.Status))
+	}
+
+	if len(unfinished) > 0 {
+		return GateResult{Gate: GatePipelineSuccess, Message: "не завершились успешно: " + strings.Join(unfinished, ", ")}, nil
+	}
+	return GateResult{Gate: GatePipelineSuccess, Passed: true, Message: fmt.Sprintf("все %d джоб(ы) пайплайна успешны", len(jobs))}, nil
+}
+
+// gateTestPassRate проверяет, что доля успешных тестов пайплайна не ниже minRate процентов.
+// Пропущенные тесты не учитываются; пайплайн без тестов проверку не проходит.
+func (s *GitLabService) gateTestPassRate(ctx context.Context, pipelineID string, minRate float64) (GateResult, error) {
+	summary, err := s.client.GetTestReportSummary(ctx, pipelineID)
+	if err != nil {
+		return GateResult{}, err
+	}
+
+	executed := summary.Total.Count - summary.Total.Skipped
+	if executed <= 0 {
+		return GateResult{Gate: GateTestPassRate, Message: "в отчёте о тестах пайплайна нет выполненных тестов"}, nil
+	}
+
+	rate := float64(summary.Total.Success) * 100 / float64(executed)
+	message := fmt.Sprintf("успешно %d из %d тестов (%.1f%%, порог %.1f%%)", summary.Total.Success, executed, rate, minRate)
+	return GateResult{Gate: GateTestPassRate, Passed: rate >= minRate, Message: message}, nil
+}
+
+// gateVulnerabilities проверяет, что в пайплайне нет неотклонённых уязвимостей уровня severity и выше
+func (s *GitLabService) gateVulnerabilities(ctx context.Context, pipelineID, severity string) (GateResult, error) {
+	findings, err := s.client.GetVulnerabilityFindings(ctx, pipelineID, config.SeveritiesFrom(severity))
+	if err != nil {
+		return GateResult{}, err
+	}
+
+	if len(findings) > 0 {
+		names := make([]string, 0, len(findings))
+		for _, finding := range findings {
+			names = append(names, fmt.Sprintf("%s (%s)", finding.Name, finding.Severity))
+		}
+		return GateResult{Gate: GateVulnerabilities, Message: fmt.Sprintf("%d уязвимост(ей) уровня %s и выше: %s",
+			len(findings), severity, strings.Join(names, ", "))}, nil
+	}
+	return GateResult{Gate: GateVulnerabilities, Passed: true, Message: fmt.Sprintf("нет уязвимостей уровня %s и выше", severity)}, nil
+}
+
+// gateProtectedBranch проверяет, что коммит сборки есть в защищённой ветке проекта
+func (s *GitLabService) gateProtectedBranch(ctx context.Context, sha string) (GateResult, error) {
+	protected, err := s.client.GetProtectedBranches(ctx)
+	if err != nil {
+		return GateResult{}, err
+	}
+	branches, err := s.client.GetCommitBranches(ctx, sha)
+	if err != nil {
+		return GateResult{}, err
+	}
+
+	for _, branch := range branches {
+		for _, rule := range protected {
+			if matched, _ := path.Match(rule.Name, branch); matched {
+				return GateResult{Gate: GateProtectedBranch, Passed: true, Message: fmt.Sprintf("коммит %s есть в защищённой ветке %s", sha, branch)}, nil
+			}
+		}
+	}
+	return GateResult{Gate: GateProtectedBranch, Message: fmt.Sprintf("коммита %s нет ни в одной защищённой ветке", sha)}, nil
+}
+
+// hasQualityGates проверяет, есть ли в проекте окружения с проверками качества
+func (s *GitLabService) hasQualityGates() bool {
+	for _, env := range s.project.Environments {
+		if env.QualityGates.Enabled() {
+			return true
+		}
+	}
+	return false
+}
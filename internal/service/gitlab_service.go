@@ -5,25 +5,51 @@ import (
 	"time"
 
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/vkr-mtuci/gitlab-service/config"
 	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/metrics"
+	"github.com/vkr-mtuci/gitlab-service/internal/tracing"
 )
 
+// requestTimeout - максимальное время выполнения одной операции сервиса
+const requestTimeout = 10 * time.Second
+
+// preflightTimeout - бюджет проверок перед запуском джобы (см. checkTrigger): джоба, окружение
+// и до четырёх проверок качества, часть из них постраничные. Запуск в GitLab получает отдельный requestTimeout.
+const preflightTimeout = 30 * time.Second
+
 // GitLabService - сервис для работы с GitLab API
 type GitLabService struct {
-	client adapter.GitLabClientInterface // Используем интерфейс для легкого мокирования
+	client      adapter.GitLabClientInterface // Используем интерфейс для легкого мокирования
+	project     config.ProjectConfig          // Настройки проекта: окружения, блокировки, заморозки
+	concurrency int                           // Максимум параллельных запросов к GitLab в одной операции
 }
 
 // NewGitLabService создаёт новый экземпляр GitLabService
 func NewGitLabService(client adapter.GitLabClientInterface) *GitLabService {
-	return &GitLabService{client: client}
+	return NewProjectService(client, config.ProjectConfig{}, config.DefaultGitLabConcurrency)
+}
+
+// NewProjectService создаёт сервис проекта с его настройками из конфигурации
+func NewProjectService(client adapter.GitLabClientInterface, project config.ProjectConfig, concurrency int) *GitLabService {
+	if concurrency <= 0 {
+		concurrency = config.DefaultGitLabConcurrency
+	}
+	return &GitLabService{client: client, project: project, concurrency: concurrency}
 }
 
 // GetEnvironments получает список окружений для проекта
-func (s *GitLabService) GetEnvironments() ([]adapter.Environment, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (s *GitLabService) GetEnvironments(ctx context.Context) (environments []adapter.Environment, err error) {
+	ctx, span := tracing.Start(ctx, "GitLabService.GetEnvironments")
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
 	defer cancel()
 
-	environments, err := s.client.GetEnvironments(ctx)
+	environments, err = s.client.GetEnvironments(ctx)
 	if err != nil {
 		log.Error().Err(err).Msg("❌ Ошибка получения окружений GitLab")
 		return nil, err
@@ -34,8 +60,12 @@ func (s *GitLabService) GetEnvironments() ([]adapter.Environment, error) {
 }
 
 // GetEnvironmentDetails получает детальную информацию о конкретном окружении
-func (s *GitLabService) GetEnvironmentDetails(environmentID string) (*adapter.DeploymentInfo, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (s *GitLabService) GetEnvironmentDetails(ctx context.Context, environmentID string) (details *adapter.DeploymentInfo, err error) {
+	ctx, span := tracing.Start(ctx, "GitLabService.GetEnvironmentDetails",
+		attribute.String("gitlab.environment.id", environmentID))
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
 	defer cancel()
 
 	if environmentID == "" {
@@ -44,24 +74,32 @@ func (s *GitLabService) GetEnvironmentDetails(environmentID string) (*adapter.De
 	}
 
 	// Запрашиваем информацию о деплое через клиент
-	details, err := s.client.GetEnvironmentDetails(ctx, environmentID)
+	details, err = s.client.GetEnvironmentDetails(ctx, environmentID)
 	if err != nil {
 		log.Error().Err(err).Msgf("❌ Ошибка получения информации по окружению %s", environmentID)
 		return nil, err
 	}
 
+	span.SetAttributes(
+		attribute.String("gitlab.environment.name", details.EnvironmentName),
+		attribute.Int("gitlab.pipeline.id", details.PipelineID),
+	)
+
 	log.Info().Msgf("✅ Успешно получена информация по окружению %s", environmentID)
 	return details, nil
 }
 
 // ErrMissingEnvironmentID возвращается, если не указан ID окружения
-var ErrMissingEnvironmentID = &adapter.GitLabError{
-	Message: "ID окружения не указан",
-}
+var ErrMissingEnvironmentID = apperror.Validation("ID окружения не указан")
 
 // GetCommitsInBuild - получает список коммитов в сборке
-func (s *GitLabService) GetCommitsInBuild(ref, currentSHA string) ([]adapter.CommitInfo, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (s *GitLabService) GetCommitsInBuild(ctx context.Context, ref, currentSHA string) (commits []adapter.CommitInfo, err error) {
+	ctx, span := tracing.Start(ctx, "GitLabService.GetCommitsInBuild",
+		attribute.String("gitlab.ref", ref),
+		attribute.String("gitlab.sha", currentSHA))
+	defer func() { tracing.End(span, err) }()
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
 	defer cancel()
 
 	previousSHA, err := s.client.GetPreviousPipelineSHA(ctx, ref, currentSHA)
@@ -69,21 +107,27 @@ func (s *GitLabService) GetCommitsInBuild(ref, currentSHA string) ([]adapter.Com
 		log.Warn().Err(err).Msg("⚠️ Не удалось найти предыдущую сборку, возможно первая сборка на этой ветке")
 		return nil, err
 	}
+	span.SetAttributes(attribute.String("gitlab.previous_sha", previousSHA))
 
-	commits, err := s.client.GetCommitsBetweenSHAs(ctx, ref, previousSHA, currentSHA)
+	commits, err = s.client.GetCommitsBetweenSHAs(ctx, ref, previousSHA, currentSHA)
 	if err != nil {
 		log.Error().Err(err).Msg("❌ Ошибка получения коммитов между сборками")
 		return nil, err
 	}
 
+	span.SetAttributes(attribute.Int("gitlab.commits.count", len(commits)))
 	return commits, nil
 }
 
 // GetDeployJobs - получает список джоб в deploy-стадии для указанного пайплайна
-func (s *GitLabService) GetDeployJobs(ctx context.Context, pipelineID string) ([]adapter.JobInfo, error) {
+func (s *GitLabService) GetDeployJobs(ctx context.Context, pipelineID string) (jobs []adapter.JobInfo, err error) {
+	ctx, span := tracing.Start(ctx, "GitLabService.GetDeployJobs",
+		attribute.String("gitlab.pipeline.id", pipelineID))
+	defer func() { tracing.End(span, err) }()
+
 	log.Debug().Msgf("📡 Получение deploy-джоб для pipelineID=%s", pipelineID)
 
-	jobs, err := s.client.GetPipelineJobs(ctx, pipelineID)
+	jobs, err = s.client.GetPipelineJobs(ctx, pipelineID)
 	if err != nil {
 		log.Error().Err(err).Msg("❌ Ошибка получения deploy-джоб")
 		return nil, err
@@ -92,15 +136,51 @@ func (s *GitLabService) GetDeployJobs(ctx context.Context, pipelineID string) ([
 	return jobs, nil
 }
 
-// TriggerDeployJob - запускает указанную deploy-джобу
-func (s *GitLabService) TriggerDeployJob(ctx context.Context, jobID string) (*adapter.TriggeredJob, error) {
+// TriggerDeployJob - запускает указанную deploy-джобу, предварительно проверив её, ветку сборки
+// и проверки качества окружения (см. checkTrigger). Возвращает и результаты проверок качества.
+func (s *GitLabService) TriggerDeployJob(ctx context.Context, jobID string) (job *adapter.TriggeredJob, gates []GateResult, err error) {
+	ctx, span := tracing.Start(ctx, "GitLabService.TriggerDeployJob",
+		attribute.String("gitlab.job.id", jobID))
+	defer func() { tracing.End(span, err) }()
+
+	preflightCtx, cancelPreflight := context.WithTimeout(ctx, preflightTimeout)
+	defer cancelPreflight()
+
+	current, err := s.client.GetJob(preflightCtx, jobID)
+	if err != nil {
+		log.Error().Err(err).Msgf("❌ Ошибка получения deploy-джобы jobID=%s перед запуском", jobID)
+		metrics.DeployJobsTriggered.WithLabelValues(metrics.UnknownEnvironment, apperror.From(err).Code()).Inc()
+		return nil, nil, err
+	}
+
+	environment, err := s.jobEnvironment(preflightCtx, current)
+	if err != nil {
+		log.Error().Err(err).Msgf("❌ Не удалось определить окружение deploy-джобы jobID=%s", jobID)
+		metrics.DeployJobsTriggered.WithLabelValues(metrics.UnknownEnvironment, apperror.From(err).Code()).Inc()
+		return nil, nil, err
+	}
+	label := s.environmentLabel(preflightCtx, environment, current)
+
+	gates, err = s.checkTrigger(preflightCtx, environment, current)
+	if err != nil {
+		log.Warn().Err(err).Msgf("⚠️ Deploy-джоба jobID=%s не может быть запущена", jobID)
+		metrics.DeployJobsTriggered.WithLabelValues(label, apperror.From(err).Code()).Inc()
+		return nil, gates, err
+	}
+
 	log.Debug().Msgf("🚀 Запуск deploy-джобы jobID=%s", jobID)
 
-	job, err := s.client.TriggerDeployJob(ctx, jobID)
+	playCtx, cancelPlay := context.WithTimeout(ctx, requestTimeout)
+	defer cancelPlay()
+
+	job, err = s.client.TriggerDeployJob(playCtx, jobID)
 	if err != nil {
 		log.Error().Err(err).Msg("❌ Ошибка запуска deploy-джобы")
-		return nil, err
+		metrics.DeployJobsTriggered.WithLabelValues(label, apperror.From(err).Code()).Inc()
+		return nil, gates, err
 	}
 
-	return job, nil
+	span.SetAttributes(attribute.String("gitlab.job.name", job.Name), attribute.String("gitlab.environment", label))
+	metrics.DeployJobsTriggered.WithLabelValues(label, metrics.OutcomeTriggered).Inc()
+	return job, gates, nil
 }
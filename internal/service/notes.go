@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/tracing"
+)
+
+// ReleaseNote - изменения сборки из одного merge request
+type ReleaseNote struct {
+	MergeRequest *adapter.MergeRequest // nil - коммиты, попавшие в ветку без merge request
+	Approvers    []adapter.User        // Одобрившие merge request
+	Commits      []adapter.CommitInfo
+	JiraKeys     []string
+}
+
+// CommitMergeRequests возвращает merge request'ы коммитов (result[i] - для commits[i]).
+// Запросы к GitLab выполняются параллельно, не более чем s.concurrency одновременно.
+func (s *GitLabService) CommitMergeRequests(ctx context.Context, commits []adapter.CommitInfo) (result [][]adapter.MergeRequest, err error) {
+	ctx, span := tracing.Start(ctx, "GitLabService.CommitMergeRequests", attribute.Int("gitlab.commits.count", len(commits)))
+	defer func() { tracing.End(span, err) }()
+
+	result = make([][]adapter.MergeRequest, len(commits))
+	err = s.parallel(ctx, len(commits), func(ctx context.Context, i int) error {
+		mergeRequests, err := s.client.GetCommitMergeRequests(ctx, commits[i].ID)
+		result[i] = mergeRequests
+		return err
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("❌ Ошибка получения merge request'ов коммитов")
+		return nil, err
+	}
+	return result, nil
+}
+
+// ReleaseNotes собирает изменения сборки sha ветки ref (см. GetCommitsInBuild), сгруппированные
+// по merge request: коммит относится к merge request'у, влитому в ref (иначе - к первому влитому).
+// Группы идут в порядке коммитов, коммиты без merge request - последней группой.
+func (s *GitLabService) ReleaseNotes(ctx context.Context, ref, sha string) (notes []ReleaseNote, err error) {
+	ctx, span := tracing.Start(ctx, "GitLabService.ReleaseNotes",
+		attribute.String("gitlab.ref", ref),
+		attribute.String("gitlab.sha", sha))
+	defer func() { tracing.End(span, err) }()
+
+	commits, err := s.GetCommitsInBuild(ctx, ref, sha)
+	if err != nil {
+		return nil, err
+	}
+	mergeRequests, err := s.CommitMergeRequests(ctx, commits)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := map[int]int{} // IID merge request'а -> индекс группы
+	var direct []adapter.CommitInfo
+	for i, commit := range commits {
+		mergeRequest := primaryMergeRequest(mergeRequests[i], ref)
+		if mergeRequest == nil {
+			direct = append(direct, commit)
+			continue
+		}
+
+		index, ok := groups[mergeRequest.IID]
+		if !ok {
+			index = len(notes)
+			groups[mergeRequest.IID] = index
+			notes = append(notes, ReleaseNote{MergeRequest: mergeRequest})
+		}
+		notes[index].Commits = append(notes[index].Commits, commit)
+	}
+	s.loadApprovers(ctx, notes)
+
+	if len(direct) > 0 {
+		notes = append(notes, ReleaseNote{Commits: direct})
+	}
+	for i := range notes {
+		notes[i].JiraKeys = jiraKeys(notes[i].Commits)
+	}
+
+	span.SetAttributes(attribute.Int("gitlab.merge_requests.count", len(groups)))
+	log.Info().Msgf("📝 Сборка %s: %d коммит(ов) в %d merge request(ах), %d без merge request", sha, len(commits), len(groups), len(direct))
+	return notes, nil
+}
+
+// primaryMergeRequest выбирает merge request, через который коммит попал в ветку ref:
+// влитый в ref, иначе первый влитый, иначе первый из найденных; nil - коммит без merge request
+func primaryMergeRequest(mergeRequests []adapter.MergeRequest, ref string) *adapter.MergeRequest {
+	var merged *adapter.MergeRequest
+	for i := range mergeRequests {
+		if mergeRequests[i].State != "merged" {
+			continue
+		}
+		if mergeRequests[i].TargetBranch == ref {
+			return &mergeRequests[i]
+		}
+		if merged == nil {
+			merged = &mergeRequests[i]
+		}
+	}
+	if merged == nil && len(mergeRequests) > 0 {
+		merged = &mergeRequests[0]
+	}
+	return merged
+}
+
+// loadApprovers запрашивает одобривших merge request'ы заметок. Ошибка не прерывает сборку заметок:
+// одобрения могут быть недоступны токену или тарифу GitLab, список одобривших остаётся пустым.
+func (s *GitLabService) loadApprovers(ctx context.Context, notes []ReleaseNote) {
+	_ = s.parallel(ctx, len(notes), func(ctx context.Context, i int) error {
+		approvers, err := s.client.GetMergeRequestApprovers(ctx, notes[i].MergeRequest.IID)
+		if err != nil {
+			log.Warn().Err(err).Msgf("⚠️ Не удалось получить одобрения merge request !%d", notes[i].MergeRequest.IID)
+			approvers = []adapter.User{}
+		}
+		notes[i].Approvers = approvers
+		return nil
+	})
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/tracing"
+)
+
+// EnvironmentOverview - сводка по окружению: последний деплой, блокировка и заморозка
+type EnvironmentOverview struct {
+	Environment adapter.Environment
+	Deployment  *adapter.DeploymentInfo // nil - деплоев не было или их не удалось получить (см. Err)
+	Locked      bool
+	LockReason  string
+	Freeze      *config.FreezeWindow // Действующее окно заморозки
+	Err         error                // Ошибка получения деплоя; остальные поля сводки заполнены
+}
+
+// EnvironmentSettings возвращает настройки окружения проекта (блокировка, заморозки)
+func (s *GitLabService) EnvironmentSettings(name string) config.EnvironmentConfig {
+	env, _ := s.project.Environment(name)
+	return env
+}
+
+// DescribeEnvironments собирает сводку по окружениям. Последние деплои запрашиваются параллельно
+// не более чем s.concurrency запросами; ошибка по одному окружению не прерывает остальные.
+func (s *GitLabService) DescribeEnvironments(ctx context.Context, environments []adapter.Environment) []EnvironmentOverview {
+	ctx, span := tracing.Start(ctx, "GitLabService.DescribeEnvironments",
+		attribute.Int("gitlab.environments.count", len(environments)))
+	defer func() { tracing.End(span, nil) }()
+
+	now := time.Now()
+	overview := make([]EnvironmentOverview, len(environments))
+
+	_ = s.parallel(ctx, len(environments), func(ctx context.Context, i int) error {
+		overview[i] = s.describeEnvironment(ctx, environments[i], now)
+		return nil // Ошибка окружения - в overview[i].Err
+	})
+
+	failed := 0
+	for _, item := range overview {
+		if item.Err != nil {
+			failed++
+		}
+	}
+	span.SetAttributes(attribute.Int("gitlab.environments.failed", failed))
+	if failed > 0 {
+		log.Warn().Msgf("⚠️ Сводка окружений неполная: не удалось получить деплой для %d из %d окружений", failed, len(environments))
+	}
+
+	return overview
+}
+
+// describeEnvironment собирает сводку по одному окружению
+func (s *GitLabService) describeEnvironment(ctx context.Context, env adapter.Environment, now time.Time) EnvironmentOverview {
+	settings := s.EnvironmentSettings(env.Name)
+	item := EnvironmentOverview{
+		Environment: env,
+		Locked:      settings.Locked,
+		LockReason:  settings.LockReason,
+	}
+	if freeze, ok := settings.ActiveFreeze(now); ok {
+		item.Freeze = &freeze
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	details, err := s.client.GetEnvironmentDetails(ctx, strconv.Itoa(env.ID))
+	if err != nil {
+		log.Warn().Err(err).Msgf("⚠️ Не удалось получить деплой окружения %s", env.Name)
+		item.Err = err
+		return item
+	}
+	if details.JobID != 0 {
+		item.Deployment = details
+	}
+	return item
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"sync"
+)
+
+// parallel - общий пул для параллельных запросов к GitLab (gitlab.concurrency): вызывает fn для индексов
+// 0..n-1 не более чем в s.concurrency горутинах и возвращает первую ошибку. После первой ошибки
+// контекст, переданный в fn, отменяется, а оставшиеся индексы не запускаются.
+func (s *GitLabService) parallel(ctx context.Context, n int, fn func(ctx context.Context, i int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	indexes := make(chan int)
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for range min(s.concurrency, n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := fn(ctx, i); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+	var stopped error
+dispatch:
+	for i := range n {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			stopped = ctx.Err()
+			break dispatch
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return stopped // Родительский контекст отменён раньше, чем запущены все индексы
+}
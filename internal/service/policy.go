@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/metrics"
+)
+
+// CheckDeployAllowed проверяет, что деплой в окружение не запрещён блокировкой
+// или действующим окном заморозки из настроек проекта
+func (s *GitLabService) CheckDeployAllowed(environment string, now time.Time) error {
+	settings := s.EnvironmentSettings(environment)
+	if settings.Locked {
+		if settings.LockReason != "" {
+			return apperror.Conflict("окружение %q заблокировано: %s", environment, settings.LockReason)
+		}
+		return apperror.Conflict("окружение %q заблокировано", environment)
+	}
+
+	if freeze, ok := settings.ActiveFreeze(now); ok {
+		return apperror.Conflict("окружение %q заморожено до %s: %s", environment, freeze.To.Format(time.RFC3339), freeze.Reason)
+	}
+	return nil
+}
+
+// AllowedRefs возвращает шаблоны веток и тегов, из которых можно деплоить в окружение:
+// allowed_refs окружения, а если они не заданы - allowed_refs проекта (пусто - из любых)
+func (s *GitLabService) AllowedRefs(environment string) []string {
+	if refs := s.EnvironmentSettings(environment).AllowedRefs; environment != "" && len(refs) > 0 {
+		return refs
+	}
+	return s.project.AllowedRefs
+}
+
+// CheckRef проверяет, что сборку из ветки или тега ref можно деплоить в окружение
+func (s *GitLabService) CheckRef(environment, ref string) error {
+	allowed := s.AllowedRefs(environment)
+	if len(allowed) == 0 || config.MatchRef(allowed, ref) {
+		return nil
+	}
+	if environment == "" {
+		return apperror.Validation("сборка из %q, а деплоить можно только из %s", ref, strings.Join(allowed, ", "))
+	}
+	return apperror.Validation("сборка из %q, а в окружение %q можно деплоить только из %s", ref, environment, strings.Join(allowed, ", "))
+}
+
+// hasRefPolicy проверяет, ограничены ли в проекте ветки и теги для деплоя
+func (s *GitLabService) hasRefPolicy() bool {
+	return len(s.project.AllowedRefs) > 0 || s.hasEnvironmentRefs()
+}
+
+// hasDeployRestrictions проверяет, есть ли в проекте заблокированные окружения или окна заморозки
+func (s *GitLabService) hasDeployRestrictions() bool {
+	for _, env := range s.project.Environments {
+		if env.Locked || len(env.FreezeWindows) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// hasEnvironmentRefs проверяет, ограничены ли ветки и теги для деплоя в отдельные окружения
+func (s *GitLabService) hasEnvironmentRefs() bool {
+	for _, env := range s.project.Environments {
+		if len(env.AllowedRefs) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// hasEnvironmentPolicy проверяет, есть ли у окружений проекта собственные ограничения:
+// блокировки, окна заморозки, ветки или проверки качества. Их нельзя проверить у джобы без окружения.
+func (s *GitLabService) hasEnvironmentPolicy() bool {
+	return s.hasDeployRestrictions() || s.hasEnvironmentRefs() || s.hasQualityGates()
+}
+
+// checkEnvironmentResolved проверяет, что окружение джобы определено, если у окружений проекта
+// есть собственные ограничения: иначе их нельзя проверить и джоба не запускается
+func (s *GitLabService) checkEnvironmentResolved(environment string, job *adapter.TriggeredJob) error {
+	if environment == "" && s.hasEnvironmentPolicy() {
+		return apperror.Validation("не удалось определить окружение джобы %d (%s), а для окружений проекта заданы ограничения", job.ID, job.Name)
+	}
+	return nil
+}
+
+// jobEnvironment определяет по имени джобы окружение, в которое она деплоит, если оно нужно
+// для блокировок, ограничений веток или проверок качества. Без них GitLab не запрашивается и возвращается "".
+func (s *GitLabService) jobEnvironment(ctx context.Context, job *adapter.TriggeredJob) (string, error) {
+	if !s.needsEnvironment() {
+		return "", nil
+	}
+	return s.EnvironmentForJob(ctx, job.Name)
+}
+
+// needsEnvironment проверяет, нужно ли окружение джобы для проверок перед запуском
+func (s *GitLabService) needsEnvironment() bool {
+	return s.hasDeployRestrictions() || s.hasRefPolicy() || s.hasQualityGates()
+}
+
+// environmentLabel возвращает окружение джобы для метки метрик. Если проверкам окружение не нужно
+// (см. jobEnvironment), оно определяется по имени джобы, но ошибка не мешает запуску. Не удалось
+// определить - metrics.UnknownEnvironment: имя джобы в метку не попадает, у него слишком много значений.
+func (s *GitLabService) environmentLabel(ctx context.Context, environment string, job *adapter.TriggeredJob) string {
+	if environment == "" && !s.needsEnvironment() {
+		environment, _ = s.EnvironmentForJob(ctx, job.Name)
+	}
+	if environment == "" {
+		return metrics.UnknownEnvironment
+	}
+	return environment
+}
+
+// checkJobRef проверяет ветку или тег пайплайна джобы по ограничениям окружения environment
+// Если ветки ограничены по окружениям, а окружение джобы не определено или у джобы нет пайплайна,
+// проверить ветку нельзя - такая джоба не проходит проверку.
+func (s *GitLabService) checkJobRef(environment string, job *adapter.TriggeredJob) error {
+	if environment == "" && s.hasEnvironmentRefs() {
+		return apperror.Validation("не удалось определить окружение джобы %d (%s), а ветки для деплоя ограничены по окружениям", job.ID, job.Name)
+	}
+	if len(s.AllowedRefs(environment)) == 0 {
+		return nil
+	}
+	if job.Pipeline == nil {
+		return apperror.Conflict("у джобы %d (%s) нет пайплайна, ветку сборки проверить невозможно", job.ID, job.Name)
+	}
+	if err := s.CheckRef(environment, job.Pipeline.Ref); err != nil {
+		return apperror.Validation("джоба %d (%s): %s", job.ID, job.Name, apperror.From(err).Message)
+	}
+	return nil
+}
+
+// checkTrigger выполняет все проверки джобы перед запуском: статус и стадию (см. CheckJob),
+// блокировку и заморозку окружения джобы environment (см. CheckDeployAllowed), ветку сборки (см. CheckRef)
+// и проверки качества окружения (см. CheckQualityGates)
+func (s *GitLabService) checkTrigger(ctx context.Context, environment string, job *adapter.TriggeredJob) ([]GateResult, error) {
+	if err := s.CheckJob(job); err != nil {
+		return nil, err
+	}
+	if err := s.checkEnvironmentResolved(environment, job); err != nil {
+		return nil, err
+	}
+	if environment != "" {
+		if err := s.CheckDeployAllowed(environment, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.checkJobRef(environment, job); err != nil {
+		return nil, err
+	}
+	return s.CheckQualityGates(ctx, environment, job)
+}
+
+// DeployJobRef - окружение deploy-джобы и ветки, из которых в него можно деплоить
+type DeployJobRef struct {
+	Environment string   // Окружение джобы ("" - не удалось определить по имени)
+	AllowedRefs []string // Разрешённые ветки и теги (пусто - любые)
+	Allowed     bool     // Пайплайн джобы собран из разрешённой ветки или тега
+}
+
+// DescribeDeployJobRefs определяет для deploy-джоб окружения и разрешённые ветки,
+// чтобы клиент заранее видел джобы, которые запускать нельзя
+func (s *GitLabService) DescribeDeployJobRefs(ctx context.Context, jobs []adapter.JobInfo) ([]DeployJobRef, error) {
+	names, err := s.environmentNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]DeployJobRef, len(jobs))
+	for i, job := range jobs {
+		environment := s.matchEnvironment(names, job.Stand)
+		allowed := s.AllowedRefs(environment)
+		refs[i] = DeployJobRef{
+			Environment: environment,
+			AllowedRefs: allowed,
+			Allowed:     len(allowed) == 0 || config.MatchRef(allowed, job.Ref),
+		}
+	}
+	return refs, nil
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/tracing"
+)
+
+// buildVersionLookups - в скольких успешных deploy-джобах пайплайна искать BUILD_VERSION
+const buildVersionLookups = 3
+
+// DeployPreview - что будет запущено деплоем (пробный запуск, ?dry_run=true): джоба,
+// сборка и коммиты, которые поедут в окружение. Все проверки пройдены, джоба не запущена.
+type DeployPreview struct {
+	Environment  string                  // Окружение деплоя ("" - не удалось определить по имени джобы)
+	Job          *adapter.TriggeredJob   // Джоба, которая будет запущена
+	PipelineID   int                     // Пайплайн сборки
+	Ref          string                  // Ветка или тег сборки
+	SHA          string                  // Коммит сборки
+	BuildVersion string                  // Версия сборки ("" - не найдена в логах джоб пайплайна)
+	Current      *adapter.DeploymentInfo // Развёрнутая сейчас сборка (nil - деплоев ещё не было)
+	Commits      []adapter.CommitInfo    // Коммиты, которые поедут в окружение
+	JiraKeys     []string                // Jira-ключи этих коммитов
+	Gates        []GateResult            // Проверки качества окружения (все пройдены)
+}
+
+// PreviewJob проверяет запуск джобы jobID без запуска: окружение определяется по имени джобы,
+// для него проверяются блокировка и заморозка
+func (s *GitLabService) PreviewJob(ctx context.Context, jobID int) (preview *DeployPreview, err error) {
+	ctx, span := tracing.Start(ctx, "GitLabService.PreviewJob", attribute.Int("gitlab.job.id", jobID))
+	defer func() { tracing.End(span, err) }()
+
+	job, err := s.client.GetJob(ctx, strconv.Itoa(jobID))
+	if err != nil {
+		return nil, err
+	}
+
+	environment, err := s.EnvironmentForJob(ctx, job.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkEnvironmentResolved(environment, job); err != nil {
+		return nil, err
+	}
+	if environment != "" {
+		if err := s.CheckDeployAllowed(environment, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	return s.preview(ctx, environment, job)
+}
+
+// PreviewDeploy проверяет деплой в окружение на момент at без запуска (см. DeployToEnvironment)
+func (s *GitLabService) PreviewDeploy(ctx context.Context, environment string, pipelineID, jobID int, at time.Time) (preview *DeployPreview, err error) {
+	ctx, span := tracing.Start(ctx, "GitLabService.PreviewDeploy",
+		attribute.String("gitlab.environment", environment),
+		attribute.Int("gitlab.pipeline.id", pipelineID),
+		attribute.Int("gitlab.job.id", jobID))
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.CheckDeployAllowed(environment, at); err != nil {
+		return nil, err
+	}
+
+	jobID, err = s.resolveDeployJob(ctx, environment, pipelineID, jobID)
+	if err != nil {
+		return nil, err
+	}
+	job, err := s.client.GetJob(ctx, strconv.Itoa(jobID))
+	if err != nil {
+		return nil, err
+	}
+	return s.preview(ctx, environment, job)
+}
+
+// PreviewPromotion проверяет продвижение сборки from → to на момент at без запуска (см. Promote)
+func (s *GitLabService) PreviewPromotion(ctx context.Context, from, to string, at time.Time) (preview *DeployPreview, err error) {
+	ctx, span := tracing.Start(ctx, "GitLabService.PreviewPromotion",
+		attribute.String("gitlab.environment.from", from),
+		attribute.String("gitlab.environment.to", to))
+	defer func() { tracing.End(span, err) }()
+
+	deployment, deployJob, err := s.resolvePromotion(ctx, from, to, at)
+	if err != nil {
+		return nil, err
+	}
+	job, err := s.client.GetJob(ctx, strconv.Itoa(deployJob.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	preview, err = s.preview(ctx, to, job)
+	if err != nil {
+		return nil, err
+	}
+	if deployment.BuildVersion != "" {
+		preview.BuildVersion = deployment.BuildVersion
+	}
+	return preview, nil
+}
+
+// EnvironmentForJob определяет окружение, в которое деплоит джоба jobName (см. FindDeployJob).
+// Если подходит несколько окружений, выбирается самое длинное имя (production-eu, а не production);
+// "" - джоба не относится ни к одному окружению проекта.
+func (s *GitLabService) EnvironmentForJob(ctx context.Context, jobName string) (string, error) {
+	names, err := s.environmentNames(ctx)
+	if err != nil {
+		return "", err
+	}
+	return s.matchEnvironment(names, jobName), nil
+}
+
+// environmentNames возвращает окружения проекта в GitLab и описанные в настройках
+// (в окружение из настроек могли ещё ни разу не деплоить)
+func (s *GitLabService) environmentNames(ctx context.Context) ([]string, error) {
+	environments, err := s.GetEnvironments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(environments)+len(s.project.Environments))
+	for _, env := range environments {
+		names = append(names, env.Name)
+	}
+	for _, env := range s.project.Environments {
+		if !slices.Contains(names, env.Name) {
+			names = append(names, env.Name)
+		}
+	}
+	return names, nil
+}
+
+// matchEnvironment выбирает из names окружение джобы jobName (см. EnvironmentForJob)
+func (s *GitLabService) matchEnvironment(names []string, jobName string) string {
+	found := ""
+	for _, name := range names {
+		if deployJobMatches(s.EnvironmentSettings(name).DeployJob, jobName, name) && len(name) > len(found) {
+			found = name
+		}
+	}
+	return found
+}
+
+// preview проверяет, что джобу можно запустить, и собирает сведения о сборке и коммитах
+func (s *GitLabService) preview(ctx context.Context, environment string, job *adapter.TriggeredJob) (*DeployPreview, error) {
+	if err := s.checkPlayable(ctx, environment, job); err != nil {
+		return nil, err
+	}
+	gates, err := s.CheckQualityGates(ctx, environment, job)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &DeployPreview{Environment: environment, Job: job, Commits: []adapter.CommitInfo{}, JiraKeys: []string{}, Gates: gates}
+	if job.Pipeline != nil {
+		preview.PipelineID = job.Pipeline.ID
+		preview.Ref = job.Pipeline.Ref
+		preview.SHA = job.Pipeline.SHA
+		preview.BuildVersion = s.pipelineBuildVersion(ctx, job.Pipeline.ID)
+	}
+	if environment == "" {
+		return preview, nil
+	}
+
+	current, err := s.CurrentDeployment(ctx, environment)
+	switch {
+	case errors.Is(err, apperror.ErrNotFound), errors.Is(err, apperror.ErrConflict):
+		return preview, nil // В окружение ещё ничего не деплоилось
+	case err != nil:
+		return nil, err
+	}
+	preview.Current = current
+
+	if preview.SHA != "" && preview.SHA != current.SHA {
+		preview.Commits, err = s.commitsBetween(ctx, preview.Ref, current.SHA, preview.SHA)
+		if err != nil {
+			return nil, err
+		}
+		preview.JiraKeys = jiraKeys(preview.Commits)
+	}
+
+	log.Info().Msgf("🔍 Пробный запуск джобы %d в %s: сборка %s (%s), %d коммит(ов)", job.ID, environment, preview.BuildVersion, preview.SHA, len(preview.Commits))
+	return preview, nil
+}
+
+// checkPlayable проверяет джобу (см. CheckJob), ветку её сборки для окружения environment
+// и то, что токен сервиса может её запустить
+func (s *GitLabService) checkPlayable(ctx context.Context, environment string, job *adapter.TriggeredJob) error {
+	if err := s.CheckJob(job); err != nil {
+		return err
+	}
+	if job.Pipeline != nil {
+		if err := s.CheckRef(environment, job.Pipeline.Ref); err != nil {
+			return err
+		}
+	}
+
+	project, err := s.client.GetProject(ctx)
+	if err != nil {
+		return err
+	}
+	if project.AccessLevel() < adapter.AccessLevelDeveloper {
+		return apperror.Forbidden("у токена сервиса нет прав на запуск джоб проекта %s (нужна роль Developer)", project.PathWithNamespace)
+	}
+	return nil
+}
+
+// pipelineBuildVersion ищет BUILD_VERSION в логах успешных deploy-джоб пайплайна; "" - не найден
+func (s *GitLabService) pipelineBuildVersion(ctx context.Context, pipelineID int) string {
+	jobs, err := s.client.GetPipelineJobs(ctx, strconv.Itoa(pipelineID))
+	if err != nil {
+		log.Warn().Err(err).Msgf("⚠️ Не удалось получить джобы пайплайна %d для версии сборки", pipelineID)
+		return ""
+	}
+
+	lookups := 0
+	for _, job := range jobs {
+		if job.Status != "success" {
+			continue
+		}
+		if version, err := s.client.GetBuildVersion(ctx, strconv.Itoa(job.ID)); err == nil && version != "" {
+			return version
+		}
+		if lookups++; lookups == buildVersionLookups {
+			break
+		}
+	}
+	return ""
+}
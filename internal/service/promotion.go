@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/tracing"
+)
+
+// Статусы продвижения сборки
+const (
+	PromotionTriggered = "triggered" // Deploy-джоба целевого окружения запущена
+	PromotionFailed    = "failed"    // GitLab не запустил джобу
+)
+
+// Promotion - запись о продвижении сборки из одного окружения в следующее
+type Promotion struct {
+	ID           string
+	Project      string
+	FromEnv      string
+	ToEnv        string
+	Ref          string
+	SHA          string
+	BuildVersion string
+	PipelineID   int
+	JobID        int
+	JobName      string
+	JobURL       string
+	JobStatus    string       // Статус джобы по ответу GitLab на запуск
+	Gates        []GateResult // Проверки качества целевого окружения перед запуском
+	Status       string
+	Error        string
+	TriggeredBy  string
+	CreatedAt    time.Time
+}
+
+// CheckPromotionPath проверяет, что продвижение from → to не перескакивает через стадии
+// настроенного пути (projects[].promotion_path). Без пути допустимо любое продвижение.
+func (s *GitLabService) CheckPromotionPath(from, to string) error {
+	promotionPath := s.project.PromotionPath
+	if len(promotionPath) == 0 {
+		return nil
+	}
+
+	fromIndex, toIndex := slices.Index(promotionPath, from), slices.Index(promotionPath, to)
+	switch {
+	case fromIndex < 0:
+		return apperror.Validation("Окружения %q нет в пути продвижения %s", from, strings.Join(promotionPath, " → "))
+	case toIndex < 0:
+		return apperror.Validation("Окружения %q нет в пути продвижения %s", to, strings.Join(promotionPath, " → "))
+	case toIndex != fromIndex+1:
+		return apperror.Validation("Из %q можно продвинуть сборку только в %q (путь %s)",
+			from, nextStage(promotionPath, fromIndex), strings.Join(promotionPath, " → "))
+	}
+	return nil
+}
+
+// nextStage возвращает окружение, следующее за index в пути продвижения
+func nextStage(promotionPath []string, index int) string {
+	if index+1 < len(promotionPath) {
+		return promotionPath[index+1]
+	}
+	return "—" // Последняя стадия: продвигать дальше некуда
+}
+
+// FindDeployJob ищет среди deploy-джоб пайплайна джобу окружения environment:
+// по шаблону deploy_job из настроек окружения, иначе - по вхождению имени окружения в имя джобы
+func (s *GitLabService) FindDeployJob(jobs []adapter.JobInfo, environment string) (*adapter.JobInfo, error) {
+	pattern := s.EnvironmentSettings(environment).DeployJob
+
+	var found []adapter.JobInfo
+	for _, job := range jobs {
+		if deployJobMatches(pattern, job.Stand, environment) {
+			found = append(found, job)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, apperror.NotFound("в пайплайне нет deploy-джобы для окружения %q", environment)
+	case 1:
+		return &found[0], nil
+	default:
+		return nil, apperror.Conflict("для окружения %q подходит несколько deploy-джоб, задайте deploy_job в настройках окружения", environment)
+	}
+}
+
+// deployJobMatches проверяет, что джоба jobName деплоит в окружение environment:
+// по шаблону pattern (deploy_job), а без него - по имени окружения как отдельному слову
+// в имени джобы ("prod" подходит к "deploy-prod", но не к "deploy-preprod")
+func deployJobMatches(pattern, jobName, environment string) bool {
+	if pattern != "" {
+		matched, _ := path.Match(pattern, jobName)
+		return matched
+	}
+
+	words, envWords := nameWords(jobName), nameWords(environment)
+	if len(envWords) == 0 {
+		return false
+	}
+	for i := 0; i+len(envWords) <= len(words); i++ {
+		if slices.Equal(words[i:i+len(envWords)], envWords) {
+			return true
+		}
+	}
+	return false
+}
+
+// nameWords разбивает имя джобы или окружения на слова в нижнем регистре
+// по разделителям (-, _, пробел, /, : и т.п.)
+func nameWords(name string) []string {
+	return strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Promote продвигает сборку, развёрнутую в from, в окружение to: находит в её пайплайне
+// deploy-джобу окружения to и запускает её. Запись возвращается и при ошибке запуска.
+func (s *GitLabService) Promote(ctx context.Context, from, to string) (promotion *Promotion, err error) {
+	ctx, span := tracing.Start(ctx, "GitLabService.Promote",
+		attribute.String("gitlab.environment.from", from),
+		attribute.String("gitlab.environment.to", to))
+	defer func() { tracing.End(span, err) }()
+
+	deployment, job, err := s.resolvePromotion(ctx, from, to, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("gitlab.pipeline.id", deployment.PipelineID), attribute.Int("gitlab.job.id", job.ID))
+
+	promotion = &Promotion{
+		ID:           newID(),
+		Project:      s.project.Name,
+		FromEnv:      from,
+		ToEnv:        to,
+		Ref:          deployment.Ref,
+		SHA:          deployment.SHA,
+		BuildVersion: deployment.BuildVersion,
+		PipelineID:   deployment.PipelineID,
+		JobID:        job.ID,
+		JobName:      job.Stand,
+		JobURL:       job.WebURL,
+		CreatedAt:    time.Now(),
+	}
+
+	triggered, gates, err := s.TriggerDeployJob(ctx, strconv.Itoa(job.ID))
+	promotion.Gates = gates
+	if err != nil {
+		promotion.Status = PromotionFailed
+		promotion.Error = err.Error()
+		return promotion, err
+	}
+
+	promotion.Status = PromotionTriggered
+	promotion.JobStatus = triggered.Status
+	if triggered.WebURL != "" {
+		promotion.JobURL = triggered.WebURL
+	}
+
+	log.Info().Msgf("⏩ Сборка %s (%s) продвигается из %s в %s: jobID=%d", deployment.BuildVersion, deployment.SHA, from, to, job.ID)
+	return promotion, nil
+}
+
+// resolvePromotion проверяет продвижение from → to на момент at и находит сборку from
+// и deploy-джобу окружения to в её пайплайне
+func (s *GitLabService) resolvePromotion(ctx context.Context, from, to string, at time.Time) (*adapter.DeploymentInfo, *adapter.JobInfo, error) {
+	if from == to {
+		return nil, nil, apperror.Validation("Окружения from_env и to_env должны различаться")
+	}
+	if err := s.CheckPromotionPath(from, to); err != nil {
+		return nil, nil, err
+	}
+	if err := s.CheckDeployAllowed(to, at); err != nil {
+		return nil, nil, err
+	}
+
+	deployment, err := s.CurrentDeployment(ctx, from)
+	if err != nil {
+		return nil, nil, err
+	}
+	if deployment.DeployStatus != "success" {
+		return nil, nil, apperror.Conflict("деплой в %q не завершился успешно (статус %s), продвигать нечего", from, deployment.DeployStatus)
+	}
+	if err := s.CheckRef(to, deployment.Ref); err != nil {
+		return nil, nil, err
+	}
+
+	jobs, err := s.GetDeployJobs(ctx, strconv.Itoa(deployment.PipelineID))
+	if err != nil {
+		return nil, nil, err
+	}
+	job, err := s.FindDeployJob(jobs, to)
+	if err != nil {
+		return nil, nil, err
+	}
+	return deployment, job, nil
+}
+
+// PromotionHistoryLimit - сколько последних продвижений помнит сервис
+const PromotionHistoryLimit = 100
+
+// PromotionStore - последние записи о продвижениях (в памяти, новые первыми)
+type PromotionStore struct {
+	mu    sync.RWMutex
+	items []*Promotion
+	limit int
+}
+
+// NewPromotionStore создаёт хранилище, которое помнит не больше limit записей
+func NewPromotionStore(limit int) *PromotionStore {
+	return &PromotionStore{limit: limit}
+}
+
+// Add сохраняет запись
+func (s *PromotionStore) Add(promotion *Promotion) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items = append([]*Promotion{promotion}, s.items...)
+	if len(s.items) > s.limit {
+		s.items = s.items[:s.limit]
+	}
+}
+
+// Get возвращает запись по ID
+func (s *PromotionStore) Get(id string) (*Promotion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, promotion := range s.items {
+		if promotion.ID == id {
+			return promotion, nil
+		}
+	}
+	return nil, apperror.NotFound("продвижение %q не найдено", id)
+}
+
+// List возвращает записи проекта (пустое имя - всех проектов), новые первыми
+func (s *PromotionStore) List(project string) []*Promotion {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*Promotion, 0, len(s.items))
+	for _, promotion := range s.items {
+		if project == "" || promotion.Project == project {
+			result = append(result, promotion)
+		}
+	}
+	return result
+}
+
+// newID генерирует случайный идентификатор записи
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+)
+
+// Registry - сервисы GitLab по проектам из конфигурации
+type Registry struct {
+	mu             sync.RWMutex
+	services       map[string]*GitLabService
+	clients        map[string]*adapter.GitLabClient
+	defaultProject string
+}
+
+// NewRegistry создаёт клиентов и сервисы для всех проектов из конфигурации
+func NewRegistry(cfg *config.Config) *Registry {
+	r := &Registry{}
+	r.Reload(cfg)
+	return r
+}
+
+// Reload пересоздаёт клиентов и сервисы по новой конфигурации и атомарно подменяет их;
+// запросы, уже получившие сервис, завершаются со старым клиентом
+func (r *Registry) Reload(cfg *config.Config) {
+	services := make(map[string]*GitLabService)
+	clients := make(map[string]*adapter.GitLabClient)
+	for _, project := range cfg.ProjectList() {
+		client := adapter.NewProjectClient(cfg, project)
+		clients[project.Name] = client
+		services[project.Name] = NewProjectService(client, project, cfg.GitLabConcurrency)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.services = services
+	r.clients = clients
+	r.defaultProject = cfg.DefaultProject().Name
+}
+
+// NewStaticRegistry создаёт реестр из одного готового сервиса (проект по умолчанию)
+func NewStaticRegistry(service *GitLabService) *Registry {
+	return &Registry{
+		services:       map[string]*GitLabService{config.DefaultProjectName: service},
+		clients:        map[string]*adapter.GitLabClient{},
+		defaultProject: config.DefaultProjectName,
+	}
+}
+
+// Get возвращает сервис проекта; пустое имя - проект по умолчанию
+func (r *Registry) Get(project string) (*GitLabService, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if project == "" {
+		project = r.defaultProject
+	}
+
+	service, ok := r.services[project]
+	if !ok {
+		return nil, apperror.NotFound("проект %q не настроен", project)
+	}
+	return service, nil
+}
+
+// Projects возвращает имена настроенных проектов
+func (r *Registry) Projects() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.services))
+	for name := range r.services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Clients возвращает клиентов GitLab по проектам (для проверок готовности)
+func (r *Registry) Clients() map[string]*adapter.GitLabClient {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	clients := make(map[string]*adapter.GitLabClient, len(r.clients))
+	for name, client := range r.clients {
+		clients[name] = client
+	}
+	return clients
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/metrics"
+)
+
+// tokenExpiryCheckInterval - как часто проверять сроки действия токенов GitLab
+const tokenExpiryCheckInterval = time.Hour
+
+// CheckTokenExpiry проверяет сроки действия токенов всех проектов через
+// /personal_access_tokens/self и предупреждает о токенах, истекающих в течение warnBefore
+func (r *Registry) CheckTokenExpiry(ctx context.Context, warnBefore time.Duration) {
+	for project, client := range r.Clients() {
+		info, err := client.GetTokenInfo(ctx)
+		if err != nil {
+			// OAuth, CI job token и старые версии GitLab не отдают срок действия
+			if apperror.From(err).Kind != apperror.KindNotFound {
+				log.Warn().Err(err).Str("project", project).Msg("⚠️ Не удалось проверить срок действия токена GitLab")
+			}
+			continue
+		}
+
+		expiry, ok := info.Expiry()
+		if !ok {
+			metrics.TokenExpiresIn.DeleteLabelValues(project)
+			continue
+		}
+
+		left := time.Until(expiry)
+		metrics.TokenExpiresIn.WithLabelValues(project).Set(left.Seconds())
+		if left < warnBefore {
+			log.Warn().Str("project", project).Str("token", info.Name).Time("expires_at", expiry).
+				Msgf("⚠️ Токен GitLab истекает через %s, замените его заранее", left.Round(time.Hour))
+		}
+	}
+}
+
+// WatchTokenExpiry периодически проверяет сроки действия токенов, пока не отменён ctx.
+// warnBefore вызывается при каждой проверке, чтобы учитывать перезагруженную конфигурацию.
+func (r *Registry) WatchTokenExpiry(ctx context.Context, warnBefore func() time.Duration) {
+	ticker := time.NewTicker(tokenExpiryCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		checkCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+		r.CheckTokenExpiry(checkCtx, warnBefore())
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
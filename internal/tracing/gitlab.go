@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/vkr-mtuci/gitlab-service/internal/metrics"
+)
+
+// InstrumentClient подключает к resty-клиенту клиентские спаны и проброс traceparent в GitLab
+func InstrumentClient(client *resty.Client) {
+	client.OnBeforeRequest(func(_ *resty.Client, req *resty.Request) error {
+		// Спан завершается в OnAfterResponse/OnError - достаём его оттуда из контекста запроса
+		ctx, _ := Tracer().Start(req.Context(), "GitLab "+req.Method+" "+metrics.Endpoint(req.URL),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(requestAttributes(req)...),
+		)
+
+		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+		req.SetContext(ctx)
+		return nil
+	})
+
+	client.OnAfterResponse(func(_ *resty.Client, resp *resty.Response) error {
+		span := trace.SpanFromContext(resp.Request.Context())
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode()))
+		if resp.IsError() {
+			span.SetStatus(codes.Error, resp.Status())
+		}
+		span.End()
+		return nil
+	})
+
+	client.OnError(func(req *resty.Request, err error) {
+		End(trace.SpanFromContext(req.Context()), err)
+	})
+}
+
+// requestAttributes извлекает из запроса к GitLab проект и номер страницы
+func requestAttributes(req *resty.Request) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("http.request.method", req.Method),
+		attribute.String("url.full", req.URL),
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return attrs
+	}
+
+	segments := strings.Split(strings.Trim(u.EscapedPath(), "/"), "/")
+	for i := 0; i < len(segments)-1; i++ {
+		if segments[i] == "projects" {
+			project, _ := url.PathUnescape(segments[i+1])
+			attrs = append(attrs, attribute.String("gitlab.project", project))
+			break
+		}
+	}
+
+	if page, err := strconv.Atoi(u.Query().Get("page")); err == nil {
+		attrs = append(attrs, attribute.Int("gitlab.page", page))
+	}
+
+	return attrs
+}
@@ -0,0 +1,99 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Поддерживаемые экспортёры трейсов
+const (
+	ExporterNone   = "none"   // Трейсы не экспортируются (по умолчанию)
+	ExporterStdout = "stdout" // Вывод спанов в stdout для локальной разработки
+	ExporterOTLP   = "otlp"   // OTLP/HTTP, адрес задаётся через OTEL_EXPORTER_OTLP_ENDPOINT
+)
+
+// instrumentationName - имя трейсера сервиса
+const instrumentationName = "github.com/vkr-mtuci/gitlab-service"
+
+// defaultServiceName - имя сервиса в трейсах, если не задан OTEL_SERVICE_NAME
+const defaultServiceName = "gitlab-service"
+
+// Init настраивает глобальный TracerProvider и W3C-пропагацию (traceparent, baggage).
+// Возвращает функцию, которая досылает накопленные спаны при остановке сервиса.
+func Init(ctx context.Context, exporterName string) (func(context.Context) error, error) {
+	// Пропагация нужна всегда: даже без экспорта пробрасываем traceparent дальше в GitLab
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch exporterName {
+	case "", ExporterNone:
+		log.Info().Msg("🔭 Экспорт трейсов отключён")
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("неизвестный экспортёр трейсов: %s", exporterName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания экспортёра трейсов %s: %w", exporterName, err)
+	}
+
+	serviceName := os.Getenv("OTEL_SERVICE_NAME")
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", serviceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка описания ресурса трейсов: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	log.Info().Msgf("🔭 Трейсинг включён, экспортёр: %s", exporterName)
+	return provider.Shutdown, nil
+}
+
+// Tracer возвращает трейсер сервиса
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start открывает дочерний спан с указанными атрибутами
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End завершает спан, отмечая ошибку, если она есть
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/vkr-mtuci/gitlab-service/config"
 	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
 	"github.com/vkr-mtuci/gitlab-service/internal/service"
 	"github.com/vkr-mtuci/gitlab-service/test/mocks"
 )
@@ -159,6 +161,7 @@ func TestTriggerDeployJob_NotFound(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, job)
 	assert.Contains(t, err.Error(), "404 job not found")
+	assert.ErrorIs(t, err, apperror.ErrNotFound)
 }
 
 // ❌ Тест ошибки 500 (внутренняя ошибка сервера)
@@ -254,14 +257,16 @@ func TestMockGitLabServer_HandleRequest_NotFound(t *testing.T) {
 
 func TestParseGitLabError_JSONError(t *testing.T) {
 	resp := []byte(`{"message":"Unauthorized"}`)
-	err := adapter.ParseGitLabError(resp)
+	err := adapter.ParseGitLabError(http.StatusUnauthorized, resp)
 	assert.EqualError(t, err, "GitLab API Error: Unauthorized")
+	assert.ErrorIs(t, err, apperror.ErrForbidden)
 }
 
 func TestParseGitLabError_NonJSON(t *testing.T) {
 	resp := []byte(`This is not JSON`)
-	err := adapter.ParseGitLabError(resp)
-	assert.EqualError(t, err, "GitLab API: This is not JSON")
+	err := adapter.ParseGitLabError(http.StatusBadGateway, resp)
+	assert.EqualError(t, err, "GitLab API Error: This is not JSON")
+	assert.ErrorIs(t, err, apperror.ErrUnavailable)
 }
 
 func TestHandleRequest_CustomErrorResponse(t *testing.T) {
@@ -492,6 +497,32 @@ func TestGetCommitsBetweenSHAs_Success(t *testing.T) {
 	assert.Contains(t, commits[1].JiraKeys, "JIRA-456")
 }
 
+// ✅ Сравнение коммитов через /repository/compare: от новых к старым, с Jira-ключами
+func TestCompareCommits_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v4/projects/1/repository/compare", r.URL.Path)
+		assert.Equal(t, "sha-old", r.URL.Query().Get("from"))
+		assert.Equal(t, "sha-new", r.URL.Query().Get("to"))
+		w.Write([]byte(`{"commits": [{"id": "commit-2", "message": "JIRA-1 first"}, {"id": "commit-1", "message": "JIRA-2 second"}]}`))
+	}))
+	defer server.Close()
+
+	client := adapter.NewGitLabClient(&config.Config{
+		GitLabBaseURL:   server.URL,
+		GitLabAPIURL:    "/api/v4/projects/",
+		GitLabAPIToken:  "test-token",
+		GitLabProjectID: "1",
+		JiraProject:     "JIRA",
+	})
+
+	commits, err := client.CompareCommits(context.Background(), "sha-old", "sha-new")
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	assert.Equal(t, "commit-1", commits[0].ID)
+	assert.Equal(t, []string{"JIRA-2"}, commits[0].JiraKeys)
+	assert.Equal(t, "commit-2", commits[1].ID)
+}
+
 // ✅ Тест получения версии билда из логов
 func TestGetBuildVersion_Success(t *testing.T) {
 	mockServer := mocks.NewMockGitLabServer()
@@ -516,9 +547,25 @@ func TestGetBuildVersion_Success(t *testing.T) {
 
 // ✅ Тест обработки ошибок GitLab API
 func TestParseGitLabError(t *testing.T) {
-	err := adapter.ParseGitLabError([]byte(`{"message": "Some error occurred"}`))
+	err := adapter.ParseGitLabError(http.StatusConflict, []byte(`{"message": "Some error occurred"}`))
 	assert.Error(t, err)
 	assert.Equal(t, "GitLab API Error: Some error occurred", err.Error())
+
+	var appErr *apperror.Error
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, apperror.KindConflict, appErr.Kind)
+	assert.Equal(t, http.StatusConflict, appErr.UpstreamStatus)
+
+	var gitlabErr *adapter.GitLabError
+	require.ErrorAs(t, err, &gitlabErr)
+	assert.Equal(t, http.StatusConflict, gitlabErr.StatusCode)
+}
+
+// ✅ Тест разбора ошибок валидации GitLab с объектом в поле message
+func TestParseGitLabError_FieldErrors(t *testing.T) {
+	err := adapter.ParseGitLabError(http.StatusBadRequest, []byte(`{"message": {"ref": ["is invalid"]}}`))
+	assert.ErrorIs(t, err, apperror.ErrValidation)
+	assert.Contains(t, err.Error(), "is invalid")
 }
 
 // TestGetPreviousPipelineSHA_Success проверяет успешное получение SHA предыдущего пайплайна
@@ -566,3 +613,30 @@ func TestGetPreviousPipelineSHA_NotFound(t *testing.T) {
 	assert.Error(t, err)
 	assert.Empty(t, prevSHA)
 }
+
+// ✅ Тест кэширования BUILD_VERSION: повторный запрос не обращается к логам джобы
+func TestGetBuildVersion_Cached(t *testing.T) {
+	mockServer := mocks.NewMockGitLabServer()
+	defer mockServer.Close()
+
+	client := adapter.NewGitLabClient(&config.Config{
+		GitLabBaseURL:   mockServer.URL,
+		GitLabAPIURL:    "/api/v4/projects/",
+		GitLabAPIToken:  "test-token",
+		GitLabProjectID: "1",
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	buildVersion, err := client.GetBuildVersion(ctx, "201")
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.3", buildVersion)
+
+	// Лог джобы стал недоступен, но версия уже в кэше
+	mockServer.SetErrorResponse("/api/v4/projects/1/jobs/201/trace", 500, `{"message": "Internal Server Error"}`)
+
+	buildVersion, err = client.GetBuildVersion(ctx, "201")
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.3", buildVersion)
+}
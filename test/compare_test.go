@@ -0,0 +1,157 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/dto"
+	"github.com/vkr-mtuci/gitlab-service/internal/handler"
+	"github.com/vkr-mtuci/gitlab-service/internal/service"
+)
+
+// historyClient - мок-клиент с историей коммитов (main и release/1.2) и деплоями по окружениям
+type historyClient struct {
+	adapter.GitLabClientInterface
+	deployed map[string]string // Окружение -> развёрнутый SHA
+}
+
+// history - коммиты от новых к старым: main (c1..c5) и release/1.2 (r1, r2), ответвлённая от c3
+var history = []adapter.CommitInfo{
+	{ID: "r2", Message: "PAY-7 hotfix", JiraKeys: []string{"PAY-7"}},
+	{ID: "r1", Message: "PAY-6 release fix", JiraKeys: []string{"PAY-6"}},
+	{ID: "c5", Message: "PAY-5 limits", JiraKeys: []string{"PAY-5"}},
+	{ID: "c4", Message: "PAY-4 refunds", JiraKeys: []string{"PAY-4"}},
+	{ID: "c3", Message: "PAY-3 fix"},
+	{ID: "c2", Message: "PAY-2"},
+	{ID: "c1", Message: "init"},
+}
+
+// parents - родитель каждого коммита
+var parents = map[string]string{"r2": "r1", "r1": "c3", "c5": "c4", "c4": "c3", "c3": "c2", "c2": "c1"}
+
+// GetEnvironments возвращает staging (1) и production (2)
+func (c *historyClient) GetEnvironments(ctx context.Context) ([]adapter.Environment, error) {
+	return []adapter.Environment{{ID: 1, Name: "staging"}, {ID: 2, Name: "production"}}, nil
+}
+
+// GetEnvironmentDetails возвращает развёрнутый SHA окружения
+func (c *historyClient) GetEnvironmentDetails(ctx context.Context, environmentID string) (*adapter.DeploymentInfo, error) {
+	name := map[string]string{"1": "staging", "2": "production"}[environmentID]
+	sha := c.deployed[name]
+	ref := "main"
+	if strings.HasPrefix(sha, "r") {
+		ref = "release/1.2"
+	}
+	return &adapter.DeploymentInfo{EnvironmentName: name, Ref: ref, SHA: sha, BuildVersion: "build-" + sha}, nil
+}
+
+// CompareCommits повторяет семантику GitLab compare: коммиты toSHA, которых нет в истории fromSHA
+func (c *historyClient) CompareCommits(ctx context.Context, fromSHA, toSHA string) ([]adapter.CommitInfo, error) {
+	ancestors := func(sha string) map[string]bool {
+		seen := map[string]bool{}
+		for ; sha != ""; sha = parents[sha] {
+			seen[sha] = true
+		}
+		return seen
+	}
+	reachable, excluded := ancestors(toSHA), ancestors(fromSHA)
+
+	commits := []adapter.CommitInfo{}
+	for _, commit := range history {
+		if reachable[commit.ID] && !excluded[commit.ID] {
+			commits = append(commits, commit)
+		}
+	}
+	return commits, nil
+}
+
+// compareEnvironments вызывает /api/v1/environments/compare
+func compareEnvironments(t *testing.T, client *historyClient, query string) (int, dto.EnvironmentComparison) {
+	services := service.NewStaticRegistry(service.NewGitLabService(client))
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Get("/api/v1/environments/compare", handler.NewV1Handler(services, nil).CompareEnvironments)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/environments/compare?"+query, nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var body struct {
+		Data dto.EnvironmentComparison `json:"data"`
+	}
+	if resp.StatusCode == http.StatusOK {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	}
+	return resp.StatusCode, body.Data
+}
+
+// ✅ staging впереди production: коммиты и Jira-ключи, которые ещё не доехали
+func TestCompareEnvironments_Ahead(t *testing.T) {
+	status, cmp := compareEnvironments(t, &historyClient{deployed: map[string]string{"staging": "c5", "production": "c3"}}, "from=staging&to=production")
+	require.Equal(t, http.StatusOK, status)
+
+	assert.Equal(t, "c5", cmp.From.SHA)
+	assert.Equal(t, "build-c3", cmp.To.BuildVersion)
+	assert.Equal(t, 2, cmp.AheadBy)
+	require.Len(t, cmp.Commits, 2)
+	assert.Equal(t, "c5", cmp.Commits[0].ID)
+	assert.Equal(t, []string{"PAY-4", "PAY-5"}, cmp.JiraKeys)
+	assert.False(t, cmp.Behind)
+	assert.False(t, cmp.InSync)
+	assert.False(t, cmp.Diverged)
+}
+
+// ⚠️ staging отстаёт от production (например, в production выкатили более новую сборку)
+func TestCompareEnvironments_Behind(t *testing.T) {
+	status, cmp := compareEnvironments(t, &historyClient{deployed: map[string]string{"staging": "c2", "production": "c4"}}, "from=staging&to=production")
+	require.Equal(t, http.StatusOK, status)
+
+	assert.Zero(t, cmp.AheadBy)
+	assert.Empty(t, cmp.Commits)
+	assert.True(t, cmp.Behind)
+	assert.Equal(t, 2, cmp.BehindBy)
+}
+
+// ✅ Один и тот же коммит - окружения совпадают; ❌ неизвестное или одинаковое окружение
+func TestCompareEnvironments_InSyncAndErrors(t *testing.T) {
+	client := &historyClient{deployed: map[string]string{"staging": "c3", "production": "c3"}}
+
+	status, cmp := compareEnvironments(t, client, "from=staging&to=production")
+	require.Equal(t, http.StatusOK, status)
+	assert.True(t, cmp.InSync)
+	assert.Empty(t, cmp.Commits)
+	assert.NotNil(t, cmp.JiraKeys)
+
+	for query, want := range map[string]int{
+		"from=staging&to=qa":      http.StatusNotFound,
+		"from=staging&to=staging": http.StatusUnprocessableEntity,
+		"from=staging":            http.StatusBadRequest,
+	} {
+		status, _ := compareEnvironments(t, client, query)
+		assert.Equal(t, want, status, query)
+	}
+}
+
+// ⚠️ Окружения на разных ветках: считаем от общего предка, а не всю историю ветки from
+func TestCompareEnvironments_DifferentRefs(t *testing.T) {
+	status, cmp := compareEnvironments(t, &historyClient{deployed: map[string]string{"staging": "c5", "production": "r2"}}, "from=staging&to=production")
+	require.Equal(t, http.StatusOK, status)
+
+	assert.Equal(t, "main", cmp.From.Ref)
+	assert.Equal(t, "release/1.2", cmp.To.Ref)
+	assert.Equal(t, 2, cmp.AheadBy)
+	require.Len(t, cmp.Commits, 2)
+	assert.Equal(t, "c5", cmp.Commits[0].ID)
+	assert.Equal(t, "c4", cmp.Commits[1].ID)
+	assert.Equal(t, []string{"PAY-4", "PAY-5"}, cmp.JiraKeys)
+	assert.True(t, cmp.Behind)
+	assert.Equal(t, 2, cmp.BehindBy)
+	assert.True(t, cmp.Diverged)
+}
@@ -2,22 +2,26 @@ package test
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/vkr-mtuci/gitlab-service/config"
 )
 
 func TestLoadConfig(t *testing.T) {
 	// ✅ Устанавливаем переменные окружения
-	os.Setenv("SERVER_PORT", "8080")
-	os.Setenv("GITLAB_BASE_URL", "https://gitlab.example.com")
-	os.Setenv("GITLAB_API_URL", "/api/v4/projects/")
-	os.Setenv("GITLAB_API_TOKEN", "dummy-token")
-	os.Setenv("GITLAB_PROJECT_ID", "123")
-	os.Setenv("JIRA_PROJECT", "JIRA")
+	t.Setenv("SERVER_PORT", "8080")
+	t.Setenv("GITLAB_BASE_URL", "https://gitlab.example.com")
+	t.Setenv("GITLAB_API_URL", "/api/v4/projects/")
+	t.Setenv("GITLAB_API_TOKEN", "dummy-token")
+	t.Setenv("GITLAB_PROJECT_ID", "123")
+	t.Setenv("JIRA_PROJECT", "JIRA")
 
-	cfg := config.LoadConfig()
+	cfg, err := config.LoadConfig()
+	require.NoError(t, err)
 
 	// ✅ Проверяем корректность конфигурации
 	assert.Equal(t, "8080", cfg.ServerPort)
@@ -27,3 +31,312 @@ func TestLoadConfig(t *testing.T) {
 	assert.Equal(t, "123", cfg.GitLabProjectID)
 	assert.Equal(t, "JIRA", cfg.JiraProject)
 }
+
+func TestLoadConfig_ServerDefaults(t *testing.T) {
+	// ✅ Обязательные переменные заданы, параметры сервера - нет
+	t.Setenv("GITLAB_BASE_URL", "https://gitlab.example.com")
+	t.Setenv("GITLAB_API_URL", "/api/v4/projects/")
+	t.Setenv("GITLAB_API_TOKEN", "dummy-token")
+	t.Setenv("GITLAB_PROJECT_ID", "123")
+	t.Setenv("JIRA_PROJECT", "JIRA")
+	t.Setenv("SERVER_WRITE_TIMEOUT", "2m")
+	t.Setenv("GITLAB_DISABLE_PREFETCH", "true")
+	t.Setenv("API_IDEMPOTENCY_TTL", "1h")
+
+	cfg, err := config.LoadConfig()
+	require.NoError(t, err)
+
+	assert.Equal(t, config.DefaultReadTimeout, cfg.ReadTimeout)
+	assert.Equal(t, 2*time.Minute, cfg.WriteTimeout)
+	assert.Equal(t, config.DefaultShutdownTimeout, cfg.ShutdownTimeout)
+	assert.Equal(t, config.DefaultBodyLimit, cfg.BodyLimit)
+	assert.False(t, cfg.TLSEnabled())
+	assert.Equal(t, config.PaginationConfig{MaxPages: config.DefaultMaxPages, DisablePrefetch: true}, cfg.Pagination)
+	assert.Equal(t, time.Hour, cfg.API.IdempotencyTTL)
+}
+
+// ❌ Все проблемы конфигурации возвращаются одной ошибкой с путями к полям
+func TestLoadConfig_ReportsAllProblems(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("SERVER_READ_TIMEOUT", "soon")
+
+	_, err := config.Load("")
+	require.Error(t, err)
+
+	var validationErr *config.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+
+	fields := map[string]bool{}
+	for _, p := range validationErr.Problems {
+		fields[p.Field] = true
+	}
+	assert.True(t, fields["env.SERVER_READ_TIMEOUT"])
+	assert.True(t, fields["gitlab.base_url (GITLAB_BASE_URL)"])
+	assert.True(t, fields["gitlab.api_url (GITLAB_API_URL)"])
+	assert.True(t, fields["gitlab.project_id (GITLAB_PROJECT_ID)"])
+	assert.True(t, fields["gitlab.token (GITLAB_API_TOKEN)"])
+	assert.True(t, fields["integrations.jira.project (JIRA_PROJECT)"])
+}
+
+// ✅ YAML-файл с проектами; переменные окружения переопределяют значения из файла
+func TestLoadConfig_YAMLFile(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("GITLAB_API_TOKEN", "env-token")
+
+	path := writeConfigFile(t, "config.yaml", `
+server:
+  port: "9090"
+  write_timeout: 2m
+gitlab:
+  base_url: https://gitlab.example.com
+  api_url: /api/v4/projects/
+  token: file-token
+  deploy_stages: ["deploy", "deploy-*"]
+  pagination:
+    max_pages: 10
+integrations:
+  jira:
+    project: JIRA
+projects:
+  - name: backend
+    id: "101"
+    environments:
+      - name: production
+        freeze_windows:
+          - from: 2025-12-30T00:00:00Z
+            to: 2026-01-09T00:00:00Z
+            reason: Новогодние праздники
+  - name: frontend
+    id: "102"
+    token: frontend-token
+    jira_project: WEB
+    deploy_stages: ["release"]
+`)
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "9090", cfg.ServerPort)
+	assert.Equal(t, 2*time.Minute, cfg.WriteTimeout)
+	assert.Equal(t, "env-token", cfg.GitLabAPIToken)
+	assert.Equal(t, 10, cfg.Pagination.MaxPages)
+
+	projects := cfg.ProjectList()
+	require.Len(t, projects, 2)
+	assert.Equal(t, "env-token", projects[0].Token)
+	assert.Equal(t, "JIRA", projects[0].JiraProject)
+	assert.Equal(t, []string{"deploy", "deploy-*"}, projects[0].DeployStages)
+	assert.Equal(t, "frontend-token", projects[1].Token)
+	assert.Equal(t, "WEB", projects[1].JiraProject)
+	assert.Equal(t, []string{"release"}, projects[1].DeployStages)
+
+	env, ok := projects[0].Environment("production")
+	require.True(t, ok)
+	require.Len(t, env.FreezeWindows, 1)
+	assert.Equal(t, "Новогодние праздники", env.FreezeWindows[0].Reason)
+
+	assert.Equal(t, "backend", cfg.DefaultProject().Name)
+}
+
+// ✅ TOML-файл разбирается по той же схеме
+func TestLoadConfig_TOMLFile(t *testing.T) {
+	clearConfigEnv(t)
+
+	path := writeConfigFile(t, "config.toml", `
+[gitlab]
+base_url = "https://gitlab.example.com"
+api_url = "/api/v4/projects/"
+token = "file-token"
+project_id = "123"
+
+[timeouts]
+gitlab = "20s"
+
+[api]
+legacy_sunset = 2026-06-01T00:00:00Z
+
+[scheduler]
+interval = "1m"
+
+[integrations.jira]
+project = "JIRA"
+
+[[projects]]
+name = "backend"
+id = "123"
+
+[[projects.environments]]
+name = "production"
+
+[[projects.environments.freeze_windows]]
+from = 2025-12-30T00:00:00Z
+to = 2026-01-09T00:00:00Z
+reason = "Новогодние праздники"
+`)
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "123", cfg.GitLabProjectID)
+	assert.Equal(t, 20*time.Second, cfg.GitLabTimeout)
+	assert.Equal(t, time.Minute, cfg.Scheduler.Interval)
+	assert.Equal(t, time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC), cfg.API.LegacySunset.UTC())
+	assert.Equal(t, "backend", cfg.DefaultProject().Name)
+
+	// Даты TOML разбираются как даты, а не строки
+	env, ok := cfg.DefaultProject().Environment("production")
+	require.True(t, ok)
+	require.Len(t, env.FreezeWindows, 1)
+	assert.Equal(t, time.Date(2026, 1, 9, 0, 0, 0, 0, time.UTC), env.FreezeWindows[0].To.UTC())
+}
+
+// ❌ Неизвестные поля TOML - ошибка, как и в YAML
+func TestLoadConfig_TOMLUnknownField(t *testing.T) {
+	clearConfigEnv(t)
+
+	path := writeConfigFile(t, "config.toml", `
+[gitlab]
+base_url = "https://gitlab.example.com"
+api_url = "/api/v4/projects/"
+project_id = "123"
+max_page = 10
+`)
+
+	_, err := config.Load(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "max_page")
+}
+
+// ❌ Ошибки в описании проектов и неизвестные поля
+func TestLoadConfig_InvalidProjects(t *testing.T) {
+	clearConfigEnv(t)
+
+	path := writeConfigFile(t, "config.yaml", `
+gitlab:
+  base_url: gitlab.example.com
+  api_url: /api/v4/projects/
+projects:
+  - name: backend
+    id: "101"
+    deploy_stages: ["deploy["]
+    environments:
+      - name: production
+        freeze_windows:
+          - from: 2026-01-09T00:00:00Z
+            to: 2025-12-30T00:00:00Z
+      - name: staging
+        deploy_job: "deploy-[staging"
+        allowed_refs: ["release/[0-9"]
+        quality_gates: { min_test_pass_rate: 120, vulnerability_severity: severe }
+    promotion_path: [staging, production, staging]
+    allowed_refs: ["main", "/^release/(\\d+$/"]
+  - name: backend
+`)
+
+	_, err := config.Load(path)
+	var validationErr *config.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+
+	fields := map[string]bool{}
+	for _, p := range validationErr.Problems {
+		fields[p.Field] = true
+	}
+	assert.True(t, fields["gitlab.base_url"])
+	assert.True(t, fields["projects[0].token"])
+	assert.True(t, fields["projects[0].deploy_stages[0]"])
+	assert.True(t, fields["projects[0].environments[0].freeze_windows[0]"])
+	assert.True(t, fields["projects[0].environments[1].deploy_job"])
+	assert.True(t, fields["projects[0].environments[1].allowed_refs[0]"])
+	assert.True(t, fields["projects[0].environments[1].quality_gates.min_test_pass_rate"])
+	assert.True(t, fields["projects[0].environments[1].quality_gates.vulnerability_severity"])
+	assert.True(t, fields["projects[0].promotion_path[2]"])
+	assert.False(t, fields["projects[0].allowed_refs[0]"])
+	assert.True(t, fields["projects[0].allowed_refs[1]"])
+	assert.True(t, fields["projects[1].name"])
+	assert.True(t, fields["projects[1].id"])
+
+	path = writeConfigFile(t, "typo.yaml", "gitlab:\n  base_ulr: https://gitlab.example.com\n")
+	_, err = config.Load(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "base_ulr")
+}
+
+// clearConfigEnv сбрасывает переменные окружения, влияющие на конфигурацию
+func clearConfigEnv(t *testing.T) {
+	for _, name := range []string{"SERVER_PORT", "GITLAB_BASE_URL", "GITLAB_API_URL", "GITLAB_API_TOKEN", "GITLAB_PROJECT_ID", "JIRA_PROJECT"} {
+		t.Setenv(name, "")
+	}
+}
+
+// writeConfigFile записывает файл конфигурации во временный каталог
+func writeConfigFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+// ❌ Неполные учётные данные GitLab и недоступный файл с токеном
+func TestLoadConfig_InvalidCredentials(t *testing.T) {
+	clearConfigEnv(t)
+
+	path := writeConfigFile(t, "config.yaml", `
+gitlab:
+  base_url: https://gitlab.example.com
+  api_url: /api/v4/projects/
+  token_file: /nonexistent/token
+integrations:
+  jira:
+    project: JIRA
+projects:
+  - name: backend
+    id: "101"
+    credentials:
+      - type: oauth
+        client_id: app
+      - type: ldap
+`)
+
+	_, err := config.Load(path)
+	var validationErr *config.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+
+	fields := map[string]bool{}
+	for _, p := range validationErr.Problems {
+		fields[p.Field] = true
+	}
+	assert.True(t, fields["gitlab.token_file (GITLAB_API_TOKEN_FILE)"])
+	assert.True(t, fields["projects[0].credentials[0].client_secret"])
+	assert.True(t, fields["projects[0].credentials[0].refresh_token"])
+	assert.True(t, fields["projects[0].credentials[1].type"])
+}
+
+// ❌ Заголовок идентичности без доверенных прокси и sudo без сопоставления пользователей
+func TestLoadConfig_InvalidAuth(t *testing.T) {
+	clearConfigEnv(t)
+
+	path := writeConfigFile(t, "config.yaml", `
+gitlab:
+  base_url: https://gitlab.example.com
+  api_url: /api/v4/projects/
+  token: admin-token
+  project_id: "101"
+  impersonation:
+    mode: sudo
+integrations:
+  jira:
+    project: JIRA
+auth:
+  user_header: X-Forwarded-User
+  trusted_proxies: ["10.0.0.0/33"]
+`)
+
+	_, err := config.Load(path)
+	var validationErr *config.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+
+	fields := map[string]bool{}
+	for _, p := range validationErr.Problems {
+		fields[p.Field] = true
+	}
+	assert.True(t, fields["auth.trusted_proxies[0]"])
+	assert.True(t, fields["gitlab.impersonation.users"])
+}
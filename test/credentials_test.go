@@ -0,0 +1,180 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+)
+
+// newTokenCheckingServer эмулирует GitLab, принимающий только указанное значение заголовка авторизации
+func newTokenCheckingServer(t *testing.T, header, accepted string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(header) != accepted {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message": "401 Unauthorized"}`))
+			return
+		}
+		w.Write([]byte(`[{"id": 1, "name": "staging"}]`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// projectClient создаёт клиента проекта с указанной цепочкой учётных данных
+func projectClient(baseURL string, creds ...config.CredentialConfig) *adapter.GitLabClient {
+	cfg := &config.Config{
+		GitLabBaseURL: baseURL,
+		GitLabAPIURL:  "/api/v4/projects/",
+		Projects:      []config.ProjectConfig{{Name: "backend", ID: "1", Credentials: creds}},
+	}
+	return adapter.NewProjectClient(cfg, cfg.ProjectList()[0])
+}
+
+// ✅ Отозванный основной токен - запрос повторяется с резервным
+func TestCredentials_FailoverToAlternateToken(t *testing.T) {
+	server := newTokenCheckingServer(t, "PRIVATE-TOKEN", "good-token")
+
+	client := projectClient(server.URL,
+		config.CredentialConfig{Token: "revoked-token"},
+		config.CredentialConfig{Token: "good-token"},
+	)
+
+	environments, err := client.GetEnvironments(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, environments, 1)
+}
+
+// ✅ Токен из файла перечитывается после ответа 401, если секрет обновили
+func TestCredentials_TokenFileRotation(t *testing.T) {
+	server := newTokenCheckingServer(t, "PRIVATE-TOKEN", "rotated-token")
+
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("old-token\n"), 0o600))
+
+	client := projectClient(server.URL, config.CredentialConfig{TokenFile: path})
+
+	_, err := client.GetEnvironments(context.Background())
+	require.Error(t, err)
+
+	// Kubernetes обновил секрет
+	require.NoError(t, os.WriteFile(path, []byte("rotated-token\n"), 0o600))
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, future, future))
+
+	_, err = client.GetEnvironments(context.Background())
+	require.NoError(t, err)
+}
+
+// ✅ CI job token передаётся в заголовке JOB-TOKEN
+func TestCredentials_JobToken(t *testing.T) {
+	server := newTokenCheckingServer(t, "JOB-TOKEN", "ci-job-token")
+
+	client := projectClient(server.URL, config.CredentialConfig{Type: config.CredentialJob, Token: "ci-job-token"})
+
+	_, err := client.GetEnvironments(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, config.CredentialJob, client.CredentialKind())
+}
+
+// ✅ OAuth: access token получается по refresh token и обновляется после 401
+func TestCredentials_OAuthRefresh(t *testing.T) {
+	refreshes := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "refresh_token", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "app-id", r.PostForm.Get("client_id"))
+		refreshes++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "fresh-access", "refresh_token": "next-refresh", "expires_in": 7200}`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fresh-access" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`[]`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := projectClient(server.URL, config.CredentialConfig{
+		Type:         config.CredentialOAuth,
+		Token:        "expired-access",
+		ClientID:     "app-id",
+		ClientSecret: "app-secret",
+		RefreshToken: "first-refresh",
+	})
+
+	_, err := client.GetEnvironments(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, refreshes)
+
+	// Информация о personal access token для OAuth недоступна
+	_, err = client.GetTokenInfo(context.Background())
+	require.Error(t, err)
+}
+
+// ✅ OAuth: одновременные запросы ждут одно обновление, новый refresh token сохраняется в файл
+func TestCredentials_OAuthSharedRefreshPersistsToken(t *testing.T) {
+	var refreshes atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		refreshes.Add(1)
+		time.Sleep(50 * time.Millisecond) // Медленный GitLab
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "fresh-access", "refresh_token": "next-refresh", "expires_in": 7200}`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "refresh-token")
+	require.NoError(t, os.WriteFile(path, []byte("first-refresh\n"), 0o600))
+
+	client := projectClient(server.URL, config.CredentialConfig{
+		Type:             config.CredentialOAuth,
+		ClientID:         "app-id",
+		ClientSecret:     "app-secret",
+		RefreshTokenFile: path,
+	})
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.GetEnvironments(context.Background())
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, 1, refreshes.Load())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "next-refresh\n", string(data))
+}
+
+// ✅ Срок действия токена: GitLab отдаёт дату без времени
+func TestTokenInfo_Expiry(t *testing.T) {
+	expiry, ok := (&adapter.TokenInfo{ExpiresAt: "2026-03-01"}).Expiry()
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), expiry)
+
+	_, ok = (&adapter.TokenInfo{}).Expiry()
+	assert.False(t, ok)
+}
@@ -0,0 +1,316 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/dto"
+	"github.com/vkr-mtuci/gitlab-service/internal/handler"
+	"github.com/vkr-mtuci/gitlab-service/internal/operation"
+	"github.com/vkr-mtuci/gitlab-service/internal/service"
+)
+
+// gatesProject - production защищено всеми проверками качества, staging - без проверок
+var gatesProject = config.ProjectConfig{
+	Name: "payments",
+	Environments: []config.EnvironmentConfig{
+		{Name: "production", QualityGates: config.QualityGates{
+			PipelineSuccess:       true,
+			MinTestPassRate:       95,
+			VulnerabilitySeverity: "high",
+			ProtectedBranch:       true,
+		}},
+	},
+}
+
+// gatesClient - мок-клиент продвижений с джобами, тестами и уязвимостями пайплайна 100.
+// Запущенная джоба сразу завершается успешно.
+type gatesClient struct {
+	*promotionClient
+	mu         sync.Mutex
+	buildJobs  []adapter.JobInfo
+	tests      [2]int // Успешных и всего тестов
+	findings   []adapter.VulnerabilityFinding
+	severities []string      // Уровни уязвимостей из последнего запроса
+	branches   []string      // Ветки коммита сборки
+	requests   int           // Запросы к GitLab для проверок качества
+	barrier    chan struct{} // Если задан - проверки ждут, пока начнутся все четыре
+	started    int
+}
+
+func newGatesClient() *gatesClient {
+	return &gatesClient{
+		promotionClient: newPromotionClient(),
+		buildJobs: []adapter.JobInfo{
+			{ID: 1, Stand: "build", Stage: "build", Status: "success"},
+			{ID: 2, Stand: "unit", Stage: "test", Status: "success"},
+			{ID: 3, Stand: "lint", Stage: "test", Status: "failed", AllowFailure: true},
+		},
+		tests:    [2]int{198, 200},
+		branches: []string{"feature/pay", "main"},
+	}
+}
+
+// request учитывает запрос проверки качества; first - первый запрос проверки (ждёт остальные проверки)
+func (c *gatesClient) request(ctx context.Context, first bool) error {
+	c.mu.Lock()
+	c.requests++
+	if !first || c.barrier == nil {
+		c.mu.Unlock()
+		return nil
+	}
+	c.started++
+	if c.started == 4 {
+		close(c.barrier)
+	}
+	barrier := c.barrier
+	c.mu.Unlock()
+
+	select {
+	case <-barrier:
+		return nil
+	case <-time.After(time.Second):
+		return errors.New("проверки качества выполняются последовательно")
+	}
+}
+
+func (c *gatesClient) GetPipelineBuildJobs(ctx context.Context, pipelineID string) ([]adapter.JobInfo, error) {
+	if err := c.request(ctx, true); err != nil {
+		return nil, err
+	}
+	return c.buildJobs, nil
+}
+
+func (c *gatesClient) GetTestReportSummary(ctx context.Context, pipelineID string) (*adapter.TestReportSummary, error) {
+	if err := c.request(ctx, true); err != nil {
+		return nil, err
+	}
+	summary := &adapter.TestReportSummary{}
+	summary.Total.Success, summary.Total.Count = c.tests[0], c.tests[1]
+	summary.Total.Failed = c.tests[1] - c.tests[0]
+	return summary, nil
+}
+
+func (c *gatesClient) GetVulnerabilityFindings(ctx context.Context, pipelineID string, severities []string) ([]adapter.VulnerabilityFinding, error) {
+	if err := c.request(ctx, true); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.severities = severities
+	c.mu.Unlock()
+	return c.findings, nil
+}
+
+func (c *gatesClient) GetCommitBranches(ctx context.Context, sha string) ([]string, error) {
+	if err := c.request(ctx, false); err != nil {
+		return nil, err
+	}
+	return c.branches, nil
+}
+
+func (c *gatesClient) GetProtectedBranches(ctx context.Context) ([]adapter.ProtectedBranch, error) {
+	if err := c.request(ctx, true); err != nil {
+		return nil, err
+	}
+	return []adapter.ProtectedBranch{{ID: 1, Name: "main"}, {ID: 2, Name: "release/*"}}, nil
+}
+
+func (c *gatesClient) GetJob(ctx context.Context, jobID string) (*adapter.TriggeredJob, error) {
+	job, err := c.promotionClient.GetJob(ctx, jobID)
+	if err == nil && slices.Contains(c.played, job.ID) {
+		job.Status = "success"
+	}
+	return job, err
+}
+
+func (c *gatesClient) GetJobTrace(ctx context.Context, jobID string) (string, error) {
+	return "Job succeeded\n", nil
+}
+
+// ✅ Сборка, прошедшая все проверки, деплоится; результаты проверок возвращаются
+func TestQualityGates_Passed(t *testing.T) {
+	client := newGatesClient()
+	svc := service.NewProjectService(client, gatesProject, config.DefaultGitLabConcurrency)
+
+	_, gates, err := svc.TriggerDeployJob(context.Background(), "13")
+	require.NoError(t, err)
+	assert.Equal(t, []int{13}, client.played)
+	assert.Equal(t, []string{"high", "critical"}, client.severities)
+
+	require.Len(t, gates, 4)
+	for _, gate := range gates {
+		assert.True(t, gate.Passed, gate.Gate+": "+gate.Message)
+	}
+	assert.Equal(t, service.GateTestPassRate, gates[1].Gate)
+	assert.Contains(t, gates[1].Message, "99.0%")
+}
+
+// ❌ Непройденные проверки отклоняют деплой: 409 с результатами всех проверок в details
+func TestQualityGates_Failed(t *testing.T) {
+	client := newGatesClient()
+	client.buildJobs = append(client.buildJobs, adapter.JobInfo{ID: 4, Stand: "e2e", Stage: "test", Status: "failed"})
+	client.tests = [2]int{180, 200}
+	client.findings = []adapter.VulnerabilityFinding{{ID: 1, Name: "SQL injection", Severity: "critical"}}
+	client.branches = []string{"feature/pay"}
+	svc := service.NewProjectService(client, gatesProject, config.DefaultGitLabConcurrency)
+
+	_, gates, err := svc.TriggerDeployJob(context.Background(), "13")
+	require.ErrorIs(t, err, apperror.ErrConflict)
+	assert.Empty(t, client.played)
+
+	details, ok := apperror.From(err).Details.([]service.GateResult)
+	require.True(t, ok)
+	assert.Equal(t, gates, details)
+	require.Len(t, gates, 4)
+	for _, gate := range gates {
+		assert.False(t, gate.Passed, gate.Gate)
+	}
+	assert.Contains(t, gates[0].Message, "e2e (failed)")
+	assert.NotContains(t, gates[0].Message, "lint")
+	assert.Contains(t, gates[2].Message, "SQL injection")
+}
+
+// ✅ Окружения без проверок качества не запрашивают у GitLab тесты и уязвимости
+func TestQualityGates_UnprotectedEnvironment(t *testing.T) {
+	client := newGatesClient()
+	svc := service.NewProjectService(client, gatesProject, config.DefaultGitLabConcurrency)
+
+	_, gates, err := svc.TriggerDeployJob(context.Background(), "12")
+	require.NoError(t, err)
+	assert.Nil(t, gates)
+	assert.Zero(t, client.requests)
+	assert.Equal(t, []int{12}, client.played)
+}
+
+// ✅ Ответ на запуск джобы и ошибка запуска содержат результаты проверок качества
+func TestQualityGates_PlayJobResponse(t *testing.T) {
+	client := newGatesClient()
+	h := handler.NewV1Handler(service.NewStaticRegistry(service.NewProjectService(client, gatesProject, config.DefaultGitLabConcurrency)), nil)
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Post("/api/v1/jobs/:job_id/play", h.PlayJob)
+
+	play := func() (int, []dto.QualityGate) {
+		resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/api/v1/jobs/13/play", nil))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var body struct {
+			Data    dto.TriggeredJob  `json:"data"`
+			Details []dto.QualityGate `json:"details"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		if resp.StatusCode == http.StatusOK {
+			return resp.StatusCode, body.Data.Gates
+		}
+		return resp.StatusCode, body.Details
+	}
+
+	client.tests = [2]int{100, 200}
+	status, gates := play()
+	assert.Equal(t, http.StatusConflict, status)
+	require.Len(t, gates, 4)
+	assert.False(t, gates[1].Passed)
+
+	client.tests = [2]int{200, 200}
+	status, gates = play()
+	assert.Equal(t, http.StatusOK, status)
+	require.Len(t, gates, 4)
+	assert.True(t, gates[1].Passed)
+}
+
+// ✅ Проверки качества запрашивают GitLab параллельно, результаты - в порядке проверок
+func TestQualityGates_RunInParallel(t *testing.T) {
+	client := newGatesClient()
+	client.barrier = make(chan struct{})
+	svc := service.NewProjectService(client, gatesProject, config.DefaultGitLabConcurrency)
+
+	_, gates, err := svc.TriggerDeployJob(context.Background(), "13")
+	require.NoError(t, err)
+	require.Len(t, gates, 4)
+	assert.Equal(t, []string{service.GatePipelineSuccess, service.GateTestPassRate, service.GateVulnerabilities, service.GateProtectedBranch},
+		[]string{gates[0].Gate, gates[1].Gate, gates[2].Gate, gates[3].Gate})
+}
+
+// stuckGatesClient - мок-клиент, в котором задания сборки недоступны, а отчёт о тестах
+// отвечает только после отмены контекста
+type stuckGatesClient struct {
+	*gatesClient
+}
+
+func (c *stuckGatesClient) GetPipelineBuildJobs(ctx context.Context, pipelineID string) ([]adapter.JobInfo, error) {
+	return nil, apperror.Unavailable(errors.New("connection reset"), "ошибка запроса к GitLab")
+}
+
+func (c *stuckGatesClient) GetTestReportSummary(ctx context.Context, pipelineID string) (*adapter.TestReportSummary, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(5 * time.Second):
+		return nil, errors.New("проверка не отменена после ошибки другой проверки")
+	}
+}
+
+// ❌ Ошибка одной проверки отменяет остальные: ответ не ждёт зависшие запросы к GitLab
+func TestQualityGates_CancelOnError(t *testing.T) {
+	client := &stuckGatesClient{gatesClient: newGatesClient()}
+	svc := service.NewProjectService(client, gatesProject, config.DefaultGitLabConcurrency)
+
+	started := time.Now()
+	_, _, err := svc.TriggerDeployJob(context.Background(), "13")
+	require.ErrorIs(t, err, apperror.ErrUnavailable)
+	assert.Less(t, time.Since(started), time.Second)
+	assert.Empty(t, client.played)
+}
+
+// ✅ Результаты проверок качества возвращаются при запуске с ожиданием, операцией и устаревшим маршрутом
+func TestQualityGates_EveryPlayResponse(t *testing.T) {
+	// Каждый запрос - к новому мок-клиенту: запущенную джобу нельзя запустить повторно
+	play := func(path string, want int, body any) {
+		services := service.NewStaticRegistry(service.NewProjectService(newGatesClient(), gatesProject, config.DefaultGitLabConcurrency))
+		v1 := handler.NewV1Handler(services, operation.NewManager(context.Background(), time.Millisecond))
+
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+		app.Post("/jobs/:job_id/play", handler.NewGitLabHandler(services, nil).TriggerDeployJob)
+		app.Post("/api/v1/jobs/:job_id/play", v1.PlayJob)
+
+		resp, err := app.Test(httptest.NewRequest(http.MethodPost, path, nil), -1)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, want, resp.StatusCode, path)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(body))
+	}
+
+	var legacy struct {
+		ID    int               `json:"id"`
+		Gates []dto.QualityGate `json:"gates"`
+	}
+	play("/jobs/13/play", http.StatusOK, &legacy)
+	assert.Equal(t, 13, legacy.ID)
+	assert.Len(t, legacy.Gates, 4)
+
+	var waited struct {
+		Data dto.JobRun `json:"data"`
+	}
+	play("/api/v1/jobs/13/play?wait=true", http.StatusOK, &waited)
+	assert.Equal(t, "success", waited.Data.Job.Status)
+	assert.Len(t, waited.Data.Job.Gates, 4)
+
+	var async struct {
+		Data dto.Operation `json:"data"`
+	}
+	play("/api/v1/jobs/13/play?async=true", http.StatusAccepted, &async)
+	assert.Len(t, async.Data.Gates, 4)
+}
@@ -30,7 +30,7 @@ func TestService_TriggerDeployJob_Success(t *testing.T) {
 	}, nil)
 
 	// Вызываем сервис
-	job, err := service.TriggerDeployJob(ctx, "7")
+	job, _, err := service.TriggerDeployJob(ctx, "7")
 
 	// Проверяем результат
 	require.NoError(t, err)
@@ -46,7 +46,7 @@ func TestServiceGetEnvironments_Success(t *testing.T) {
 	mockClient := &mocks.MockGitLabClient{}
 	svc := service.NewGitLabService(mockClient)
 
-	environments, err := svc.GetEnvironments()
+	environments, err := svc.GetEnvironments(context.Background())
 
 	assert.NoError(t, err)
 	assert.Len(t, environments, 2)
@@ -58,7 +58,7 @@ func TestServiceGetEnvironmentDetails_Success(t *testing.T) {
 	mockClient := &mocks.MockGitLabClient{}
 	svc := service.NewGitLabService(mockClient)
 
-	environment, err := svc.GetEnvironmentDetails("1")
+	environment, err := svc.GetEnvironmentDetails(context.Background(), "1")
 
 	assert.NoError(t, err)
 	assert.NotNil(t, environment)
@@ -71,7 +71,7 @@ func TestGetCommitsInBuild_Success(t *testing.T) {
 	mockClient := &mocks.MockGitLabClient{}
 	svc := service.NewGitLabService(mockClient)
 
-	commits, err := svc.GetCommitsInBuild("develop", "sha-123")
+	commits, err := svc.GetCommitsInBuild(context.Background(), "develop", "sha-123")
 
 	assert.NoError(t, err)
 	assert.Len(t, commits, 2)
@@ -87,7 +87,7 @@ func TestGetCommitsInBuild_NoCommits(t *testing.T) {
 	mockClient := &mocks.MockGitLabClient{}
 	svc := service.NewGitLabService(mockClient)
 
-	commits, err := svc.GetCommitsInBuild("develop", "unknown-sha")
+	commits, err := svc.GetCommitsInBuild(context.Background(), "develop", "unknown-sha")
 
 	assert.Error(t, err)
 	assert.Nil(t, commits)
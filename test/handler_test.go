@@ -0,0 +1,139 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/dto"
+	"github.com/vkr-mtuci/gitlab-service/internal/handler"
+	"github.com/vkr-mtuci/gitlab-service/internal/service"
+	"github.com/vkr-mtuci/gitlab-service/test/mocks"
+)
+
+// newTestApp создаёт Fiber-приложение с обработчиками поверх мок-клиента
+func newTestApp() *fiber.App {
+	h := handler.NewGitLabHandler(service.NewStaticRegistry(service.NewGitLabService(&mocks.MockGitLabClient{})), nil)
+
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Get("/environments/:id", h.GetEnvironmentDetails)
+	app.Post("/jobs/:job_id/play", h.TriggerDeployJob)
+	return app
+}
+
+// ✅ Ошибка "не найдено" отдаётся как 404 с машиночитаемым кодом
+func TestHandler_EnvironmentNotFound(t *testing.T) {
+	app := newTestApp()
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/environments/42", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	var body handler.ErrorResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "not_found", body.Code)
+	assert.Equal(t, "Запрошенный ресурс не найден", body.Error)
+	assert.Equal(t, "environment not found", body.Message)
+}
+
+// ✅ Локализация сообщения по заголовку Accept-Language
+func TestHandler_ErrorLocalizedEN(t *testing.T) {
+	app := newTestApp()
+
+	req := httptest.NewRequest(http.MethodPost, "/jobs/999/play", nil)
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	var body handler.ErrorResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "not_found", body.Code)
+	assert.Equal(t, "The requested resource was not found", body.Error)
+}
+
+// ❌ Некорректный запрос отдаётся как 400 bad_request, как до перехода на типизированные ошибки
+func TestHandler_MalformedRequest(t *testing.T) {
+	app := newTestApp()
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/jobs/abc/play?dry_run=true", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var body handler.ErrorResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "bad_request", body.Code)
+	assert.Equal(t, "Некорректный запрос", body.Error)
+}
+
+// ✅ Неизвестный маршрут отдаётся в едином формате ошибок
+func TestHandler_UnknownRoute(t *testing.T) {
+	app := newTestApp()
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	var body handler.ErrorResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "not_found", body.Code)
+}
+
+// ✅ API v1: конверт с метаданными и время в RFC 3339
+func TestHandlerV1_EnvelopeAndTimestamps(t *testing.T) {
+	services := service.NewStaticRegistry(service.NewGitLabService(&mocks.MockGitLabClient{}))
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Get("/api/v1/pipelines/:pipeline_id/deploy-jobs", handler.NewV1Handler(services, nil).ListDeployJobs)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/pipelines/9679696/deploy-jobs", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Data []map[string]any `json:"data"`
+		Meta dto.Meta         `json:"meta"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+
+	assert.Equal(t, "v1", body.Meta.APIVersion)
+	require.NotNil(t, body.Meta.Pagination)
+	assert.Equal(t, len(body.Data), body.Meta.Pagination.Total)
+	require.NotEmpty(t, body.Data)
+	assert.Regexp(t, `^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z$`, body.Data[0]["finished_at"])
+}
+
+// ✅ Устаревший маршрут работает и указывает на преемника, после отключения - 404
+func TestHandler_DeprecatedRoute(t *testing.T) {
+	apiConfig := config.APIConfig{LegacySunset: time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)}
+	services := service.NewStaticRegistry(service.NewGitLabService(&mocks.MockGitLabClient{}))
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Get("/environments", handler.Deprecated(func() config.APIConfig { return apiConfig }), handler.NewGitLabHandler(services, nil).GetEnvironments)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/environments", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "true", resp.Header.Get("Deprecation"))
+	assert.Equal(t, "Mon, 01 Jun 2026 00:00:00 GMT", resp.Header.Get("Sunset"))
+	assert.Equal(t, `</api/v1/environments>; rel="successor-version"`, resp.Header.Get("Link"))
+
+	apiConfig.DisableLegacy = true
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/environments", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
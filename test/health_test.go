@@ -0,0 +1,96 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/handler"
+	"github.com/vkr-mtuci/gitlab-service/internal/health"
+	"github.com/vkr-mtuci/gitlab-service/test/mocks"
+)
+
+// ✅ Все проверки GitLab проходят: токен активен, доступ Maintainer, scope api
+func TestReadiness_GitLabOK(t *testing.T) {
+	mockServer := mocks.NewMockGitLabServer()
+	defer mockServer.Close()
+
+	mockServer.SetResponse("/api/v4/user", 200, `{"id": 5, "username": "deploy-bot", "state": "active"}`)
+	mockServer.SetResponse("/api/v4/projects/1", 200, `{"id": 1, "path_with_namespace": "group/project", "permissions": {"project_access": {"access_level": 40}}}`)
+	mockServer.SetResponse("/api/v4/personal_access_tokens/self", 200, `{"id": 3, "name": "deploy", "scopes": ["api"], "active": true}`)
+
+	client := adapter.NewGitLabClient(&config.Config{
+		GitLabBaseURL:   mockServer.URL,
+		GitLabAPIURL:    "/api/v4/projects/",
+		GitLabAPIToken:  "test-token",
+		GitLabProjectID: "1",
+	})
+
+	checks := health.NewRegistry(5*time.Second, 0)
+	health.RegisterGitLabChecks(checks, "", client)
+
+	report := checks.Run(context.Background())
+	assert.True(t, report.Healthy())
+	require.Len(t, report.Checks, 3)
+	for _, result := range report.Checks {
+		assert.Equal(t, health.StatusOK, result.Status, result.Name)
+	}
+}
+
+// ❌ Токен без scope api и с доступом Reporter - сервис не готов
+func TestReadiness_GitLabInsufficientPermissions(t *testing.T) {
+	mockServer := mocks.NewMockGitLabServer()
+	defer mockServer.Close()
+
+	mockServer.SetResponse("/api/v4/user", 200, `{"id": 5, "username": "deploy-bot", "state": "active"}`)
+	mockServer.SetResponse("/api/v4/projects/1", 200, `{"id": 1, "path_with_namespace": "group/project", "permissions": {"project_access": {"access_level": 20}}}`)
+	mockServer.SetResponse("/api/v4/personal_access_tokens/self", 200, `{"id": 3, "name": "deploy", "scopes": ["read_api"], "active": true}`)
+
+	client := adapter.NewGitLabClient(&config.Config{
+		GitLabBaseURL:   mockServer.URL,
+		GitLabAPIURL:    "/api/v4/projects/",
+		GitLabAPIToken:  "test-token",
+		GitLabProjectID: "1",
+	})
+
+	checks := health.NewRegistry(5*time.Second, 0)
+	health.RegisterGitLabChecks(checks, "", client)
+
+	report := checks.Run(context.Background())
+	assert.False(t, report.Healthy())
+
+	statuses := map[string]string{}
+	for _, result := range report.Checks {
+		statuses[result.Name] = result.Status
+	}
+	assert.Equal(t, health.StatusOK, statuses["gitlab_token"])
+	assert.Equal(t, health.StatusFail, statuses["gitlab_project"])
+	assert.Equal(t, health.StatusFail, statuses["gitlab_token_scopes"])
+}
+
+// ❌ /readyz отвечает 503, если хотя бы одна проверка не прошла
+func TestReadinessHandler_ServiceUnavailable(t *testing.T) {
+	checks := health.NewRegistry(time.Second, 0)
+	checks.Register("storage", func(ctx context.Context) error { return errors.New("storage is down") })
+
+	h := handler.NewHealthHandler(checks)
+	app := fiber.New()
+	app.Get("/healthz", h.Liveness)
+	app.Get("/readyz", h.Readiness)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
@@ -0,0 +1,196 @@
+package test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/auth"
+	"github.com/vkr-mtuci/gitlab-service/internal/handler"
+	"github.com/vkr-mtuci/gitlab-service/internal/idempotency"
+)
+
+// idempotencyApp собирает приложение, в котором POST /play считает вызовы и отвечает статусом status
+func idempotencyApp(ttl time.Duration, status int, calls *atomic.Int32, release <-chan struct{}) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	authConfig := func() config.AuthConfig {
+		return config.AuthConfig{Tokens: []config.APIToken{{Name: "ci", Token: "ci-token"}, {Name: "ui", Token: "ui-token"}}}
+	}
+	app.Use(auth.Middleware(authConfig, handler.ErrorHandler))
+	app.Use(idempotency.Middleware(func() config.APIConfig { return config.APIConfig{IdempotencyTTL: ttl} }, authConfig, handler.ErrorHandler))
+	app.Post("/play", func(c *fiber.Ctx) error {
+		n := calls.Add(1)
+		if release != nil {
+			<-release
+		}
+		c.Location("/jobs/7")
+		return c.Status(status).JSON(fiber.Map{"call": n, "body": string(c.Body())})
+	})
+	return app
+}
+
+// postWithKey отправляет POST /play с ключом идемпотентности от имени клиента token
+func postWithKey(t *testing.T, app *fiber.App, token, key, body string) (*http.Response, string) {
+	req := httptest.NewRequest(http.MethodPost, "/play", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	if key != "" {
+		req.Header.Set(idempotency.HeaderKey, key)
+	}
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(data)
+}
+
+// ✅ Повтор с тем же ключом получает сохранённый ответ, обработчик не вызывается снова
+func TestIdempotency_ReplaysStoredResponse(t *testing.T) {
+	var calls atomic.Int32
+	app := idempotencyApp(time.Hour, http.StatusCreated, &calls, nil)
+
+	first, firstBody := postWithKey(t, app, "ci-token", "deploy-42", `{"env":"production"}`)
+	second, secondBody := postWithKey(t, app, "ci-token", "deploy-42", `{"env":"production"}`)
+
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, http.StatusCreated, second.StatusCode)
+	assert.Equal(t, firstBody, secondBody)
+	assert.Equal(t, "/jobs/7", second.Header.Get(fiber.HeaderLocation))
+	assert.Equal(t, fiber.MIMEApplicationJSON, second.Header.Get(fiber.HeaderContentType))
+	assert.Empty(t, first.Header.Get(idempotency.HeaderReplayed))
+	assert.Equal(t, "true", second.Header.Get(idempotency.HeaderReplayed))
+}
+
+// ❌ Тот же ключ с другим телом запроса отклоняется с 422
+func TestIdempotency_RejectsConflictingPayload(t *testing.T) {
+	var calls atomic.Int32
+	app := idempotencyApp(time.Hour, http.StatusOK, &calls, nil)
+
+	postWithKey(t, app, "ci-token", "deploy-42", `{"env":"staging"}`)
+	resp, body := postWithKey(t, app, "ci-token", "deploy-42", `{"env":"production"}`)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Contains(t, body, "validation_failed")
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+// ❌ Пока запрос с ключом выполняется, повтор получает 409
+func TestIdempotency_ConcurrentRequestConflicts(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	app := idempotencyApp(time.Hour, http.StatusOK, &calls, release)
+
+	done := make(chan int)
+	go func() {
+		resp, _ := postWithKey(t, app, "ci-token", "deploy-42", `{}`)
+		done <- resp.StatusCode
+	}()
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+
+	resp, _ := postWithKey(t, app, "ci-token", "deploy-42", `{}`)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	close(release)
+	assert.Equal(t, http.StatusOK, <-done)
+}
+
+// ✅ Ключи разных клиентов, запросы без ключа и ответы с истёкшим сроком не повторяются
+func TestIdempotency_ExecutesWhenNotReplayable(t *testing.T) {
+	var calls atomic.Int32
+	app := idempotencyApp(time.Hour, http.StatusOK, &calls, nil)
+
+	postWithKey(t, app, "ci-token", "deploy-42", `{}`)
+	postWithKey(t, app, "ui-token", "deploy-42", `{}`)
+	postWithKey(t, app, "ci-token", "", `{}`)
+	postWithKey(t, app, "ci-token", "", `{}`)
+	assert.Equal(t, int32(4), calls.Load())
+
+	calls.Store(0)
+	expiring := idempotencyApp(time.Millisecond, http.StatusOK, &calls, nil)
+	postWithKey(t, expiring, "ci-token", "deploy-42", `{}`)
+	time.Sleep(5 * time.Millisecond)
+	resp, _ := postWithKey(t, expiring, "ci-token", "deploy-42", `{}`)
+	assert.Empty(t, resp.Header.Get(idempotency.HeaderReplayed))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+// ✅ Ошибки, кроме 422, не сохраняются: повтор с тем же ключом выполняет запрос снова
+// (например, 409 из-за блокировки окружения, которую уже сняли)
+func TestIdempotency_DoesNotStoreErrors(t *testing.T) {
+	for _, status := range []int{http.StatusBadGateway, http.StatusConflict, http.StatusForbidden, http.StatusNotFound} {
+		var calls atomic.Int32
+		app := idempotencyApp(time.Hour, status, &calls, nil)
+
+		postWithKey(t, app, "ci-token", "deploy-42", `{}`)
+		resp, _ := postWithKey(t, app, "ci-token", "deploy-42", `{}`)
+
+		assert.Equal(t, status, resp.StatusCode)
+		assert.Equal(t, int32(2), calls.Load(), status)
+	}
+
+	var calls atomic.Int32
+	app := idempotencyApp(time.Hour, http.StatusUnprocessableEntity, &calls, nil)
+	postWithKey(t, app, "ci-token", "deploy-42", `{}`)
+	resp, _ := postWithKey(t, app, "ci-token", "deploy-42", `{}`)
+	assert.Equal(t, "true", resp.Header.Get(idempotency.HeaderReplayed))
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+// ✅ Без настроенной аутентификации (по умолчанию) анонимные клиенты делят общую область ключей
+func TestIdempotency_AnonymousWithoutAuth(t *testing.T) {
+	var calls atomic.Int32
+	noAuth := func() config.AuthConfig { return config.AuthConfig{} }
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Use(auth.Middleware(noAuth, handler.ErrorHandler))
+	app.Use(idempotency.Middleware(func() config.APIConfig { return config.APIConfig{IdempotencyTTL: time.Hour} }, noAuth, handler.ErrorHandler))
+	app.Post("/play", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"call": calls.Add(1)})
+	})
+
+	resp, first := postWithKey(t, app, "", "deploy-42", `{}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, second := postWithKey(t, app, "", "deploy-42", `{}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "true", resp.Header.Get(idempotency.HeaderReplayed))
+	assert.Equal(t, first, second)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+// ❌ При включённой аутентификации ключ анонимного клиента не принимается
+func TestIdempotency_RequiresIdentityWhenAuthEnabled(t *testing.T) {
+	var calls atomic.Int32
+	withAuth := func() config.AuthConfig {
+		return config.AuthConfig{Tokens: []config.APIToken{{Name: "ci", Token: "ci-token"}}}
+	}
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Use(idempotency.Middleware(func() config.APIConfig { return config.APIConfig{IdempotencyTTL: time.Hour} }, withAuth, handler.ErrorHandler))
+	app.Post("/play", func(c *fiber.Ctx) error {
+		calls.Add(1)
+		return c.SendStatus(http.StatusOK)
+	})
+
+	resp, _ := postWithKey(t, app, "", "deploy-42", `{}`)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp, _ = postWithKey(t, app, "", "", `{}`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+// ❌ Слишком длинный ключ отклоняется
+func TestIdempotency_RejectsLongKey(t *testing.T) {
+	var calls atomic.Int32
+	app := idempotencyApp(time.Hour, http.StatusOK, &calls, nil)
+
+	resp, _ := postWithKey(t, app, "ci-token", strings.Repeat("k", idempotency.MaxKeyLength+1), `{}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Zero(t, calls.Load())
+}
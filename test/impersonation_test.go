@@ -0,0 +1,141 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/auth"
+	"github.com/vkr-mtuci/gitlab-service/internal/handler"
+	"github.com/vkr-mtuci/gitlab-service/internal/service"
+)
+
+// playRecorder эмулирует GitLab и запоминает заголовки запроса на запуск джобы
+type playRecorder struct {
+	mu     sync.Mutex
+	header http.Header
+}
+
+func (p *playRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		w.Write([]byte(`{"id": 7, "name": "deploy-production", "stage": "deploy", "status": "manual"}`))
+		return
+	}
+
+	p.mu.Lock()
+	p.header = r.Header.Clone()
+	p.mu.Unlock()
+	w.Write([]byte(`{"id": 7, "name": "deploy-production", "status": "pending", "user": {"id": 42, "username": "ivanov"}}`))
+}
+
+// testClientIP - адрес, с которого app.Test отправляет запросы (в тестах - доверенный прокси)
+const testClientIP = "0.0.0.0"
+
+// newImpersonationApp собирает приложение с аутентификацией и запуском джоб от имени пользователя
+func newImpersonationApp(t *testing.T, impersonation config.ImpersonationConfig) (*fiber.App, *playRecorder) {
+	recorder := &playRecorder{}
+	server := httptest.NewServer(recorder)
+	t.Cleanup(server.Close)
+
+	cfg := &config.Config{
+		GitLabBaseURL:   server.URL,
+		GitLabAPIURL:    "/api/v4/projects/",
+		GitLabAPIToken:  "admin-token",
+		GitLabProjectID: "1",
+		Auth:            config.AuthConfig{UserHeader: "X-Forwarded-User", TrustedProxies: []string{testClientIP}},
+		Impersonation:   impersonation,
+	}
+
+	h := handler.NewGitLabHandler(service.NewRegistry(cfg), nil)
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Use(auth.Middleware(func() config.AuthConfig { return cfg.Auth }, handler.ErrorHandler))
+	app.Post("/jobs/:job_id/play",
+		auth.Impersonate(func() config.ImpersonationConfig { return cfg.Impersonation }, handler.ErrorHandler),
+		h.TriggerDeployJob)
+
+	return app, recorder
+}
+
+// ✅ Sudo: токен администратора и имя пользователя GitLab из сопоставления
+func TestImpersonation_Sudo(t *testing.T) {
+	app, recorder := newImpersonationApp(t, config.ImpersonationConfig{
+		Mode:  config.ImpersonationSudo,
+		Users: map[string]string{"ivan@example.com": "ivanov"},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/jobs/7/play", nil)
+	req.Header.Set("X-Forwarded-User", "ivan@example.com")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Equal(t, "admin-token", recorder.header.Get("PRIVATE-TOKEN"))
+	assert.Equal(t, "ivanov", recorder.header.Get("Sudo"))
+}
+
+// ❌ Sudo: клиент без сопоставления в users не запускает джобы от имени одноимённого пользователя GitLab
+func TestImpersonation_SudoUnmappedIdentity(t *testing.T) {
+	app, recorder := newImpersonationApp(t, config.ImpersonationConfig{
+		Mode:  config.ImpersonationSudo,
+		Users: map[string]string{"ivan@example.com": "ivanov"},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/jobs/7/play", nil)
+	req.Header.Set("X-Forwarded-User", "root")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Nil(t, recorder.header)
+}
+
+// ❌ Заголовок идентичности от клиента не из trusted_proxies отклоняется
+func TestAuth_UserHeaderFromUntrustedAddress(t *testing.T) {
+	authConfig := config.AuthConfig{UserHeader: "X-Forwarded-User", TrustedProxies: []string{"10.0.0.0/8"}}
+
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Use(auth.Middleware(func() config.AuthConfig { return authConfig }, handler.ErrorHandler))
+	app.Get("/whoami", func(c *fiber.Ctx) error { return c.SendString(auth.Identity(c)) })
+
+	req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+	req.Header.Set("X-Forwarded-User", "root")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	authConfig.TrustedProxies = append(authConfig.TrustedProxies, testClientIP)
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+// ✅ OAuth: запрос к GitLab идёт с токеном пользователя вместо токена сервиса
+func TestImpersonation_OAuthToken(t *testing.T) {
+	app, recorder := newImpersonationApp(t, config.ImpersonationConfig{Mode: config.ImpersonationOAuth})
+
+	req := httptest.NewRequest(http.MethodPost, "/jobs/7/play", nil)
+	req.Header.Set("X-Forwarded-User", "ivan@example.com")
+	req.Header.Set("X-GitLab-Token", "user-oauth-token")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Equal(t, "Bearer user-oauth-token", recorder.header.Get("Authorization"))
+	assert.Empty(t, recorder.header.Get("PRIVATE-TOKEN"))
+}
+
+// ❌ Токен пользователя обязателен, но не передан - 401 без обращения к GitLab
+func TestImpersonation_RequiredTokenMissing(t *testing.T) {
+	app, recorder := newImpersonationApp(t, config.ImpersonationConfig{Mode: config.ImpersonationOAuth, Required: true})
+
+	req := httptest.NewRequest(http.MethodPost, "/jobs/7/play", nil)
+	req.Header.Set("X-Forwarded-User", "ivan@example.com")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Nil(t, recorder.header)
+}
@@ -0,0 +1,195 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/dto"
+	"github.com/vkr-mtuci/gitlab-service/internal/handler"
+	"github.com/vkr-mtuci/gitlab-service/internal/listing"
+	"github.com/vkr-mtuci/gitlab-service/internal/service"
+)
+
+// reviewEnvironments - окружения для проверки фильтров
+var reviewEnvironments = []adapter.Environment{
+	{ID: 1, Name: "staging", State: "available"},
+	{ID: 2, Name: "production", State: "available"},
+	{ID: 3, Name: "review/feature-a", State: "available"},
+	{ID: 4, Name: "review/feature-b-api", State: "stopped"},
+	{ID: 5, Name: "review/feature-c-api", State: "available"},
+}
+
+// envSpec - спецификация списка окружений для тестов пакета listing
+var envSpec = listing.Spec[adapter.Environment]{
+	Name:   func(e adapter.Environment) string { return e.Name },
+	State:  func(e adapter.Environment) string { return e.State },
+	States: []string{"available", "stopping", "stopped"},
+	Search: func(e adapter.Environment) []string { return []string{e.Name} },
+	Sort: map[string]func(a, b adapter.Environment) int{
+		"id":   listing.Compare(func(e adapter.Environment) int { return e.ID }),
+		"name": listing.Compare(func(e adapter.Environment) string { return e.Name }),
+	},
+}
+
+// queryOf разбирает параметры из map
+func queryOf(t *testing.T, params map[string]string, defaultPerPage int) listing.Query {
+	q, err := listing.Parse(func(key string) string { return params[key] }, defaultPerPage)
+	require.NoError(t, err)
+	return q
+}
+
+// environmentIDs возвращает ID окружений
+func environmentIDs(environments []adapter.Environment) []int {
+	ids := make([]int, 0, len(environments))
+	for _, env := range environments {
+		ids = append(ids, env.ID)
+	}
+	return ids
+}
+
+// ✅ Фильтр name: префикс с * и без, glob-шаблон
+func TestListing_NameFilter(t *testing.T) {
+	for pattern, want := range map[string][]int{
+		"review/*":     {3, 4, 5},
+		"review/":      {3, 4, 5},
+		"prod":         {2},
+		"review/*-api": {4, 5},
+	} {
+		items, _, err := envSpec.Apply(reviewEnvironments, queryOf(t, map[string]string{"name": pattern}, 0))
+		require.NoError(t, err, pattern)
+		assert.Equal(t, want, environmentIDs(items), pattern)
+	}
+}
+
+// ✅ states, search и сортировка по убыванию
+func TestListing_StatesSearchSort(t *testing.T) {
+	q := queryOf(t, map[string]string{"states": "available", "search": "FEATURE", "sort": "-name"}, 0)
+
+	items, pagination, err := envSpec.Apply(reviewEnvironments, q)
+	require.NoError(t, err)
+	assert.Equal(t, []int{5, 3}, environmentIDs(items))
+	assert.Equal(t, &dto.Pagination{Page: 1, PerPage: 2, Total: 2, TotalPages: 1}, pagination)
+}
+
+// ✅ Постраничная выдача: номер следующей страницы и пустая страница за пределами списка
+func TestListing_Pages(t *testing.T) {
+	items, pagination, err := envSpec.Apply(reviewEnvironments, queryOf(t, map[string]string{"per_page": "2", "page": "2"}, 0))
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 4}, environmentIDs(items))
+	assert.Equal(t, &dto.Pagination{Page: 2, PerPage: 2, Total: 5, TotalPages: 3, NextPage: 3}, pagination)
+
+	items, pagination, err = envSpec.Apply(reviewEnvironments, queryOf(t, map[string]string{"page": "9"}, 2))
+	require.NoError(t, err)
+	assert.Empty(t, items)
+	assert.Zero(t, pagination.NextPage)
+}
+
+// ❌ Неразбираемые page/per_page - некорректный запрос (400), неподдерживаемые фильтры и сортировка - ошибка валидации
+func TestListing_Invalid(t *testing.T) {
+	for _, params := range []map[string]string{
+		{"page": "0"},
+		{"per_page": "500"},
+	} {
+		_, err := listing.Parse(func(key string) string { return params[key] }, 0)
+		assert.ErrorIs(t, err, apperror.ErrBadRequest, params)
+	}
+
+	for _, params := range []map[string]string{
+		{"states": "deleted"},
+		{"sort": "created_at"},
+		{"name": "review/[a"},
+	} {
+		_, _, err := envSpec.Apply(reviewEnvironments, queryOf(t, params, 0))
+		assert.ErrorIs(t, err, apperror.ErrValidation, params)
+	}
+
+	// Для коммитов фильтра states нет
+	_, _, err := listing.Spec[adapter.CommitInfo]{}.Apply(nil, queryOf(t, map[string]string{"states": "available"}, 0))
+	assert.ErrorIs(t, err, apperror.ErrValidation)
+}
+
+// ✅ Адаптер выгружает все страницы окружений: X-Next-Page, а без него - Link rel="next"
+func TestGetEnvironments_FollowsPagination(t *testing.T) {
+	var pages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		pages = append(pages, page)
+		switch page {
+		case "1":
+			w.Header().Set("X-Next-Page", "2")
+			fmt.Fprint(w, `[{"id": 1, "name": "staging", "state": "available"}]`)
+		case "2":
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?page=3&per_page=100>; rel="next"`, "http://"+r.Host, r.URL.Path))
+			fmt.Fprint(w, `[{"id": 2, "name": "production", "state": "available"}]`)
+		default:
+			fmt.Fprint(w, `[{"id": 3, "name": "review/a", "state": "stopped"}]`)
+		}
+	}))
+	defer server.Close()
+
+	client := adapter.NewGitLabClient(&config.Config{
+		GitLabBaseURL:   server.URL,
+		GitLabAPIURL:    "/api/v4/projects/",
+		GitLabAPIToken:  "test-token",
+		GitLabProjectID: "1",
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	environments, err := client.GetEnvironments(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, environmentIDs(environments))
+	assert.Equal(t, []string{"1", "2", "3"}, pages)
+}
+
+// ✅ /api/v1/environments: страница по умолчанию, meta.pagination и заголовки в стиле GitLab
+func TestHandlerV1_ListEnvironmentsPaginated(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	v1 := handler.NewV1Handler(service.NewStaticRegistry(service.NewGitLabService(&environmentsClient{})), nil)
+	app.Get("/api/v1/environments", v1.ListEnvironments)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/environments?name=review/*&per_page=2&sort=-id", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Data []dto.Environment `json:"data"`
+		Meta dto.Meta          `json:"meta"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Len(t, body.Data, 2)
+	assert.Equal(t, 5, body.Data[0].ID)
+	assert.Equal(t, "available", body.Data[0].State)
+	assert.Equal(t, &dto.Pagination{Page: 1, PerPage: 2, Total: 3, TotalPages: 2, NextPage: 2}, body.Meta.Pagination)
+
+	assert.Equal(t, "3", resp.Header.Get("X-Total"))
+	assert.Equal(t, "2", resp.Header.Get("X-Next-Page"))
+	assert.Contains(t, resp.Header.Get("Link"), `page=2&per_page=2&sort=-id>; rel="next"`)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/environments?states=deleted", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+}
+
+// environmentsClient - мок-клиент с окружениями review-приложений
+type environmentsClient struct {
+	adapter.GitLabClientInterface
+}
+
+// GetEnvironments возвращает окружения для проверки фильтров
+func (c *environmentsClient) GetEnvironments(ctx context.Context) ([]adapter.Environment, error) {
+	return reviewEnvironments, nil
+}
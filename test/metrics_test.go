@@ -0,0 +1,72 @@
+package test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/metrics"
+	"github.com/vkr-mtuci/gitlab-service/internal/service"
+)
+
+// ✅ Тест приведения URL GitLab к шаблону эндпоинта
+func TestMetricsEndpoint(t *testing.T) {
+	assert.Equal(t, "/projects/:project/jobs/:id/trace",
+		metrics.Endpoint("https://gitlab.example.com/api/v4/projects/1/jobs/201/trace"))
+	assert.Equal(t, "/projects/:project/repository/commits",
+		metrics.Endpoint("https://gitlab.example.com/api/v4/projects/group%2Fproject/repository/commits?ref_name=develop&page=3"))
+	assert.Equal(t, "/projects/:project/repository/commits/:sha/merge_requests",
+		metrics.Endpoint("/api/v4/projects/7/repository/commits/b0f9951803dcd80c141b667acbca9e46bace8acf/merge_requests"))
+}
+
+// ✅ Тест эндпоинта /metrics: метрики запросов учитываются по шаблону маршрута
+func TestMetricsHandler(t *testing.T) {
+	app := fiber.New()
+	app.Use(metrics.Middleware())
+	app.Get("/environments/:id", func(c *fiber.Ctx) error { return c.SendStatus(http.StatusOK) })
+	app.Get("/metrics", metrics.Handler())
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/environments/42", nil))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `gitlab_service_http_requests_total{method="GET",route="/environments/:id",status="200"}`)
+}
+
+// ✅ Запуски deploy-джоб учитываются по окружению джобы, а не по её имени
+func TestMetricsDeployJobsTriggered(t *testing.T) {
+	count := func(environment, outcome string) float64 {
+		return testutil.ToFloat64(metrics.DeployJobsTriggered.WithLabelValues(environment, outcome))
+	}
+	triggered, conflicts := count("staging", metrics.OutcomeTriggered), count("dev", "conflict")
+	unknown := count(metrics.UnknownEnvironment, "conflict")
+
+	svc := service.NewGitLabService(newPromotionClient())
+	_, _, err := svc.TriggerDeployJob(context.Background(), "12") // deploy-staging
+	require.NoError(t, err)
+	_, _, err = svc.TriggerDeployJob(context.Background(), "11") // deploy-dev уже выполнена
+	require.Error(t, err)
+
+	assert.Equal(t, triggered+1, count("staging", metrics.OutcomeTriggered))
+	assert.Equal(t, conflicts+1, count("dev", "conflict"))
+
+	// Окружение не определилось - метка unknown
+	client := newDetachedClient()
+	client.jobs[3].Status = "success"
+	_, _, err = service.NewProjectService(client, config.ProjectConfig{}, config.DefaultGitLabConcurrency).TriggerDeployJob(context.Background(), "14")
+	require.Error(t, err)
+	assert.Equal(t, unknown+1, count(metrics.UnknownEnvironment, "conflict"))
+}
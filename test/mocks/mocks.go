@@ -11,11 +11,14 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/mock"
 	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
 )
 
 // MockGitLabClient - мок-реализация клиента GitLab
 type MockGitLabClient struct {
 	mock.Mock
+	mu     sync.Mutex
+	played map[string]bool // Запущенные джобы: GitLab отвечает, что они уже выполнены
 }
 
 // MockGitLabServer - структура мок-сервера с кастомными ответами
@@ -34,8 +37,8 @@ type mockResponse struct {
 // GetEnvironments - возвращает тестовые окружения
 func (m *MockGitLabClient) GetEnvironments(ctx context.Context) ([]adapter.Environment, error) {
 	return []adapter.Environment{
-		{ID: 1, Name: "staging"},
-		{ID: 2, Name: "production"},
+		{ID: 1, Name: "staging", State: "available"},
+		{ID: 2, Name: "production", State: "available"},
 	}, nil
 }
 
@@ -53,7 +56,7 @@ func (m *MockGitLabClient) GetEnvironmentDetails(ctx context.Context, environmen
 			BuildVersion:    "1.2.3",
 		}, nil
 	}
-	return nil, errors.New("environment not found")
+	return nil, apperror.NotFound("environment not found")
 }
 
 // GetPreviousPipelineSHA - возвращает SHA предыдущего пайплайна
@@ -91,6 +94,15 @@ func (m *MockGitLabClient) GetCommitsBetweenSHAs(ctx context.Context, ref, fromS
 	return nil, errors.New("no commits found")
 }
 
+// CompareCommits - возвращает те же тестовые коммиты, что и GetCommitsBetweenSHAs
+func (m *MockGitLabClient) CompareCommits(ctx context.Context, fromSHA, toSHA string) ([]adapter.CommitInfo, error) {
+	commits, err := m.GetCommitsBetweenSHAs(ctx, "", fromSHA, toSHA)
+	if err != nil {
+		return []adapter.CommitInfo{}, nil
+	}
+	return commits, nil
+}
+
 // GetPipelineJobs - возвращает тестовые джобы для пайплайна
 func (m *MockGitLabClient) GetPipelineJobs(ctx context.Context, pipelineID string) ([]adapter.JobInfo, error) {
 	// Возвращаем фиктивные джобы со stage=deploy
@@ -119,6 +131,11 @@ func (m *MockGitLabClient) GetPipelineJobs(ctx context.Context, pipelineID strin
 	return nil, errors.New("❌ Джобы не найдены для pipelineID=" + pipelineID)
 }
 
+// Мок метода GetProjectJobs
+func (m *MockGitLabClient) GetProjectJobs(ctx context.Context, scopes ...string) ([]adapter.JobInfo, error) {
+	return m.GetPipelineJobs(ctx, "9679696")
+}
+
 // NewMockGitLabServer создаёт тестовый сервер, который эмулирует GitLab API
 func NewMockGitLabServer() *MockGitLabServer {
 	mock := &MockGitLabServer{
@@ -195,8 +212,8 @@ func NewMockGitLabServer() *MockGitLabServer {
 		// ✅ Стандартный ответ, если кастомный не подставлен
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte(`[
-			{"id": 1, "name": "staging"},
-			{"id": 2, "name": "production"}
+			{"id": 1, "name": "staging", "state": "available"},
+			{"id": 2, "name": "production", "state": "available"}
 		]`))
 	})
 
@@ -289,6 +306,13 @@ func NewMockGitLabServer() *MockGitLabServer {
 // TriggerDeployJob - мок для запуска деплоя
 func (m *MockGitLabClient) TriggerDeployJob(ctx context.Context, jobID string) (*adapter.TriggeredJob, error) {
 	if jobID == "7" {
+		m.mu.Lock()
+		if m.played == nil {
+			m.played = map[string]bool{}
+		}
+		m.played[jobID] = true
+		m.mu.Unlock()
+
 		return &adapter.TriggeredJob{
 			ID:        7,
 			Name:      "deploy-production",
@@ -298,7 +322,131 @@ func (m *MockGitLabClient) TriggerDeployJob(ctx context.Context, jobID string) (
 			WebURL:    "https://example.com/foo/bar/-/jobs/7",
 		}, nil
 	}
-	return nil, errors.New("job not found")
+	return nil, apperror.NotFound("job not found")
+}
+
+// GetJob - мок состояния джобы: джобы 7 и 8 ждут ручного запуска, запущенная джоба 7 завершилась успешно,
+// джоба 9 уже выполняется
+func (m *MockGitLabClient) GetJob(ctx context.Context, jobID string) (*adapter.TriggeredJob, error) {
+	if jobID == "9" {
+		return &adapter.TriggeredJob{
+			ID:        9,
+			Name:      "deploy to staging",
+			Stage:     "deploy",
+			Status:    "running",
+			CreatedAt: time.Now(),
+			WebURL:    "https://example.com/foo/bar/-/jobs/9",
+			Pipeline:  &adapter.Pipeline{ID: 9679696, SHA: "sha-123", Ref: "develop"},
+		}, nil
+	}
+	if jobID == "8" {
+		return &adapter.TriggeredJob{
+			ID:        8,
+			Name:      "deploy to staging",
+			Stage:     "deploy",
+			Status:    "manual",
+			CreatedAt: time.Now(),
+			WebURL:    "https://example.com/foo/bar/-/jobs/8",
+			Pipeline:  &adapter.Pipeline{ID: 9679696, SHA: "sha-123", Ref: "develop"},
+		}, nil
+	}
+	if jobID == "7" {
+		m.mu.Lock()
+		status := "manual"
+		if m.played[jobID] {
+			status = "success"
+		}
+		m.mu.Unlock()
+
+		return &adapter.TriggeredJob{
+			ID:        7,
+			Name:      "deploy-production",
+			Stage:     "deploy",
+			Status:    status,
+			CreatedAt: time.Now(),
+			WebURL:    "https://example.com/foo/bar/-/jobs/7",
+		}, nil
+	}
+	return nil, apperror.NotFound("job not found")
+}
+
+// GetJobTrace - мок лога джобы
+func (m *MockGitLabClient) GetJobTrace(ctx context.Context, jobID string) (string, error) {
+	if jobID == "7" {
+		return "Running with gitlab-runner\nBUILD_VERSION=1.2.3\nJob succeeded\n", nil
+	}
+	return "", apperror.NotFound("job not found")
+}
+
+// GetBuildVersion - мок BUILD_VERSION из лога джобы
+func (m *MockGitLabClient) GetBuildVersion(ctx context.Context, jobID string) (string, error) {
+	if jobID == "1001" || jobID == "201" {
+		return "1.2.3", nil
+	}
+	return "", apperror.NotFound("BUILD_VERSION не найден в логах")
+}
+
+// GetProject - мок проекта: у токена права Developer
+func (m *MockGitLabClient) GetProject(ctx context.Context) (*adapter.Project, error) {
+	project := &adapter.Project{ID: 1, Name: "bar", PathWithNamespace: "foo/bar"}
+	project.Permissions.ProjectAccess = &adapter.ProjectAccess{AccessLevel: adapter.AccessLevelDeveloper}
+	return project, nil
+}
+
+// RetryJob - мок перезапуска джобы
+func (m *MockGitLabClient) RetryJob(ctx context.Context, jobID string) (*adapter.TriggeredJob, error) {
+	return nil, apperror.NotFound("job not found")
+}
+
+// GetLatestPipeline - мок последнего пайплайна ветки
+func (m *MockGitLabClient) GetLatestPipeline(ctx context.Context, ref string) (*adapter.Pipeline, error) {
+	if ref == "develop" {
+		return &adapter.Pipeline{ID: 9679696, SHA: "sha-123", Ref: "develop"}, nil
+	}
+	return nil, apperror.NotFound("pipeline not found")
+}
+
+// GetPipelineBuildJobs - мок джоб пайплайна вне deploy-стадий
+func (m *MockGitLabClient) GetPipelineBuildJobs(ctx context.Context, pipelineID string) ([]adapter.JobInfo, error) {
+	return []adapter.JobInfo{
+		{ID: 5, Status: "success", Stage: "build", Stand: "build"},
+		{ID: 6, Status: "success", Stage: "test", Stand: "unit tests"},
+	}, nil
+}
+
+// GetTestReportSummary - мок отчёта о тестах: 99 из 100 тестов успешны
+func (m *MockGitLabClient) GetTestReportSummary(ctx context.Context, pipelineID string) (*adapter.TestReportSummary, error) {
+	summary := &adapter.TestReportSummary{}
+	summary.Total.Count, summary.Total.Success, summary.Total.Failed = 100, 99, 1
+	return summary, nil
+}
+
+// GetVulnerabilityFindings - мок уязвимостей: сканер ничего не нашёл
+func (m *MockGitLabClient) GetVulnerabilityFindings(ctx context.Context, pipelineID string, severities []string) ([]adapter.VulnerabilityFinding, error) {
+	return []adapter.VulnerabilityFinding{}, nil
+}
+
+// GetCommitBranches - мок веток коммита
+func (m *MockGitLabClient) GetCommitBranches(ctx context.Context, sha string) ([]string, error) {
+	return []string{"develop"}, nil
+}
+
+// GetProtectedBranches - мок защищённых веток
+func (m *MockGitLabClient) GetProtectedBranches(ctx context.Context) ([]adapter.ProtectedBranch, error) {
+	return []adapter.ProtectedBranch{{ID: 1, Name: "main"}, {ID: 2, Name: "release/*"}}, nil
+}
+
+// GetCommitMergeRequests - мок merge request'ов коммита
+func (m *MockGitLabClient) GetCommitMergeRequests(ctx context.Context, sha string) ([]adapter.MergeRequest, error) {
+	return []adapter.MergeRequest{{
+		IID: 42, Title: "Оплата частями", State: "merged", Author: adapter.User{Username: "dev", Name: "Developer"},
+		Labels: []string{"feature"}, SourceBranch: "feature/pay", TargetBranch: "develop", WebURL: "https://gitlab.com/mr/42",
+	}}, nil
+}
+
+// GetMergeRequestApprovers - мок одобривших merge request
+func (m *MockGitLabClient) GetMergeRequestApprovers(ctx context.Context, iid int) ([]adapter.User, error) {
+	return []adapter.User{{Username: "lead", Name: "Team Lead"}}, nil
 }
 
 // handleRequest - обрабатывает запросы и подставляет кастомные ответы
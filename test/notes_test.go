@@ -0,0 +1,153 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/dto"
+	"github.com/vkr-mtuci/gitlab-service/internal/handler"
+	"github.com/vkr-mtuci/gitlab-service/internal/service"
+)
+
+// notesClient - мок-клиент: сборка abc из main содержит коммиты двух merge request'ов и прямой push
+type notesClient struct {
+	adapter.GitLabClientInterface
+	mergeRequests map[string][]adapter.MergeRequest // SHA коммита -> его merge request'ы
+}
+
+func newNotesClient() *notesClient {
+	feature := adapter.MergeRequest{IID: 10, Title: "Оплата частями", State: "merged", TargetBranch: "main",
+		Author: adapter.User{Username: "anna", Name: "Анна"}, Labels: []string{"feature"}, WebURL: "https://gitlab.com/mr/10"}
+	backport := adapter.MergeRequest{IID: 11, Title: "Backport в release/1.0", State: "merged", TargetBranch: "release/1.0"}
+	hotfix := adapter.MergeRequest{IID: 12, Title: "Исправление округления", State: "merged", TargetBranch: "main",
+		Author: adapter.User{Username: "ivan", Name: "Иван"}, WebURL: "https://gitlab.com/mr/12"}
+
+	return &notesClient{mergeRequests: map[string][]adapter.MergeRequest{
+		"c1": {feature},
+		"c2": {feature},
+		"c3": {backport, hotfix},
+		"c4": {feature},
+	}}
+}
+
+func (c *notesClient) GetPreviousPipelineSHA(ctx context.Context, ref, currentSHA string) (string, error) {
+	return "prev", nil
+}
+
+func (c *notesClient) GetCommitsBetweenSHAs(ctx context.Context, ref, fromSHA, toSHA string) ([]adapter.CommitInfo, error) {
+	return []adapter.CommitInfo{
+		{ID: "c1", Message: "Оплата частями PAY-1", JiraKeys: []string{"PAY-1"}},
+		{ID: "c2", Message: "fix review comments"},
+		{ID: "c3", Message: "Округление сумм PAY-2", JiraKeys: []string{"PAY-2"}},
+		{ID: "c4", Message: "fix review comments"},
+		{ID: "c5", Message: "Обновлён README"},
+	}, nil
+}
+
+func (c *notesClient) GetCommitMergeRequests(ctx context.Context, sha string) ([]adapter.MergeRequest, error) {
+	return c.mergeRequests[sha], nil
+}
+
+func (c *notesClient) GetMergeRequestApprovers(ctx context.Context, iid int) ([]adapter.User, error) {
+	if iid == 12 {
+		return nil, apperror.Forbidden("approvals недоступны")
+	}
+	return []adapter.User{{Username: "lead", Name: "Тимлид"}}, nil
+}
+
+// notesApp собирает приложение с коммитами сборки и release notes
+func notesApp(client *notesClient) *fiber.App {
+	services := service.NewStaticRegistry(service.NewProjectService(client, config.ProjectConfig{Name: "payments"}, config.DefaultGitLabConcurrency))
+	h := handler.NewV1Handler(services, nil)
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Get("/api/v1/commits/:ref/:sha", h.ListCommits)
+	app.Get("/api/v1/commits/:ref/:sha/release-notes", h.ReleaseNotes)
+	app.Get("/commits/:ref/:sha", handler.NewGitLabHandler(services, nil).GetCommitsInBuild)
+	return app
+}
+
+// getData выполняет GET и разбирает data из конверта ответа
+func getData(t *testing.T, app *fiber.App, path string, data any) {
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	envelope := struct {
+		Data any `json:"data"`
+	}{Data: data}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&envelope))
+}
+
+// ✅ Release notes группируют коммиты по merge request, коммиты без merge request - последними
+func TestReleaseNotes_GroupedByMergeRequest(t *testing.T) {
+	var notes []dto.ReleaseNote
+	getData(t, notesApp(newNotesClient()), "/api/v1/commits/main/abc/release-notes", &notes)
+
+	require.Len(t, notes, 3)
+
+	feature := notes[0]
+	require.NotNil(t, feature.MergeRequest)
+	assert.Equal(t, 10, feature.MergeRequest.IID)
+	assert.Equal(t, "Оплата частями", feature.MergeRequest.Title)
+	assert.Equal(t, "anna", feature.MergeRequest.Author.Username)
+	assert.Equal(t, []string{"feature"}, feature.MergeRequest.Labels)
+	assert.Equal(t, []dto.Person{{Username: "lead", Name: "Тимлид"}}, feature.MergeRequest.Approvers)
+	assert.Equal(t, "https://gitlab.com/mr/10", feature.MergeRequest.WebURL)
+	assert.Len(t, feature.Commits, 3)
+	assert.Equal(t, []string{"PAY-1"}, feature.JiraKeys)
+
+	// Коммит из backport и hotfix относится к merge request'у, влитому в main
+	hotfix := notes[1]
+	require.NotNil(t, hotfix.MergeRequest)
+	assert.Equal(t, 12, hotfix.MergeRequest.IID)
+	assert.Empty(t, hotfix.MergeRequest.Approvers)
+	assert.Equal(t, "c3", hotfix.Commits[0].ID)
+
+	direct := notes[2]
+	assert.Nil(t, direct.MergeRequest)
+	require.Len(t, direct.Commits, 1)
+	assert.Equal(t, "c5", direct.Commits[0].ID)
+}
+
+// ✅ С ?with_merge_requests=true у коммитов есть ссылки на все их merge request'ы
+func TestListCommits_WithMergeRequests(t *testing.T) {
+	app := notesApp(newNotesClient())
+
+	var commits []dto.Commit
+	getData(t, app, "/api/v1/commits/main/abc", &commits)
+	require.Len(t, commits, 5)
+	assert.Empty(t, commits[0].MergeRequests)
+
+	getData(t, app, "/api/v1/commits/main/abc?with_merge_requests=true", &commits)
+	require.Len(t, commits, 5)
+	assert.Equal(t, []dto.MergeRequestRef{{IID: 10, Title: "Оплата частями", State: "merged", WebURL: "https://gitlab.com/mr/10"}}, commits[0].MergeRequests)
+	assert.Len(t, commits[2].MergeRequests, 2)
+	assert.Empty(t, commits[4].MergeRequests)
+}
+
+// ✅ Устаревший маршрут коммитов сборки тоже добавляет merge request'ы по ?with_merge_requests=true
+func TestGetCommitsInBuild_WithMergeRequests(t *testing.T) {
+	resp, err := notesApp(newNotesClient()).Test(httptest.NewRequest(http.MethodGet, "/commits/main/abc?with_merge_requests=true", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Commits []handler.CommitResponse `json:"commits"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Len(t, body.Commits, 5)
+	assert.Equal(t, []dto.MergeRequestRef{{IID: 10, Title: "Оплата частями", State: "merged", WebURL: "https://gitlab.com/mr/10"}}, body.Commits[0].MergeRequests)
+	assert.Len(t, body.Commits[2].MergeRequests, 2)
+	assert.Empty(t, body.Commits[4].MergeRequests)
+}
@@ -0,0 +1,273 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/dto"
+	"github.com/vkr-mtuci/gitlab-service/internal/handler"
+	"github.com/vkr-mtuci/gitlab-service/internal/health"
+	"github.com/vkr-mtuci/gitlab-service/internal/openapi"
+	"github.com/vkr-mtuci/gitlab-service/internal/operation"
+	"github.com/vkr-mtuci/gitlab-service/internal/server"
+	"github.com/vkr-mtuci/gitlab-service/internal/service"
+	"github.com/vkr-mtuci/gitlab-service/test/mocks"
+)
+
+// specModels - схемы спецификации и модели, которые сервис отдаёт в JSON
+var specModels = map[string]any{
+	"Environment":             adapter.Environment{},
+	"DeploymentInfo":          adapter.DeploymentInfo{},
+	"CommitInfo":              handler.CommitResponse{},
+	"JobInfo":                 adapter.JobInfo{},
+	"TriggeredJob":            handler.TriggeredJobResponse{},
+	"Pipeline":                adapter.Pipeline{},
+	"GitLabUser":              adapter.User{},
+	"ErrorResponse":           handler.ErrorResponse{},
+	"HealthReport":            health.Report{},
+	"HealthCheckResult":       health.Result{},
+	"ConfigStatus":            handler.ConfigStatusResponse{},
+	"ReloadResult":            config.ReloadResult{},
+	"ConfigProblem":           config.Problem{},
+	"Meta":                    dto.Meta{},
+	"Pagination":              dto.Pagination{},
+	"V1Environment":           dto.Environment{},
+	"V1Deployment":            dto.Deployment{},
+	"V1Commit":                dto.Commit{},
+	"V1DeployJob":             dto.DeployJob{},
+	"V1QualityGate":           dto.QualityGate{},
+	"V1MergeRequestRef":       dto.MergeRequestRef{},
+	"V1Person":                dto.Person{},
+	"V1MergeRequest":          dto.MergeRequest{},
+	"V1ReleaseNote":           dto.ReleaseNote{},
+	"V1TriggeredJob":          dto.TriggeredJob{},
+	"V1JobRun":                dto.JobRun{},
+	"V1Operation":             dto.Operation{},
+	"V1DeployPreview":         dto.DeployPreview{},
+	"V1ReleaseStepPreview":    dto.ReleaseStepPreview{},
+	"V1EnvironmentOverview":   dto.EnvironmentOverview{},
+	"V1Freeze":                dto.Freeze{},
+	"ItemError":               dto.ItemError{},
+	"V1EnvironmentComparison": dto.EnvironmentComparison{},
+	"PromotionRequest":        handler.PromotionRequest{},
+	"V1Promotion":             dto.Promotion{},
+	"ScheduleRequest":         handler.ScheduleRequest{},
+	"V1Schedule":              dto.Schedule{},
+	"ReleasePlanRequest":      handler.ReleasePlanRequest{},
+	"ReleaseStepRequest":      handler.ReleaseStepRequest{},
+	"V1ReleasePlan":           dto.ReleasePlan{},
+	"V1ReleaseStep":           dto.ReleaseStep{},
+}
+
+// ✅ Спецификация корректна и содержит все схемы моделей
+func TestOpenAPI_SpecIsValid(t *testing.T) {
+	spec, err := openapi.Spec()
+	require.NoError(t, err)
+
+	for name, schema := range spec.Components.Schemas {
+		if !schema.Value.Type.Is("object") {
+			continue // Скалярные схемы (например Timestamp) моделям не сопоставляются
+		}
+		assert.Contains(t, specModels, name, "схема %s не сопоставлена модели в specModels", name)
+	}
+}
+
+// ✅ Маршруты сервиса и пути спецификации совпадают
+func TestOpenAPI_RoutesMatchSpec(t *testing.T) {
+	spec, err := openapi.Spec()
+	require.NoError(t, err)
+
+	app := fiber.New()
+	server.Routes{
+		GitLab:     &handler.GitLabHandler{},
+		V1:         &handler.V1Handler{},
+		Promotions: &handler.PromotionHandler{},
+		Schedules:  &handler.ScheduleHandler{},
+		Releases:   &handler.ReleaseHandler{},
+		Health:     &handler.HealthHandler{},
+		Admin:      &handler.AdminHandler{},
+	}.Register(app)
+
+	var routes []string
+	for _, route := range app.GetRoutes(true) {
+		if route.Method == fiber.MethodHead {
+			continue // Fiber регистрирует HEAD для каждого GET
+		}
+		routes = append(routes, route.Method+" "+route.Path)
+	}
+
+	param := regexp.MustCompile(`\{([^}]+)\}`)
+	var documented []string
+	for path, item := range spec.Paths.Map() {
+		for method := range item.Operations() {
+			documented = append(documented, method+" "+param.ReplaceAllString(path, ":$1"))
+		}
+	}
+
+	sort.Strings(routes)
+	sort.Strings(documented)
+	assert.Equal(t, documented, routes, "маршруты в internal/server/routes.go и internal/openapi/openapi.yaml разошлись")
+}
+
+// ✅ Свойства схем совпадают с JSON-полями моделей (например, JobInfo.Stand отдаётся как name)
+func TestOpenAPI_SchemasMatchModels(t *testing.T) {
+	spec, err := openapi.Spec()
+	require.NoError(t, err)
+
+	for name, model := range specModels {
+		schema, ok := spec.Components.Schemas[name]
+		require.True(t, ok, "в спецификации нет схемы %s", name)
+
+		var properties []string
+		for property := range schema.Value.Properties {
+			properties = append(properties, property)
+		}
+		sort.Strings(properties)
+
+		assert.Equal(t, jsonFields(reflect.TypeOf(model)), properties, "схема %s не совпадает с моделью %T", name, model)
+	}
+}
+
+// ✅ Ответы обработчиков соответствуют спецификации
+func TestOpenAPI_ResponsesMatchSpec(t *testing.T) {
+	spec, err := openapi.Spec()
+	require.NoError(t, err)
+	router, err := gorillamux.NewRouter(spec)
+	require.NoError(t, err)
+
+	// Каждый запрос - к новому мок-клиенту: запущенную джобу нельзя запустить повторно
+	newApp := func() *fiber.App {
+		services := service.NewStaticRegistry(service.NewGitLabService(&mocks.MockGitLabClient{}))
+		operations := operation.NewManager(context.Background(), time.Millisecond)
+		app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+		server.Routes{
+			GitLab:     handler.NewGitLabHandler(services, operations),
+			V1:         handler.NewV1Handler(services, operations),
+			Promotions: handler.NewPromotionHandler(services, service.NewPromotionStore(service.PromotionHistoryLimit)),
+		}.Register(app)
+		return app
+	}
+
+	requests := []struct {
+		method, path string
+		status       int
+	}{
+		{http.MethodGet, "/environments", http.StatusOK},
+		{http.MethodGet, "/environments/1", http.StatusOK},
+		{http.MethodGet, "/environments/42", http.StatusNotFound},
+		{http.MethodGet, "/commits/develop/sha-123", http.StatusOK},
+		{http.MethodGet, "/commits/develop/sha-123?with_merge_requests=true", http.StatusOK},
+		{http.MethodGet, "/pipelines/9679696/deploy-jobs", http.StatusOK},
+		{http.MethodPost, "/jobs/7/play", http.StatusOK},
+		{http.MethodPost, "/jobs/999/play", http.StatusNotFound},
+		{http.MethodPost, "/jobs/8/play?dry_run=true", http.StatusOK},
+		{http.MethodPost, "/jobs/7/play?wait=true&timeout=10m", http.StatusOK},
+		{http.MethodPost, "/jobs/7/play?async=true", http.StatusAccepted},
+		{http.MethodGet, "/api/v1/environments", http.StatusOK},
+		{http.MethodGet, "/api/v1/environments/overview", http.StatusOK},
+		{http.MethodGet, "/api/v1/environments/compare?from=staging&to=production", http.StatusConflict},
+		{http.MethodGet, "/api/v1/environments/1", http.StatusOK},
+		{http.MethodGet, "/api/v1/environments/42", http.StatusNotFound},
+		{http.MethodGet, "/api/v1/commits/develop/sha-123", http.StatusOK},
+		{http.MethodGet, "/api/v1/commits/develop/sha-123?with_merge_requests=true", http.StatusOK},
+		{http.MethodGet, "/api/v1/commits/develop/sha-123/release-notes", http.StatusOK},
+		{http.MethodGet, "/api/v1/pipelines/9679696/deploy-jobs", http.StatusOK},
+		{http.MethodPost, "/api/v1/jobs/7/play", http.StatusOK},
+		{http.MethodPost, "/api/v1/jobs/7/play?wait=true", http.StatusOK},
+		{http.MethodPost, "/api/v1/jobs/7/play?async=true", http.StatusAccepted},
+		{http.MethodPost, "/api/v1/jobs/7/play?wait=true&timeout=forever", http.StatusBadRequest},
+		{http.MethodPost, "/api/v1/jobs/8/play?dry_run=true", http.StatusOK},
+		{http.MethodPost, "/api/v1/jobs/9/play?dry_run=true", http.StatusConflict},
+		{http.MethodPost, "/api/v1/jobs/999/play?dry_run=true", http.StatusNotFound},
+		{http.MethodGet, "/api/v1/operations/unknown", http.StatusNotFound},
+		{http.MethodGet, "/api/v1/promotions", http.StatusOK},
+		{http.MethodGet, "/api/v1/promotions/unknown", http.StatusNotFound},
+	}
+
+	for _, r := range requests {
+		t.Run(r.method+" "+r.path, func(t *testing.T) {
+			req := httptest.NewRequest(r.method, r.path, nil)
+			resp, err := newApp().Test(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, r.status, resp.StatusCode)
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			route, pathParams, err := router.FindRoute(httptest.NewRequest(r.method, "http://localhost"+r.path, nil))
+			require.NoError(t, err)
+
+			input := &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: &openapi3filter.RequestValidationInput{
+					Request:    req,
+					PathParams: pathParams,
+					Route:      route,
+				},
+				Status: resp.StatusCode,
+				Header: resp.Header,
+				Body:   io.NopCloser(bytes.NewReader(body)),
+			}
+			assert.NoError(t, openapi3filter.ValidateResponse(context.Background(), input), string(body))
+		})
+	}
+}
+
+// ❌ Запрос с параметрами не по спецификации отклоняется с 400 до обращения к GitLab
+func TestOpenAPI_ValidationMiddleware(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Use(openapi.Middleware(handler.ErrorHandler))
+	app.Get("/environments/:id", func(c *fiber.Ctx) error { return c.SendStatus(http.StatusOK) })
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/environments/1", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	for _, path := range []string{"/environments/staging", "/environments/1?lang=de"} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, path)
+	}
+}
+
+// jsonFields возвращает отсортированные имена JSON-полей структуры
+func jsonFields(t reflect.Type) []string {
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" && field.Anonymous {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			fields = append(fields, jsonFields(embedded)...) // Поля встроенной структуры - на уровне модели
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	return fields
+}
@@ -0,0 +1,304 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/auth"
+	"github.com/vkr-mtuci/gitlab-service/internal/dto"
+	"github.com/vkr-mtuci/gitlab-service/internal/handler"
+	"github.com/vkr-mtuci/gitlab-service/internal/operation"
+	"github.com/vkr-mtuci/gitlab-service/internal/service"
+	"github.com/vkr-mtuci/gitlab-service/test/mocks"
+)
+
+// waitClient - мок-клиент: джоба после запуска выполняется runningPolls опросов и завершается со статусом final
+type waitClient struct {
+	adapter.GitLabClientInterface
+	mu           sync.Mutex
+	runningPolls int
+	final        string
+	triggered    bool
+	polls        int
+}
+
+func (c *waitClient) TriggerDeployJob(ctx context.Context, jobID string) (*adapter.TriggeredJob, error) {
+	c.mu.Lock()
+	c.triggered = true
+	c.mu.Unlock()
+
+	id, _ := strconv.Atoi(jobID)
+	return &adapter.TriggeredJob{ID: id, Name: "deploy-production", Status: "pending", CreatedAt: time.Now()}, nil
+}
+
+func (c *waitClient) GetJob(ctx context.Context, jobID string) (*adapter.TriggeredJob, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id, _ := strconv.Atoi(jobID)
+	if !c.triggered {
+		return &adapter.TriggeredJob{ID: id, Name: "deploy-production", Stage: "deploy", Status: "manual"}, nil
+	}
+
+	c.polls++
+	job := &adapter.TriggeredJob{ID: id, Name: "deploy-production", Status: "running", StartedAt: time.Now()}
+	if c.runningPolls >= 0 && c.polls > c.runningPolls {
+		job.Status = c.final
+		job.Duration = 42.5
+	}
+	return job, nil
+}
+
+func (c *waitClient) GetJobTrace(ctx context.Context, jobID string) (string, error) {
+	return "\x1b[0KRunning with gitlab-runner\nsection_start:1700000000:step_script\r\x1b[0K$ ./deploy.sh\n\x1b[31;1mERROR: deploy failed\x1b[0;m\n", nil
+}
+
+// waitApp собирает приложение с запуском джоб (v1 и устаревшим маршрутом) и операциями ожидания
+func waitApp(client adapter.GitLabClientInterface) *fiber.App {
+	services := service.NewStaticRegistry(service.NewGitLabService(client))
+	operations := operation.NewManager(context.Background(), time.Millisecond)
+	h := handler.NewV1Handler(services, operations)
+
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Post("/api/v1/jobs/:job_id/play", h.PlayJob)
+	app.Get("/api/v1/operations/:id", h.GetOperation)
+	app.Post("/jobs/:job_id/play", handler.NewGitLabHandler(services, operations).TriggerDeployJob)
+	return app
+}
+
+// playJob запускает джобу и возвращает ответ
+func playJob(t *testing.T, app *fiber.App, query string) *http.Response {
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/api/v1/jobs/7/play"+query, nil), -1)
+	require.NoError(t, err)
+	return resp
+}
+
+// ✅ Лог джобы очищается от управляющих последовательностей, остаются последние строки
+func TestTraceTail(t *testing.T) {
+	trace := "\x1b[0Kline 1\r\nsection_start:1700000000:step_script\r\x1b[0Kline 2\n\x1b[32;1mline 3\x1b[0;m\n\n"
+
+	assert.Equal(t, "line 1\nline 2\nline 3", service.TraceTail(trace, 10))
+	assert.Equal(t, "line 2\nline 3", service.TraceTail(trace, 2))
+	assert.Equal(t, "", service.TraceTail("", 10))
+}
+
+// ✅ С wait=true ответ приходит после завершения джобы: джоба, длительность и конец лога
+func TestPlayJob_WaitReturnsJobRun(t *testing.T) {
+	client := &waitClient{GitLabClientInterface: &mocks.MockGitLabClient{}, runningPolls: 2, final: "success"}
+
+	resp := playJob(t, waitApp(client), "?wait=true")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Data dto.JobRun `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "success", body.Data.Job.Status)
+	assert.Equal(t, 42.5, body.Data.Duration)
+	assert.Equal(t, "Running with gitlab-runner\n$ ./deploy.sh\nERROR: deploy failed", body.Data.TraceTail)
+	assert.Equal(t, 3, client.polls)
+}
+
+// ✅ Устаревший маршрут тоже ждёт джобу (?wait=true&timeout=10m) и возвращает итог без конверта
+func TestPlayJob_LegacyWait(t *testing.T) {
+	client := &waitClient{GitLabClientInterface: &mocks.MockGitLabClient{}, runningPolls: 1, final: "success"}
+
+	resp, err := waitApp(client).Test(httptest.NewRequest(http.MethodPost, "/jobs/7/play?wait=true&timeout=10m", nil), -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var run dto.JobRun
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&run))
+	assert.Equal(t, "success", run.Job.Status)
+	assert.Contains(t, run.TraceTail, "ERROR: deploy failed")
+	assert.Equal(t, 2, client.polls)
+}
+
+// ❌ Неуспешная джоба даёт 424 job_failed, итог с логом - в details
+func TestPlayJob_WaitJobFailed(t *testing.T) {
+	client := &waitClient{GitLabClientInterface: &mocks.MockGitLabClient{}, final: "failed"}
+
+	resp := playJob(t, waitApp(client), "?wait=true")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFailedDependency, resp.StatusCode)
+
+	var body struct {
+		Code    string     `json:"code"`
+		Details dto.JobRun `json:"details"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "job_failed", body.Code)
+	assert.Equal(t, "failed", body.Details.Job.Status)
+	assert.Contains(t, body.Details.TraceTail, "ERROR: deploy failed")
+}
+
+// ❌ Если джоба не завершилась за timeout - 504 с последним известным состоянием
+func TestPlayJob_WaitTimeout(t *testing.T) {
+	client := &waitClient{GitLabClientInterface: &mocks.MockGitLabClient{}, runningPolls: -1}
+
+	resp := playJob(t, waitApp(client), "?wait=true&timeout=50ms")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusGatewayTimeout, resp.StatusCode)
+
+	var body struct {
+		Details dto.JobRun `json:"details"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "running", body.Details.Job.Status)
+}
+
+// ❌ Некорректный (400) или слишком большой (422) timeout отклоняется до запуска джобы
+func TestPlayJob_WaitInvalidTimeout(t *testing.T) {
+	app := waitApp(&mocks.MockGitLabClient{})
+
+	for query, want := range map[string]int{
+		"?wait=true&timeout=soon": http.StatusBadRequest,
+		"?wait=true&timeout=-1m":  http.StatusBadRequest,
+		"?wait=true&timeout=7h":   http.StatusUnprocessableEntity,
+		"?async=true&timeout=7h":  http.StatusUnprocessableEntity,
+	} {
+		resp := playJob(t, app, query)
+		resp.Body.Close()
+		assert.Equal(t, want, resp.StatusCode, query)
+	}
+}
+
+// ✅ С async=true ответ 202 с операцией, которая завершается вместе с джобой
+func TestPlayJob_AsyncOperation(t *testing.T) {
+	client := &waitClient{GitLabClientInterface: &mocks.MockGitLabClient{}, runningPolls: 3, final: "success"}
+	app := waitApp(client)
+
+	resp := playJob(t, app, "?async=true&timeout=1h")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	var created struct {
+		Data dto.Operation `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	assert.Equal(t, operation.StatusRunning, created.Data.Status)
+	assert.Equal(t, 7, created.Data.JobID)
+	location := resp.Header.Get(fiber.HeaderLocation)
+	assert.Equal(t, "/api/v1/operations/"+created.Data.ID, location)
+
+	var current struct {
+		Data dto.Operation `json:"data"`
+	}
+	require.Eventually(t, func() bool {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, location, nil))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&current))
+		return current.Data.Status != operation.StatusRunning
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, operation.StatusSucceeded, current.Data.Status)
+	require.NotNil(t, current.Data.Result)
+	assert.Equal(t, "success", current.Data.Result.Job.Status)
+	assert.Nil(t, current.Data.Error)
+}
+
+// ❌ Операция с неуспешной джобой завершается с ошибкой job_failed
+func TestPlayJob_AsyncOperationFailed(t *testing.T) {
+	client := &waitClient{GitLabClientInterface: &mocks.MockGitLabClient{}, final: "canceled"}
+	app := waitApp(client)
+
+	resp := playJob(t, app, "?async=true")
+	resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	location := resp.Header.Get(fiber.HeaderLocation)
+
+	var current struct {
+		Data dto.Operation `json:"data"`
+	}
+	require.Eventually(t, func() bool {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, location, nil))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&current))
+		return current.Data.Status != operation.StatusRunning
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, operation.StatusFailed, current.Data.Status)
+	require.NotNil(t, current.Data.Error)
+	assert.Equal(t, "job_failed", current.Data.Error.Code)
+	assert.Contains(t, current.Data.Error.Message, "canceled")
+}
+
+// ❌ Неизвестная операция - 404
+func TestGetOperation_NotFound(t *testing.T) {
+	resp, err := waitApp(&mocks.MockGitLabClient{}).Test(httptest.NewRequest(http.MethodGet, "/api/v1/operations/unknown", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+// 🔒 Операция видна только запустившему её клиенту: чужой клиент получает 404
+func TestGetOperation_OwnerOnly(t *testing.T) {
+	client := &waitClient{GitLabClientInterface: &mocks.MockGitLabClient{}, final: "success"}
+	services := service.NewStaticRegistry(service.NewGitLabService(client))
+	h := handler.NewV1Handler(services, operation.NewManager(context.Background(), time.Millisecond))
+
+	authConfig := config.AuthConfig{UserHeader: "X-Forwarded-User", TrustedProxies: []string{testClientIP}}
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Use(auth.Middleware(func() config.AuthConfig { return authConfig }, handler.ErrorHandler))
+	app.Post("/api/v1/jobs/:job_id/play", h.PlayJob)
+	app.Get("/api/v1/operations/:id", h.GetOperation)
+
+	request := func(method, target, user string) *http.Response {
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("X-Forwarded-User", user)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	resp := request(http.MethodPost, "/api/v1/jobs/7/play?async=true", "ivan@example.com")
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	location := resp.Header.Get(fiber.HeaderLocation)
+
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, location, "ivan@example.com").StatusCode)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, location, "petr@example.com").StatusCode)
+}
+
+// 🛑 При остановке сервис ждёт операции; не дождавшиеся джобы до таймаута прерываются
+func TestOperations_Drain(t *testing.T) {
+	ctx, interrupt := context.WithCancel(context.Background())
+	defer interrupt()
+	operations := operation.NewManager(ctx, time.Millisecond)
+
+	finishing := service.NewGitLabService(&waitClient{GitLabClientInterface: &mocks.MockGitLabClient{}, triggered: true, runningPolls: 3, final: "success"})
+	done := operations.Start(finishing, operation.Operation{JobID: 7}, time.Hour, time.Now())
+	require.NoError(t, operations.Drain(context.Background()))
+
+	op, err := operations.Get(done.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, operation.StatusSucceeded, op.Status)
+
+	endless := service.NewGitLabService(&waitClient{GitLabClientInterface: &mocks.MockGitLabClient{}, triggered: true, runningPolls: -1})
+	stuck := operations.Start(endless, operation.Operation{JobID: 7}, time.Hour, time.Now())
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, operations.Drain(drainCtx), context.DeadlineExceeded)
+
+	interrupt()
+	require.NoError(t, operations.Drain(context.Background()))
+	op, err = operations.Get(stuck.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, operation.StatusFailed, op.Status)
+}
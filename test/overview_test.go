@@ -0,0 +1,114 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/dto"
+	"github.com/vkr-mtuci/gitlab-service/internal/handler"
+	"github.com/vkr-mtuci/gitlab-service/internal/openapi"
+	"github.com/vkr-mtuci/gitlab-service/internal/service"
+)
+
+// overviewClient - мок-клиент со множеством окружений; считает параллельные запросы деплоев
+type overviewClient struct {
+	adapter.GitLabClientInterface
+	environments []adapter.Environment
+	inFlight     atomic.Int32
+	maxInFlight  atomic.Int32
+}
+
+// GetEnvironments возвращает окружения
+func (c *overviewClient) GetEnvironments(ctx context.Context) ([]adapter.Environment, error) {
+	return c.environments, nil
+}
+
+// GetEnvironmentDetails возвращает деплой; окружение 13 недоступно
+func (c *overviewClient) GetEnvironmentDetails(ctx context.Context, environmentID string) (*adapter.DeploymentInfo, error) {
+	current := c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+	for {
+		top := c.maxInFlight.Load()
+		if current <= top || c.maxInFlight.CompareAndSwap(top, current) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	if environmentID == "13" {
+		return nil, apperror.Unavailable(fmt.Errorf("timeout"), "ошибка запроса к GitLab")
+	}
+	id, _ := strconv.Atoi(environmentID)
+	return &adapter.DeploymentInfo{
+		EnvironmentName: "env-" + environmentID,
+		DeploymentDate:  "2025-02-06T17:54:27Z",
+		SHA:             "sha-" + environmentID,
+		JobID:           100 + id,
+		DeployStatus:    "success",
+		BuildVersion:    "1.0." + environmentID,
+	}, nil
+}
+
+// ✅ Сводка: параллельно, не больше concurrency запросов, частичный результат, блокировки и заморозки
+func TestHandlerV1_EnvironmentsOverview(t *testing.T) {
+	client := &overviewClient{}
+	for i := 1; i <= 20; i++ {
+		client.environments = append(client.environments, adapter.Environment{ID: i, Name: fmt.Sprintf("env-%d", i), State: "available"})
+	}
+
+	now := time.Now()
+	project := config.ProjectConfig{Environments: []config.EnvironmentConfig{
+		{Name: "env-1", Locked: true, LockReason: "Инцидент"},
+		{Name: "env-2", FreezeWindows: []config.FreezeWindow{{From: now.Add(-time.Hour), To: now.Add(time.Hour), Reason: "Релиз"}}},
+		{Name: "env-3", FreezeWindows: []config.FreezeWindow{{From: now.Add(time.Hour), To: now.Add(2 * time.Hour)}}},
+	}}
+	services := service.NewStaticRegistry(service.NewProjectService(client, project, 3))
+
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Use(openapi.Middleware(handler.ErrorHandler))
+	app.Get("/api/v1/environments/overview", handler.NewV1Handler(services, nil).EnvironmentsOverview)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/environments/overview?per_page=100&sort=id", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Data []dto.EnvironmentOverview `json:"data"`
+		Meta dto.Meta                  `json:"meta"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Len(t, body.Data, 20)
+	assert.True(t, body.Meta.Partial)
+	assert.LessOrEqual(t, client.maxInFlight.Load(), int32(3))
+	assert.Greater(t, client.maxInFlight.Load(), int32(1))
+
+	assert.True(t, body.Data[0].Locked)
+	assert.Equal(t, "Инцидент", body.Data[0].LockReason)
+	require.NotNil(t, body.Data[1].Freeze)
+	assert.Equal(t, "Релиз", body.Data[1].Freeze.Reason)
+	assert.Nil(t, body.Data[2].Freeze) // Окно заморозки ещё не началось
+
+	require.NotNil(t, body.Data[4].LastDeployment)
+	assert.Equal(t, "1.0.5", body.Data[4].LastDeployment.BuildVersion)
+	assert.Nil(t, body.Data[4].Error)
+
+	failed := body.Data[12]
+	assert.Equal(t, "env-13", failed.Name)
+	assert.Nil(t, failed.LastDeployment)
+	require.NotNil(t, failed.Error)
+	assert.Equal(t, "upstream_unavailable", failed.Error.Code)
+}
@@ -0,0 +1,221 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+)
+
+// pagedServer - GitLab-подобный список из pages страниц по одному элементу (id = номер страницы)
+type pagedServer struct {
+	*httptest.Server
+	mu        sync.Mutex
+	requested []string // Запрошенные страницы
+}
+
+// newPagedServer запускает сервер со списком /items
+func newPagedServer(t *testing.T, pages int) *pagedServer {
+	s := &pagedServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("pagination") == "keyset" {
+			// Курсор - ID последнего элемента, следующая страница только по ссылке в Link
+			after, _ := strconv.Atoi(query.Get("id_after"))
+			s.record("keyset:" + strconv.Itoa(after+1))
+			if after+1 < pages {
+				w.Header().Set("Link", fmt.Sprintf(`<%s/items?pagination=keyset&id_after=%d>; rel="next"`, s.URL, after+1))
+			}
+			fmt.Fprintf(w, `[{"id": %d}]`, after+1)
+			return
+		}
+
+		page, _ := strconv.Atoi(query.Get("page"))
+		s.record(strconv.Itoa(page))
+		w.Header().Set("X-Total-Pages", strconv.Itoa(pages))
+		if page < pages {
+			w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+		}
+		fmt.Fprintf(w, `[{"id": %d}]`, page)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// record запоминает запрошенную страницу
+func (s *pagedServer) record(page string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requested = append(s.requested, page)
+}
+
+// pages возвращает запрошенные страницы
+func (s *pagedServer) pages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requested...)
+}
+
+// item - элемент тестового списка
+type item struct {
+	ID int `json:"id"`
+}
+
+// collect обходит список и возвращает ID элементов
+func collect(t *testing.T, server *pagedServer, opts adapter.PageOptions, stopAt int) ([]int, adapter.PageStats) {
+	var ids []int
+	stats, err := adapter.Paginate(context.Background(), resty.New(), server.URL+"/items", url.Values{"scope": {"all"}}, opts,
+		func(items []item) bool {
+			for _, it := range items {
+				ids = append(ids, it.ID)
+			}
+			return stopAt == 0 || ids[len(ids)-1] < stopAt
+		})
+	require.NoError(t, err)
+	return ids, stats
+}
+
+// ✅ Все страницы по X-Next-Page, X-Total-Pages попадает в итог
+func TestPaginate_AllPages(t *testing.T) {
+	for _, prefetch := range []bool{false, true} {
+		server := newPagedServer(t, 4)
+
+		ids, stats := collect(t, server, adapter.PageOptions{Name: "items", Prefetch: prefetch}, 0)
+		assert.Equal(t, []int{1, 2, 3, 4}, ids)
+		assert.Equal(t, adapter.PageStats{Pages: 4, TotalPages: 4}, stats)
+		assert.Equal(t, []string{"1", "2", "3", "4"}, server.pages())
+	}
+}
+
+// ✅ Ограничение max pages обрезает список и не запрашивает лишних страниц
+func TestPaginate_MaxPages(t *testing.T) {
+	for _, prefetch := range []bool{false, true} {
+		server := newPagedServer(t, 10)
+
+		ids, stats := collect(t, server, adapter.PageOptions{Name: "items", MaxPages: 3, Prefetch: prefetch}, 0)
+		assert.Equal(t, []int{1, 2, 3}, ids)
+		assert.True(t, stats.Truncated)
+		assert.Equal(t, []string{"1", "2", "3"}, server.pages())
+	}
+}
+
+// ✅ Досрочная остановка: без предзагрузки следующая страница не запрашивается,
+// с предзагрузкой - не больше одной страницы вперёд
+func TestPaginate_EarlyStop(t *testing.T) {
+	server := newPagedServer(t, 10)
+	ids, stats := collect(t, server, adapter.PageOptions{Name: "items"}, 2)
+	assert.Equal(t, []int{1, 2}, ids)
+	assert.True(t, stats.Stopped)
+	assert.Equal(t, []string{"1", "2"}, server.pages())
+
+	server = newPagedServer(t, 10)
+	ids, stats = collect(t, server, adapter.PageOptions{Name: "items", Prefetch: true}, 2)
+	assert.Equal(t, []int{1, 2}, ids)
+	assert.True(t, stats.Stopped)
+	assert.LessOrEqual(t, len(server.pages()), 3)
+}
+
+// ✅ Keyset-пагинация идёт по курсору из ссылки rel="next", а не по номерам страниц
+func TestPaginate_Keyset(t *testing.T) {
+	for _, prefetch := range []bool{false, true} {
+		server := newPagedServer(t, 3)
+
+		ids, stats := collect(t, server, adapter.PageOptions{Name: "items", Prefetch: prefetch, Keyset: &adapter.Keyset{OrderBy: "id", Sort: "asc"}}, 0)
+		assert.Equal(t, []int{1, 2, 3}, ids)
+		assert.Equal(t, 3, stats.Pages)
+		assert.Equal(t, []string{"keyset:1", "keyset:2", "keyset:3"}, server.pages())
+	}
+}
+
+// ✅ Джобы проекта выгружаются keyset-пагинацией: первый запрос задаёт порядок, дальше - курсор из Link
+func TestGetProjectJobs_Keyset(t *testing.T) {
+	var queries []url.Values
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v4/projects/1/jobs", r.URL.Path)
+		query := r.URL.Query()
+		queries = append(queries, query)
+		if query.Get("cursor") == "" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/api/v4/projects/1/jobs?pagination=keyset&cursor=next-page>; rel="next"`, server.URL))
+			fmt.Fprint(w, `[{"id": 20, "status": "running"}]`)
+			return
+		}
+		fmt.Fprint(w, `[{"id": 10, "status": "pending"}]`)
+	}))
+	defer server.Close()
+
+	client := adapter.NewGitLabClient(&config.Config{
+		GitLabBaseURL:   server.URL,
+		GitLabAPIURL:    "/api/v4/projects/",
+		GitLabAPIToken:  "test-token",
+		GitLabProjectID: "1",
+		Pagination:      config.PaginationConfig{DisablePrefetch: true},
+	})
+
+	jobs, err := client.GetProjectJobs(context.Background(), "running", "pending")
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	assert.Equal(t, 20, jobs[0].ID)
+	assert.Equal(t, 10, jobs[1].ID)
+
+	require.Len(t, queries, 2)
+	assert.Equal(t, "keyset", queries[0].Get("pagination"))
+	assert.Equal(t, "id", queries[0].Get("order_by"))
+	assert.Equal(t, "desc", queries[0].Get("sort"))
+	assert.Equal(t, []string{"running", "pending"}, queries[0]["scope[]"])
+	assert.Empty(t, queries[0].Get("page"))
+	assert.Equal(t, "next-page", queries[1].Get("cursor"))
+}
+
+// ❌ Ошибка GitLab на любой странице прерывает обход с типизированной ошибкой
+func TestPaginate_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message": "403 Forbidden"}`)
+			return
+		}
+		w.Header().Set("X-Next-Page", "2")
+		fmt.Fprint(w, `[{"id": 1}]`)
+	}))
+	defer server.Close()
+
+	_, err := adapter.Paginate(context.Background(), resty.New(), server.URL+"/items", nil, adapter.PageOptions{Name: "items", Prefetch: true},
+		func(items []item) bool { return true })
+	assert.ErrorIs(t, err, apperror.ErrForbidden)
+}
+
+// ❌ fromSHA не найден за max_pages страниц - ошибка, а не неполный список коммитов
+func TestGetCommitsBetweenSHAs_Truncated(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+		fmt.Fprintf(w, `[{"id": "commit-%d", "message": "JIRA-%d"}]`, page, page)
+	}))
+	defer server.Close()
+
+	client := adapter.NewGitLabClient(&config.Config{
+		GitLabBaseURL:   server.URL,
+		GitLabAPIURL:    "/api/v4/projects/",
+		GitLabAPIToken:  "test-token",
+		GitLabProjectID: "1",
+		JiraProject:     "JIRA",
+		Pagination:      config.PaginationConfig{MaxPages: 3},
+	})
+
+	commits, err := client.GetCommitsBetweenSHAs(context.Background(), "main", "commit-10", "commit-1")
+	require.ErrorIs(t, err, apperror.ErrValidation)
+	assert.Contains(t, err.Error(), "max_pages")
+	assert.Nil(t, commits)
+}
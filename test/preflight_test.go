@@ -0,0 +1,272 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/dto"
+	"github.com/vkr-mtuci/gitlab-service/internal/handler"
+	"github.com/vkr-mtuci/gitlab-service/internal/service"
+)
+
+// ✅ Шаблоны веток: glob и регулярные выражения в слэшах
+func TestMatchRef(t *testing.T) {
+	patterns := []string{"main", "release/*", `/^v\d+\.\d+\.\d+$/`}
+
+	for _, ref := range []string{"main", "release/2.4", "v1.12.0"} {
+		assert.True(t, config.MatchRef(patterns, ref), ref)
+	}
+	for _, ref := range []string{"develop", "release/2.4/hotfix", "v1.12", "feature/main"} {
+		assert.False(t, config.MatchRef(patterns, ref), ref)
+	}
+	assert.False(t, config.MatchRef(nil, "main"))
+}
+
+// ❌ Джоба запускается только из статуса manual и из deploy-стадии
+func TestCheckJob(t *testing.T) {
+	svc := service.NewProjectService(nil, config.ProjectConfig{
+		DeployStages: []string{"deploy", "deploy-*"},
+	}, config.DefaultGitLabConcurrency)
+
+	job := func(status, stage, ref string) *adapter.TriggeredJob {
+		return &adapter.TriggeredJob{ID: 7, Name: "deploy-production", Status: status, Stage: stage, Pipeline: &adapter.Pipeline{ID: 100, Ref: ref}}
+	}
+
+	assert.NoError(t, svc.CheckJob(job("manual", "deploy", "main")))
+	assert.NoError(t, svc.CheckJob(job("manual", "deploy-eu", "release/2.4")))
+
+	tests := []struct {
+		name     string
+		job      *adapter.TriggeredJob
+		kind     error
+		contains string
+	}{
+		{"уже выполняется", job("running", "deploy", "main"), apperror.ErrConflict, "уже выполняется"},
+		{"уже выполнена", job("success", "deploy", "main"), apperror.ErrConflict, "не ожидает ручного запуска"},
+		{"пайплайн не дошёл", job("created", "deploy", "main"), apperror.ErrConflict, "ещё не готова"},
+		{"не deploy-стадия", job("manual", "test", "main"), apperror.ErrValidation, `в стадии "test"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := svc.CheckJob(tt.job)
+			require.ErrorIs(t, err, tt.kind)
+			assert.Contains(t, err.Error(), tt.contains)
+		})
+	}
+}
+
+// ❌ Джоба, которая не ждёт ручного запуска, не запускается в GitLab
+func TestTriggerDeployJob_PreflightRejects(t *testing.T) {
+	client := newPromotionClient()
+	svc := service.NewGitLabService(client)
+
+	_, _, err := svc.TriggerDeployJob(context.Background(), "11") // deploy-dev уже выполнена
+	require.ErrorIs(t, err, apperror.ErrConflict)
+	assert.Empty(t, client.played)
+
+	_, _, err = svc.TriggerDeployJob(context.Background(), "12")
+	require.NoError(t, err)
+	assert.Equal(t, []int{12}, client.played)
+}
+
+// refPolicyProject - production только из main и тегов версий, staging только из release/*,
+// остальные окружения - из веток проекта
+var refPolicyProject = config.ProjectConfig{
+	Name:        "payments",
+	AllowedRefs: []string{"main", "release/*", "develop"},
+	Environments: []config.EnvironmentConfig{
+		{Name: "production", AllowedRefs: []string{"main", `/^v\d+\.\d+\.\d+$/`}},
+		{Name: "staging", AllowedRefs: []string{"release/*"}},
+	},
+}
+
+// refClient - мок-клиент продвижений, в котором пайплайн 100 собран из ветки ref
+type refClient struct {
+	*promotionClient
+	ref     string
+	retried []int
+}
+
+func newRefClient(ref string) *refClient {
+	return &refClient{promotionClient: newPromotionClient(), ref: ref}
+}
+
+func (c *refClient) GetEnvironmentDetails(ctx context.Context, environmentID string) (*adapter.DeploymentInfo, error) {
+	deployment, err := c.promotionClient.GetEnvironmentDetails(ctx, environmentID)
+	if err == nil {
+		deployment.Ref = c.ref
+	}
+	return deployment, err
+}
+
+func (c *refClient) GetPipelineJobs(ctx context.Context, pipelineID string) ([]adapter.JobInfo, error) {
+	jobs := make([]adapter.JobInfo, 0, len(c.jobs))
+	for _, job := range c.jobs {
+		job.Ref = c.ref
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+func (c *refClient) GetJob(ctx context.Context, jobID string) (*adapter.TriggeredJob, error) {
+	job, err := c.promotionClient.GetJob(ctx, jobID)
+	if err == nil {
+		job.Pipeline.Ref = c.ref
+	}
+	return job, err
+}
+
+func (c *refClient) RetryJob(ctx context.Context, jobID string) (*adapter.TriggeredJob, error) {
+	id, _ := strconv.Atoi(jobID)
+	c.retried = append(c.retried, id)
+	return &adapter.TriggeredJob{ID: 1000 + id, Status: "pending"}, nil
+}
+
+// ✅ Ветки окружения заменяют ветки проекта; окружения без своих веток используют ветки проекта
+func TestCheckRef(t *testing.T) {
+	svc := service.NewProjectService(nil, refPolicyProject, config.DefaultGitLabConcurrency)
+
+	for _, tt := range []struct{ environment, ref string }{
+		{"production", "main"}, {"production", "v2.0.1"}, {"staging", "release/2.0"},
+		{"dev", "develop"}, {"dev", "release/2.0"},
+	} {
+		assert.NoError(t, svc.CheckRef(tt.environment, tt.ref), tt.environment+" ← "+tt.ref)
+	}
+	for _, tt := range []struct{ environment, ref string }{
+		{"production", "release/2.0"}, {"production", "v2.0"}, {"staging", "main"}, {"dev", "feature/x"}, {"", "feature/x"},
+	} {
+		err := svc.CheckRef(tt.environment, tt.ref)
+		require.ErrorIs(t, err, apperror.ErrValidation, tt.environment+" ← "+tt.ref)
+		assert.Contains(t, err.Error(), strconv.Quote(tt.ref))
+	}
+}
+
+// ❌ Джоба не запускается, если её сборка из ветки, запрещённой для окружения джобы
+func TestTriggerDeployJob_EnvironmentRefs(t *testing.T) {
+	client := newRefClient("release/2.0")
+	svc := service.NewProjectService(client, refPolicyProject, config.DefaultGitLabConcurrency)
+
+	_, _, err := svc.TriggerDeployJob(context.Background(), "13")
+	require.ErrorIs(t, err, apperror.ErrValidation)
+	assert.Contains(t, err.Error(), `"production"`)
+	assert.Empty(t, client.played)
+
+	_, _, err = svc.TriggerDeployJob(context.Background(), "12")
+	require.NoError(t, err)
+	assert.Equal(t, []int{12}, client.played)
+}
+
+// ❌ Продвижение и откат проверяют ветку сборки для целевого окружения
+func TestPromoteAndRedeploy_EnvironmentRefs(t *testing.T) {
+	client := newRefClient("release/2.0")
+	svc := service.NewProjectService(client, refPolicyProject, config.DefaultGitLabConcurrency)
+
+	_, err := svc.Promote(context.Background(), "staging", "production")
+	require.ErrorIs(t, err, apperror.ErrValidation)
+	assert.Empty(t, client.played)
+
+	_, err = svc.RedeployJob(context.Background(), 13)
+	require.ErrorIs(t, err, apperror.ErrValidation)
+	assert.Empty(t, client.retried)
+
+	client.ref = "main"
+	_, err = svc.RedeployJob(context.Background(), 13)
+	require.NoError(t, err)
+	assert.Equal(t, []int{13}, client.retried)
+}
+
+// detachedClient - мок-клиент продвижений, в котором у джобы 13 нет пайплайна,
+// а джоба 14 (deploy-sandbox) не относится ни к одному окружению
+type detachedClient struct {
+	*promotionClient
+}
+
+func newDetachedClient() *detachedClient {
+	client := &detachedClient{promotionClient: newPromotionClient()}
+	client.jobs = append(client.jobs, adapter.JobInfo{ID: 14, Stand: "deploy-sandbox", Stage: "deploy", Status: "manual"})
+	return client
+}
+
+func (c *detachedClient) GetJob(ctx context.Context, jobID string) (*adapter.TriggeredJob, error) {
+	job, err := c.promotionClient.GetJob(ctx, jobID)
+	if err == nil && job.ID == 13 {
+		job.Pipeline = nil
+	}
+	return job, err
+}
+
+// ❌ Если у окружений есть ограничения, джоба без окружения или без пайплайна не запускается
+func TestTriggerDeployJob_FailsClosed(t *testing.T) {
+	locked := config.ProjectConfig{
+		Environments: []config.EnvironmentConfig{{Name: "production", Locked: true}},
+	}
+	client := newDetachedClient()
+	svc := service.NewProjectService(client, locked, config.DefaultGitLabConcurrency)
+
+	_, _, err := svc.TriggerDeployJob(context.Background(), "14")
+	require.ErrorIs(t, err, apperror.ErrValidation)
+	assert.Contains(t, err.Error(), "не удалось определить окружение")
+	_, err = svc.PreviewJob(context.Background(), 14)
+	require.ErrorIs(t, err, apperror.ErrValidation)
+	assert.Empty(t, client.played)
+
+	client = newDetachedClient()
+	svc = service.NewProjectService(client, refPolicyProject, config.DefaultGitLabConcurrency)
+
+	_, _, err = svc.TriggerDeployJob(context.Background(), "14")
+	require.ErrorIs(t, err, apperror.ErrValidation)
+	_, _, err = svc.TriggerDeployJob(context.Background(), "13")
+	require.ErrorIs(t, err, apperror.ErrConflict)
+	assert.Contains(t, err.Error(), "нет пайплайна")
+	_, err = svc.RedeployJob(context.Background(), 14)
+	require.ErrorIs(t, err, apperror.ErrValidation)
+	assert.Empty(t, client.played)
+
+	// Без ограничений окружений джоба без окружения запускается как раньше
+	client = newDetachedClient()
+	svc = service.NewProjectService(client, config.ProjectConfig{}, config.DefaultGitLabConcurrency)
+	_, _, err = svc.TriggerDeployJob(context.Background(), "14")
+	require.NoError(t, err)
+	assert.Equal(t, []int{14}, client.played)
+}
+
+// ✅ В списке deploy-джоб видно окружение, разрешённые ветки и можно ли запустить джобу
+func TestListDeployJobs_AllowedRefs(t *testing.T) {
+	client := newRefClient("release/2.0")
+	services := service.NewStaticRegistry(service.NewProjectService(client, refPolicyProject, config.DefaultGitLabConcurrency))
+	h := handler.NewV1Handler(services, nil)
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Get("/api/v1/pipelines/:pipeline_id/deploy-jobs", h.ListDeployJobs)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/pipelines/100/deploy-jobs", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Data []dto.DeployJob `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Len(t, body.Data, 3)
+
+	jobs := map[string]dto.DeployJob{}
+	for _, job := range body.Data {
+		jobs[job.Environment] = job
+	}
+	assert.Equal(t, "release/2.0", jobs["dev"].Ref)
+	assert.True(t, jobs["dev"].RefAllowed)
+	assert.Equal(t, refPolicyProject.AllowedRefs, jobs["dev"].AllowedRefs)
+	assert.True(t, jobs["staging"].RefAllowed)
+	assert.False(t, jobs["production"].RefAllowed)
+	assert.Equal(t, []string{"main", `/^v\d+\.\d+\.\d+$/`}, jobs["production"].AllowedRefs)
+}
@@ -0,0 +1,241 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/dto"
+	"github.com/vkr-mtuci/gitlab-service/internal/handler"
+	"github.com/vkr-mtuci/gitlab-service/internal/operation"
+	"github.com/vkr-mtuci/gitlab-service/internal/release"
+	"github.com/vkr-mtuci/gitlab-service/internal/scheduler"
+	"github.com/vkr-mtuci/gitlab-service/internal/service"
+)
+
+// previewClient - мок-клиент продвижений, в котором джобы можно посмотреть, но не запустить:
+// джобы пайплайна принадлежат сборке pipeline, а токен имеет уровень доступа access
+type previewClient struct {
+	*promotionClient
+	pipeline adapter.Pipeline
+	access   int
+}
+
+func newPreviewClient() *previewClient {
+	return &previewClient{
+		promotionClient: newPromotionClient(),
+		pipeline:        adapter.Pipeline{ID: 101, SHA: "def456", Ref: "main"},
+		access:          adapter.AccessLevelDeveloper,
+	}
+}
+
+func (c *previewClient) GetJob(ctx context.Context, jobID string) (*adapter.TriggeredJob, error) {
+	id, _ := strconv.Atoi(jobID)
+	for _, job := range c.jobs {
+		if job.ID == id {
+			pipeline := c.pipeline
+			return &adapter.TriggeredJob{ID: job.ID, Name: job.Stand, Stage: job.Stage, Status: job.Status, Pipeline: &pipeline}, nil
+		}
+	}
+	return nil, apperror.NotFound("job not found")
+}
+
+func (c *previewClient) GetProject(ctx context.Context) (*adapter.Project, error) {
+	project := &adapter.Project{ID: 1, PathWithNamespace: "shop/payments"}
+	project.Permissions.ProjectAccess = &adapter.ProjectAccess{AccessLevel: c.access}
+	return project, nil
+}
+
+func (c *previewClient) GetBuildVersion(ctx context.Context, jobID string) (string, error) {
+	return "1.5.0", nil
+}
+
+func (c *previewClient) GetCommitsBetweenSHAs(ctx context.Context, ref, fromSHA, toSHA string) ([]adapter.CommitInfo, error) {
+	return []adapter.CommitInfo{{ID: toSHA, Message: "Оплата частями PAY-42", JiraKeys: []string{"PAY-42"}}}, nil
+}
+
+func (c *previewClient) GetLatestPipeline(ctx context.Context, ref string) (*adapter.Pipeline, error) {
+	pipeline := c.pipeline
+	return &pipeline, nil
+}
+
+// previewServices - реестр с проектом payments
+func previewServices(client *previewClient, project config.ProjectConfig) *service.Registry {
+	project.Name = "payments"
+	return service.NewStaticRegistry(service.NewProjectService(client, project, config.DefaultGitLabConcurrency))
+}
+
+// postDryRun отправляет POST с ?dry_run=true и возвращает статус и тело ответа
+func postDryRun(t *testing.T, app *fiber.App, path, body string, data any) int {
+	req := httptest.NewRequest(http.MethodPost, path+"?dry_run=true", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK && data != nil {
+		envelope := struct {
+			Data any `json:"data"`
+		}{Data: data}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&envelope))
+	}
+	return resp.StatusCode
+}
+
+// ✅ Пробный запуск джобы возвращает окружение, сборку и коммиты, но не запускает джобу
+func TestDryRun_PlayJobPreview(t *testing.T) {
+	client := newPreviewClient()
+	h := handler.NewV1Handler(previewServices(client, config.ProjectConfig{}), operation.NewManager(context.Background(), time.Millisecond))
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Post("/api/v1/jobs/:job_id/play", h.PlayJob)
+
+	var preview dto.DeployPreview
+	require.Equal(t, http.StatusOK, postDryRun(t, app, "/api/v1/jobs/13/play", "", &preview))
+
+	assert.True(t, preview.DryRun)
+	assert.Equal(t, "production", preview.Environment)
+	assert.Equal(t, 13, preview.Job.ID)
+	assert.Equal(t, 101, preview.PipelineID)
+	assert.Equal(t, "def456", preview.SHA)
+	assert.Equal(t, "1.5.0", preview.BuildVersion)
+	require.NotNil(t, preview.Current)
+	assert.Equal(t, "abc123", preview.Current.SHA)
+	require.Len(t, preview.Commits, 1)
+	assert.Equal(t, []string{"PAY-42"}, preview.JiraKeys)
+	assert.Empty(t, client.played)
+}
+
+// ✅ Устаревший маршрут запуска с ?dry_run=true тоже только проверяет джобу и не запускает её
+func TestDryRun_LegacyPlayJob(t *testing.T) {
+	client := newPreviewClient()
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Post("/jobs/:job_id/play", handler.NewGitLabHandler(previewServices(client, config.ProjectConfig{}), nil).TriggerDeployJob)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/jobs/13/play?dry_run=true", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var preview dto.DeployPreview
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&preview))
+	assert.True(t, preview.DryRun)
+	assert.Equal(t, "production", preview.Environment)
+	assert.Equal(t, 13, preview.Job.ID)
+	assert.Empty(t, client.played)
+}
+
+// ❌ Пробный запуск отклоняет джобу, которая не ждёт ручного запуска, токен без прав и замороженное окружение
+func TestDryRun_PlayJobChecks(t *testing.T) {
+	now := time.Now()
+	frozen := config.ProjectConfig{Environments: []config.EnvironmentConfig{
+		{Name: "production", FreezeWindows: []config.FreezeWindow{{From: now.Add(-time.Hour), To: now.Add(time.Hour), Reason: "релиз"}}},
+	}}
+
+	tests := []struct {
+		name    string
+		project config.ProjectConfig
+		access  int
+		jobID   string
+		status  int
+	}{
+		{"джоба уже выполнена", config.ProjectConfig{}, adapter.AccessLevelDeveloper, "11", http.StatusConflict},
+		{"нет прав на запуск", config.ProjectConfig{}, adapter.AccessLevelReporter, "13", http.StatusForbidden},
+		{"окружение заморожено", frozen, adapter.AccessLevelDeveloper, "13", http.StatusConflict},
+		{"джоба не найдена", config.ProjectConfig{}, adapter.AccessLevelDeveloper, "99", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newPreviewClient()
+			client.access = tt.access
+			h := handler.NewV1Handler(previewServices(client, tt.project), nil)
+			app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+			app.Post("/api/v1/jobs/:job_id/play", h.PlayJob)
+
+			assert.Equal(t, tt.status, postDryRun(t, app, "/api/v1/jobs/"+tt.jobID+"/play", "", nil))
+			assert.Empty(t, client.played)
+		})
+	}
+}
+
+// ✅ Пробное продвижение возвращает сборку исходного окружения и не записывается в историю
+func TestDryRun_PromotionPreview(t *testing.T) {
+	client := newPreviewClient()
+	client.pipeline = adapter.Pipeline{ID: 100, SHA: "abc123", Ref: "main"}
+	store := service.NewPromotionStore(service.PromotionHistoryLimit)
+	h := handler.NewPromotionHandler(previewServices(client, config.ProjectConfig{}), store)
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Post("/api/v1/promotions", h.Create)
+
+	var preview dto.DeployPreview
+	require.Equal(t, http.StatusOK, postDryRun(t, app, "/api/v1/promotions", `{"from_env":"staging","to_env":"production"}`, &preview))
+
+	assert.Equal(t, "production", preview.Environment)
+	assert.Equal(t, 13, preview.Job.ID)
+	assert.Equal(t, "1.4.0", preview.BuildVersion)
+	assert.Empty(t, preview.Commits)
+	assert.Empty(t, client.played)
+	assert.Empty(t, store.List(""))
+}
+
+// ✅ Пробное расписание проверяет заморозку на время run_at и не сохраняется
+func TestDryRun_SchedulePreview(t *testing.T) {
+	client := newPreviewClient()
+	runAt := time.Now().Add(2 * time.Hour).UTC().Truncate(time.Second)
+	project := config.ProjectConfig{Environments: []config.EnvironmentConfig{
+		{Name: "production", FreezeWindows: []config.FreezeWindow{{From: runAt.Add(-time.Minute), To: runAt.Add(time.Hour), Reason: "ночные работы"}}},
+	}}
+	store, err := scheduler.OpenStore(filepath.Join(t.TempDir(), "schedules.json"))
+	require.NoError(t, err)
+	sched := scheduler.New(store, previewServices(client, project), service.NewPromotionStore(service.PromotionHistoryLimit), time.Hour)
+	h := handler.NewScheduleHandler(sched)
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Post("/api/v1/schedules", h.Create)
+
+	schedule := func(environment string) string {
+		return `{"type":"deploy","environment":"` + environment + `","pipeline_id":100,"run_at":"` + runAt.Format(time.RFC3339) + `"}`
+	}
+	var preview dto.DeployPreview
+	require.Equal(t, http.StatusOK, postDryRun(t, app, "/api/v1/schedules", schedule("staging"), &preview))
+	assert.Equal(t, "staging", preview.Environment)
+	assert.Equal(t, 12, preview.Job.ID)
+
+	assert.Equal(t, http.StatusConflict, postDryRun(t, app, "/api/v1/schedules", schedule("production"), nil))
+	assert.Empty(t, sched.List(""))
+	assert.Empty(t, client.played)
+}
+
+// ❌ Ошибка пробного запуска плана релиза указывает шаг; план не запускается
+func TestDryRun_ReleasePlanPreview(t *testing.T) {
+	client := newPreviewClient()
+	releases := release.NewManager(context.Background(), previewServices(client, config.ProjectConfig{}), time.Millisecond)
+
+	previews, err := releases.Preview(context.Background(), release.Plan{Steps: []release.Step{
+		{ID: "api", Environment: "production", Ref: "main"},
+		{ID: "web", Environment: "staging", PipelineID: 100, DependsOn: []string{"api"}},
+	}})
+	require.NoError(t, err)
+	require.Len(t, previews, 2)
+	assert.Equal(t, "api", previews[0].StepID)
+	assert.Equal(t, 13, previews[0].Preview.Job.ID)
+	assert.Equal(t, 12, previews[1].Preview.Job.ID)
+
+	_, err = releases.Preview(context.Background(), release.Plan{Steps: []release.Step{
+		{ID: "api", Environment: "dev", PipelineID: 100},
+	}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `шаг "api"`)
+	assert.Empty(t, releases.List())
+	assert.Empty(t, client.played)
+}
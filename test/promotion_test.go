@@ -0,0 +1,273 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/apperror"
+	"github.com/vkr-mtuci/gitlab-service/internal/credentials"
+	"github.com/vkr-mtuci/gitlab-service/internal/dto"
+	"github.com/vkr-mtuci/gitlab-service/internal/handler"
+	"github.com/vkr-mtuci/gitlab-service/internal/service"
+)
+
+// promotionClient - мок-клиент: окружения dev, staging, production развёрнуты из пайплайна 100
+type promotionClient struct {
+	adapter.GitLabClientInterface
+	statuses map[string]string // Окружение -> статус деплоя
+	jobs     []adapter.JobInfo // Deploy-джобы пайплайна 100
+	played   []int             // ID запущенных джоб
+	sudo     string            // Пользователь Sudo последнего запуска
+}
+
+// promotionEnvironments - окружения проекта в порядке продвижения
+var promotionEnvironments = []string{"dev", "staging", "production"}
+
+// newPromotionClient создаёт клиент, в котором все деплои успешны
+func newPromotionClient() *promotionClient {
+	return &promotionClient{
+		statuses: map[string]string{"dev": "success", "staging": "success", "production": "success"},
+		jobs: []adapter.JobInfo{
+			{ID: 11, Stand: "deploy-dev", Stage: "deploy", Status: "success"},
+			{ID: 12, Stand: "deploy-staging", Stage: "deploy", Status: "manual"},
+			{ID: 13, Stand: "deploy-production", Stage: "deploy", Status: "manual"},
+		},
+	}
+}
+
+func (c *promotionClient) GetEnvironments(ctx context.Context) ([]adapter.Environment, error) {
+	environments := make([]adapter.Environment, 0, len(promotionEnvironments))
+	for i, name := range promotionEnvironments {
+		environments = append(environments, adapter.Environment{ID: i + 1, Name: name, State: "available"})
+	}
+	return environments, nil
+}
+
+func (c *promotionClient) GetEnvironmentDetails(ctx context.Context, environmentID string) (*adapter.DeploymentInfo, error) {
+	id, _ := strconv.Atoi(environmentID)
+	name := promotionEnvironments[id-1]
+	return &adapter.DeploymentInfo{
+		EnvironmentName: name,
+		Ref:             "main",
+		SHA:             "abc123",
+		PipelineID:      100,
+		JobID:           10 + id,
+		DeployStatus:    c.statuses[name],
+		BuildVersion:    "1.4.0",
+	}, nil
+}
+
+func (c *promotionClient) GetPipelineJobs(ctx context.Context, pipelineID string) ([]adapter.JobInfo, error) {
+	return c.jobs, nil
+}
+
+func (c *promotionClient) GetJob(ctx context.Context, jobID string) (*adapter.TriggeredJob, error) {
+	id, _ := strconv.Atoi(jobID)
+	for _, job := range c.jobs {
+		if job.ID == id {
+			return &adapter.TriggeredJob{ID: job.ID, Name: job.Stand, Stage: job.Stage, Status: job.Status,
+				Pipeline: &adapter.Pipeline{ID: 100, SHA: "abc123", Ref: "main"}}, nil
+		}
+	}
+	return nil, apperror.NotFound("job not found")
+}
+
+func (c *promotionClient) TriggerDeployJob(ctx context.Context, jobID string) (*adapter.TriggeredJob, error) {
+	id, _ := strconv.Atoi(jobID)
+	c.played = append(c.played, id)
+	if actor, ok := credentials.ActorFrom(ctx); ok {
+		c.sudo = actor.Sudo
+	}
+	return &adapter.TriggeredJob{ID: id, Name: "deploy", Status: "pending", CreatedAt: time.Now()}, nil
+}
+
+// promotionApp собирает приложение с обработчиком продвижений для проекта payments
+func promotionApp(client *promotionClient, project config.ProjectConfig) (*fiber.App, *service.PromotionStore) {
+	project.Name = "payments"
+	store := service.NewPromotionStore(service.PromotionHistoryLimit)
+	services := service.NewStaticRegistry(service.NewProjectService(client, project, config.DefaultGitLabConcurrency))
+	h := handler.NewPromotionHandler(services, store)
+
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Post("/api/v1/promotions", h.Create)
+	app.Get("/api/v1/promotions", h.List)
+	app.Get("/api/v1/promotions/:id", h.Get)
+	return app, store
+}
+
+// promote отправляет запрос на продвижение и возвращает статус и тело ответа
+func promote(t *testing.T, app *fiber.App, from, to string) (int, dto.Promotion) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/promotions",
+		strings.NewReader(`{"from_env":"`+from+`","to_env":"`+to+`"}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var body struct {
+		Data dto.Promotion `json:"data"`
+	}
+	if resp.StatusCode == http.StatusCreated {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	}
+	return resp.StatusCode, body.Data
+}
+
+// ✅ Сборка из dev продвигается в staging: запускается deploy-джоба staging из того же пайплайна
+func TestPromotion_TriggersNextEnvironmentJob(t *testing.T) {
+	client := newPromotionClient()
+	app, store := promotionApp(client, config.ProjectConfig{PromotionPath: promotionEnvironments})
+
+	status, promotion := promote(t, app, "dev", "staging")
+	require.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, []int{12}, client.played)
+	assert.Equal(t, service.PromotionTriggered, promotion.Status)
+	assert.Equal(t, "payments", promotion.Project)
+	assert.Equal(t, 100, promotion.PipelineID)
+	assert.Equal(t, "abc123", promotion.SHA)
+	assert.Equal(t, "1.4.0", promotion.BuildVersion)
+	assert.Equal(t, "deploy-staging", promotion.JobName)
+	assert.Equal(t, "pending", promotion.JobStatus)
+
+	// Запись доступна по ID и в списке
+	saved, err := store.Get(promotion.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "staging", saved.ToEnv)
+	assert.Len(t, store.List("payments"), 1)
+	assert.Empty(t, store.List("billing"))
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/promotions/"+promotion.ID, nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+// ❌ Через стадии пути продвижения перескакивать нельзя
+func TestPromotion_SkippingStageRejected(t *testing.T) {
+	client := newPromotionClient()
+	app, _ := promotionApp(client, config.ProjectConfig{PromotionPath: promotionEnvironments})
+
+	for _, pair := range [][2]string{{"dev", "production"}, {"production", "staging"}, {"dev", "qa"}, {"dev", "dev"}} {
+		status, _ := promote(t, app, pair[0], pair[1])
+		assert.Equal(t, http.StatusUnprocessableEntity, status, pair)
+	}
+	assert.Empty(t, client.played)
+
+	// Без пути продвижения допустимо любое направление
+	app, _ = promotionApp(client, config.ProjectConfig{})
+	status, _ := promote(t, app, "dev", "production")
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, []int{13}, client.played)
+}
+
+// ❌ Неуспешный деплой в исходное окружение не продвигается
+func TestPromotion_SourceDeployMustSucceed(t *testing.T) {
+	client := newPromotionClient()
+	client.statuses["dev"] = "failed"
+	app, _ := promotionApp(client, config.ProjectConfig{})
+
+	status, _ := promote(t, app, "dev", "staging")
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Empty(t, client.played)
+}
+
+// ❌ Заблокированное или замороженное целевое окружение не принимает продвижение
+func TestPromotion_TargetLockedOrFrozen(t *testing.T) {
+	client := newPromotionClient()
+	now := time.Now()
+	app, _ := promotionApp(client, config.ProjectConfig{Environments: []config.EnvironmentConfig{
+		{Name: "staging", Locked: true, LockReason: "нагрузочное тестирование"},
+		{Name: "production", FreezeWindows: []config.FreezeWindow{{From: now.Add(-time.Hour), To: now.Add(time.Hour), Reason: "релиз"}}},
+	}})
+
+	status, _ := promote(t, app, "dev", "staging")
+	assert.Equal(t, http.StatusConflict, status)
+	status, _ = promote(t, app, "staging", "production")
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Empty(t, client.played)
+}
+
+// ❌ Нет deploy-джобы целевого окружения - 404; шаблон deploy_job уточняет поиск
+func TestPromotion_DeployJobLookup(t *testing.T) {
+	client := newPromotionClient()
+	client.jobs = client.jobs[:2]
+	app, _ := promotionApp(client, config.ProjectConfig{})
+
+	status, _ := promote(t, app, "staging", "production")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	// Имя окружения входит в имена нескольких джоб - нужен шаблон deploy_job
+	client = newPromotionClient()
+	client.jobs = append(client.jobs, adapter.JobInfo{ID: 14, Stand: "deploy-production-db", Stage: "deploy", Status: "manual"})
+	app, _ = promotionApp(client, config.ProjectConfig{})
+	status, _ = promote(t, app, "staging", "production")
+	assert.Equal(t, http.StatusConflict, status)
+
+	app, _ = promotionApp(client, config.ProjectConfig{Environments: []config.EnvironmentConfig{
+		{Name: "production", DeployJob: "deploy-production-db"},
+	}})
+	status, promotion := promote(t, app, "staging", "production")
+	require.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, 14, promotion.JobID)
+}
+
+// ✅ Имя окружения ищется в имени джобы как отдельное слово: prod не совпадает с deploy-preprod
+func TestPromotion_DeployJobWordMatch(t *testing.T) {
+	svc := service.NewProjectService(newPromotionClient(), config.ProjectConfig{Name: "payments"}, config.DefaultGitLabConcurrency)
+	jobs := []adapter.JobInfo{
+		{ID: 21, Stand: "deploy-preprod", Stage: "deploy", Status: "manual"},
+		{ID: 22, Stand: "deploy-prod", Stage: "deploy", Status: "manual"},
+		{ID: 23, Stand: "Deploy EU_Staging", Stage: "deploy", Status: "manual"},
+	}
+
+	job, err := svc.FindDeployJob(jobs, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, 22, job.ID)
+
+	job, err = svc.FindDeployJob(jobs, "preprod")
+	require.NoError(t, err)
+	assert.Equal(t, 21, job.ID)
+
+	job, err = svc.FindDeployJob(jobs, "eu-staging")
+	require.NoError(t, err)
+	assert.Equal(t, 23, job.ID)
+
+	_, err = svc.FindDeployJob(jobs[:1], "prod")
+	assert.ErrorIs(t, err, apperror.ErrNotFound)
+}
+
+// ⛔ Запуск джобы заблокированного окружения по её ID (v1 и устаревший маршрут) - 409
+func TestPlayJob_LockedEnvironment(t *testing.T) {
+	client := newPromotionClient()
+	services := service.NewStaticRegistry(service.NewProjectService(client, config.ProjectConfig{
+		Name:         "payments",
+		Environments: []config.EnvironmentConfig{{Name: "production", Locked: true, LockReason: "инцидент"}},
+	}, config.DefaultGitLabConcurrency))
+
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Post("/api/v1/jobs/:job_id/play", handler.NewV1Handler(services, nil).PlayJob)
+	app.Post("/jobs/:job_id/play", handler.NewGitLabHandler(services, nil).TriggerDeployJob)
+
+	for _, path := range []string{"/api/v1/jobs/13/play", "/jobs/13/play", "/api/v1/jobs/13/play?dry_run=true"} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodPost, path, nil))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode, path)
+	}
+	assert.Empty(t, client.played)
+
+	// Джоба незаблокированного окружения запускается
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/api/v1/jobs/12/play", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []int{12}, client.played)
+}
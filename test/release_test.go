@@ -0,0 +1,309 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/credentials"
+	"github.com/vkr-mtuci/gitlab-service/internal/handler"
+	"github.com/vkr-mtuci/gitlab-service/internal/release"
+	"github.com/vkr-mtuci/gitlab-service/internal/service"
+)
+
+// releaseClient - мок-клиент: окружения db, api, web с deploy-джобами 11, 12, 13 в пайплайне сборки
+type releaseClient struct {
+	adapter.GitLabClientInterface
+	mu       sync.Mutex
+	outcomes map[int]string // Итоговый статус джобы (по умолчанию success)
+	played   []int
+	retried  []int
+	started  map[int]bool // Запущенные джобы (остальные ждут ручного запуска)
+	sudo     []string     // Пользователи Sudo запусков и перезапусков
+}
+
+// releaseEnvironments - окружения по ID (с 1)
+var releaseEnvironments = []string{"db", "api", "web"}
+
+func (c *releaseClient) GetEnvironments(ctx context.Context) ([]adapter.Environment, error) {
+	var environments []adapter.Environment
+	for i, name := range releaseEnvironments {
+		environments = append(environments, adapter.Environment{ID: i + 1, Name: name})
+	}
+	return environments, nil
+}
+
+// GetEnvironmentDetails - предыдущий деплой окружения с ID N выполнен джобой 100+N
+func (c *releaseClient) GetEnvironmentDetails(ctx context.Context, environmentID string) (*adapter.DeploymentInfo, error) {
+	id, _ := strconv.Atoi(environmentID)
+	return &adapter.DeploymentInfo{EnvironmentName: releaseEnvironments[id-1], SHA: "old", JobID: 100 + id, DeployStatus: "success"}, nil
+}
+
+func (c *releaseClient) GetPipelineJobs(ctx context.Context, pipelineID string) ([]adapter.JobInfo, error) {
+	return []adapter.JobInfo{
+		{ID: 11, Stand: "deploy-db", Stage: "deploy", Status: "manual"},
+		{ID: 12, Stand: "deploy-api", Stage: "deploy", Status: "manual"},
+		{ID: 13, Stand: "deploy-web", Stage: "deploy", Status: "manual"},
+	}, nil
+}
+
+func (c *releaseClient) GetLatestPipeline(ctx context.Context, ref string) (*adapter.Pipeline, error) {
+	return &adapter.Pipeline{ID: 500, Ref: ref}, nil
+}
+
+func (c *releaseClient) TriggerDeployJob(ctx context.Context, jobID string) (*adapter.TriggeredJob, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id, _ := strconv.Atoi(jobID)
+	c.played = append(c.played, id)
+	c.recordSudo(ctx)
+	c.start(id)
+	return &adapter.TriggeredJob{ID: id, Status: "pending"}, nil
+}
+
+func (c *releaseClient) GetJob(ctx context.Context, jobID string) (*adapter.TriggeredJob, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id, _ := strconv.Atoi(jobID)
+	if !c.started[id] {
+		return &adapter.TriggeredJob{ID: id, Stage: "deploy", Status: "manual"}, nil
+	}
+	if status, ok := c.outcomes[id]; ok {
+		return &adapter.TriggeredJob{ID: id, Status: status}, nil
+	}
+	return &adapter.TriggeredJob{ID: id, Status: "success"}, nil
+}
+
+func (c *releaseClient) RetryJob(ctx context.Context, jobID string) (*adapter.TriggeredJob, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id, _ := strconv.Atoi(jobID)
+	c.retried = append(c.retried, id)
+	c.recordSudo(ctx)
+	c.start(1000 + id)
+	return &adapter.TriggeredJob{ID: 1000 + id, Status: "pending"}, nil
+}
+
+// start отмечает джобу запущенной (вызывается под блокировкой)
+func (c *releaseClient) start(id int) {
+	if c.started == nil {
+		c.started = map[int]bool{}
+	}
+	c.started[id] = true
+}
+
+// recordSudo запоминает пользователя Sudo запроса (вызывается под блокировкой)
+func (c *releaseClient) recordSudo(ctx context.Context) {
+	actor, _ := credentials.ActorFrom(ctx)
+	c.sudo = append(c.sudo, actor.Sudo)
+}
+
+// setOutcome задаёт итоговый статус джобы
+func (c *releaseClient) setOutcome(id int, status string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.outcomes[id] = status
+}
+
+// playedJobs возвращает копию списка запущенных джоб
+func (c *releaseClient) playedJobs() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]int(nil), c.played...)
+}
+
+// runPlan запускает план и ждёт его завершения
+func runPlan(t *testing.T, client *releaseClient, plan release.Plan) release.Plan {
+	manager := release.NewManager(context.Background(), service.NewStaticRegistry(service.NewGitLabService(client)), time.Millisecond)
+	created, err := manager.Create(plan, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, release.PlanRunning, created.Status)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	final, err := manager.Wait(ctx, created.ID)
+	require.NoError(t, err)
+	return final
+}
+
+// stepStatuses возвращает статусы шагов по ID
+func stepStatuses(plan release.Plan) map[string]string {
+	statuses := map[string]string{}
+	for _, step := range plan.Steps {
+		statuses[step.ID] = step.Status
+	}
+	return statuses
+}
+
+// ✅ Шаги выполняются в порядке зависимостей, каждый - после успеха предыдущего
+func TestReleasePlan_RunsInDependencyOrder(t *testing.T) {
+	client := &releaseClient{}
+	plan := runPlan(t, client, release.Plan{Steps: []release.Step{
+		{ID: "web", Environment: "web", PipelineID: 7, DependsOn: []string{"api"}},
+		{ID: "api", Environment: "api", Ref: "v1.4.0", DependsOn: []string{"db"}},
+		{ID: "db", Environment: "db", PipelineID: 7},
+	}})
+
+	assert.Equal(t, release.PlanSucceeded, plan.Status)
+	assert.Equal(t, release.PolicyStop, plan.Policy)
+	assert.Equal(t, []int{11, 12, 13}, client.playedJobs())
+	for _, step := range plan.Steps {
+		assert.Equal(t, release.StepSucceeded, step.Status, step.ID)
+		assert.Equal(t, "success", step.JobStatus, step.ID)
+		assert.False(t, step.FinishedAt.IsZero(), step.ID)
+	}
+	assert.Equal(t, 101, plan.Steps[2].PreviousJobID)
+}
+
+// ❌ stop: после неудачного шага новые шаги не запускаются
+func TestReleasePlan_StopPolicy(t *testing.T) {
+	client := &releaseClient{outcomes: map[int]string{11: "failed"}}
+	plan := runPlan(t, client, release.Plan{Policy: release.PolicyStop, Steps: []release.Step{
+		{ID: "db", Environment: "db", PipelineID: 7},
+		{ID: "api", Environment: "api", PipelineID: 7, DependsOn: []string{"db"}},
+	}})
+
+	assert.Equal(t, release.PlanFailed, plan.Status)
+	assert.Equal(t, map[string]string{"db": release.StepFailed, "api": release.StepSkipped}, stepStatuses(plan))
+	assert.Contains(t, plan.Steps[0].Error, "failed")
+	assert.Equal(t, []int{11}, client.playedJobs())
+}
+
+// ⚠️ continue: независимые шаги выполняются, зависящие от неудачного - пропускаются
+func TestReleasePlan_ContinuePolicy(t *testing.T) {
+	client := &releaseClient{outcomes: map[int]string{11: "failed"}}
+	plan := runPlan(t, client, release.Plan{Policy: release.PolicyContinue, Steps: []release.Step{
+		{ID: "db", Environment: "db", PipelineID: 7},
+		{ID: "api", Environment: "api", PipelineID: 7, DependsOn: []string{"db"}},
+		{ID: "web", Environment: "web", PipelineID: 7},
+	}})
+
+	assert.Equal(t, release.PlanFailed, plan.Status)
+	assert.Equal(t, map[string]string{"db": release.StepFailed, "api": release.StepSkipped, "web": release.StepSucceeded}, stepStatuses(plan))
+	assert.ElementsMatch(t, []int{11, 13}, client.playedJobs())
+}
+
+// ↩️ rollback: выполненные шаги возвращаются к предыдущим деплоям
+func TestReleasePlan_RollbackPolicy(t *testing.T) {
+	client := &releaseClient{outcomes: map[int]string{13: "failed"}}
+	plan := runPlan(t, client, release.Plan{Policy: release.PolicyRollback, Steps: []release.Step{
+		{ID: "db", Environment: "db", PipelineID: 7},
+		{ID: "api", Environment: "api", PipelineID: 7, DependsOn: []string{"db"}},
+		{ID: "web", Environment: "web", PipelineID: 7, DependsOn: []string{"api"}},
+	}})
+
+	assert.Equal(t, release.PlanRolledBack, plan.Status)
+	assert.Equal(t, map[string]string{"db": release.StepRolledBack, "api": release.StepRolledBack, "web": release.StepFailed}, stepStatuses(plan))
+	// Откат в порядке, обратном выполнению: сначала api, затем db
+	assert.Equal(t, []int{102, 101}, client.retried)
+	assert.Equal(t, 1101, plan.Steps[0].RollbackJobID)
+}
+
+// 👤 Джобы плана и отката запускаются от имени создателя плана
+func TestReleasePlan_RunsAsCreator(t *testing.T) {
+	client := &releaseClient{outcomes: map[int]string{12: "failed"}}
+	plan := runPlan(t, client, release.Plan{Policy: release.PolicyRollback, RunAs: "ivanov", Steps: []release.Step{
+		{ID: "db", Environment: "db", PipelineID: 7},
+		{ID: "api", Environment: "api", PipelineID: 7, DependsOn: []string{"db"}},
+	}})
+
+	assert.Equal(t, release.PlanRolledBack, plan.Status)
+	assert.Equal(t, []int{101}, client.retried)
+	assert.Equal(t, []string{"ivanov", "ivanov", "ivanov"}, client.sudo)
+}
+
+// 🛑 Остановка: Drain ждёт выполняющиеся планы, а прерванный план не откатывается
+func TestReleasePlan_DrainOnShutdown(t *testing.T) {
+	ctx, interrupt := context.WithCancel(context.Background())
+	defer interrupt()
+
+	client := &releaseClient{outcomes: map[int]string{11: "running", 12: "running"}}
+	manager := release.NewManager(ctx, service.NewStaticRegistry(service.NewGitLabService(client)), time.Millisecond)
+	created, err := manager.Create(release.Plan{Policy: release.PolicyRollback, Steps: []release.Step{
+		{ID: "db", Environment: "db", PipelineID: 7},
+		{ID: "api", Environment: "api", PipelineID: 7},
+	}}, time.Now())
+	require.NoError(t, err)
+
+	// Джобы ещё выполняются: Drain не дожидается плана
+	drainCtx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, manager.Drain(drainCtx), context.DeadlineExceeded)
+
+	// Одна джоба завершилась, вторую прерывает остановка сервиса
+	client.setOutcome(11, "success")
+	require.Eventually(t, func() bool {
+		plan, _ := manager.Get(created.ID)
+		return stepStatuses(plan)["db"] == release.StepSucceeded
+	}, 5*time.Second, time.Millisecond)
+	interrupt()
+
+	drainCtx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, manager.Drain(drainCtx))
+
+	plan, err := manager.Get(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, release.PlanFailed, plan.Status)
+	assert.Equal(t, map[string]string{"db": release.StepSucceeded, "api": release.StepFailed}, stepStatuses(plan))
+	assert.Contains(t, plan.Steps[1].Error, "остановкой сервиса")
+	assert.Empty(t, client.retried)
+}
+
+// ❌ Некорректный план отклоняется с 422 до запуска
+func TestReleaseHandler_RejectsInvalidPlans(t *testing.T) {
+	client := &releaseClient{}
+	manager := release.NewManager(context.Background(), service.NewStaticRegistry(service.NewGitLabService(client)), time.Millisecond)
+	h := handler.NewReleaseHandler(manager)
+
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Post("/api/v1/release-plans", h.Create)
+	app.Get("/api/v1/release-plans/:id", h.Get)
+
+	post := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/release-plans", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	invalid := []string{
+		`{"steps":[]}`,
+		`{"steps":[{"id":"a","environment":"db"}]}`,
+		`{"steps":[{"id":"a","environment":"db","pipeline_id":1,"depends_on":["b"]},{"id":"b","environment":"api","pipeline_id":1,"depends_on":["a"]}]}`,
+		`{"steps":[{"id":"a","environment":"db","pipeline_id":1,"depends_on":["missing"]}]}`,
+		`{"steps":[{"id":"a","environment":"db","pipeline_id":1},{"id":"a","environment":"api","pipeline_id":1}]}`,
+		`{"steps":[{"id":"a","project":"unknown","environment":"db","pipeline_id":1}]}`,
+		`{"failure_policy":"retry","steps":[{"id":"a","environment":"db","pipeline_id":1}]}`,
+	}
+	for _, body := range invalid {
+		assert.Equal(t, http.StatusUnprocessableEntity, post(body), body)
+	}
+	assert.Equal(t, http.StatusBadRequest, post(`{"step_timeout":"soon","steps":[{"id":"a","environment":"db","pipeline_id":1}]}`))
+	assert.Empty(t, client.playedJobs())
+
+	assert.Equal(t, http.StatusAccepted, post(`{"name":"release-42","step_timeout":"1m","steps":[{"id":"a","environment":"db","pipeline_id":1}]}`))
+	plans := manager.List()
+	require.Len(t, plans, 1)
+	assert.Equal(t, time.Minute, plans[0].StepTimeout)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/release-plans/"+plans[0].ID, nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, err = manager.Wait(context.Background(), plans[0].ID)
+	require.NoError(t, err)
+}
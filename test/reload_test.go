@@ -0,0 +1,133 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/auth"
+	"github.com/vkr-mtuci/gitlab-service/internal/handler"
+	"github.com/vkr-mtuci/gitlab-service/internal/health"
+)
+
+const reloadBaseConfig = `
+gitlab:
+  base_url: https://gitlab.example.com
+  api_url: /api/v4/projects/
+  token: file-token
+integrations:
+  jira:
+    project: JIRA
+projects:
+  - name: backend
+    id: "101"
+`
+
+// ✅ Корректная конфигурация применяется, подписчики получают новую версию
+func TestReloader_AppliesValidConfig(t *testing.T) {
+	clearConfigEnv(t)
+	path := writeConfigFile(t, "config.yaml", reloadBaseConfig)
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	store := config.NewStore(cfg)
+	reloader := config.NewReloader(store, path)
+
+	var notified *config.Config
+	reloader.OnReload(func(next *config.Config) { notified = next })
+
+	require.NoError(t, os.WriteFile(path, []byte(reloadBaseConfig+`
+  - name: frontend
+    id: "102"
+server:
+  port: "9090"
+`), 0o600))
+
+	result := reloader.Reload(config.TriggerSignal)
+
+	assert.Equal(t, config.ReloadApplied, result.Status)
+	assert.Equal(t, []string{"backend", "frontend"}, result.Projects)
+	assert.Equal(t, []string{"server.port"}, result.RestartRequired)
+	assert.Same(t, store.Current(), notified)
+	assert.Len(t, store.Current().ProjectList(), 2)
+}
+
+// ❌ Некорректная конфигурация отклоняется, действует прежняя
+func TestReloader_RejectsInvalidConfig(t *testing.T) {
+	clearConfigEnv(t)
+	path := writeConfigFile(t, "config.yaml", reloadBaseConfig)
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	store := config.NewStore(cfg)
+	reloader := config.NewReloader(store, path)
+
+	called := false
+	reloader.OnReload(func(*config.Config) { called = true })
+
+	require.NoError(t, os.WriteFile(path, []byte(reloadBaseConfig+`
+  - name: backend
+`), 0o600))
+
+	result := reloader.Reload(config.TriggerFile)
+
+	assert.Equal(t, config.ReloadRejected, result.Status)
+	assert.NotEmpty(t, result.Problems)
+	assert.False(t, called)
+	assert.Same(t, cfg, store.Current())
+
+	history := reloader.History()
+	require.Len(t, history, 1)
+	assert.Equal(t, config.TriggerFile, history[0].Trigger)
+}
+
+// ❌ Административные эндпоинты доступны только клиентам из auth.admins
+func TestAdminEndpoints_RequireAdmin(t *testing.T) {
+	authConfig := config.AuthConfig{
+		Tokens: []config.APIToken{{Name: "ops", Token: "ops-token"}, {Name: "ci", Token: "ci-token"}},
+		Admins: []string{"ops"},
+	}
+	settings := func() config.AuthConfig { return authConfig }
+
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Use(auth.Middleware(settings, handler.ErrorHandler))
+	app.Get("/admin/config", auth.RequireAdmin(settings, handler.ErrorHandler), func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	})
+
+	status := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/admin/config", nil)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusOK, status("ops-token"))
+	assert.Equal(t, http.StatusForbidden, status("ci-token"))
+}
+
+// ✅ Новый набор проверок готовности заменяет прежний целиком
+func TestReadinessRegistry_Replace(t *testing.T) {
+	checks := health.NewRegistry(time.Second, time.Hour)
+	checks.Register("old", func(ctx context.Context) error { return nil })
+	require.Len(t, checks.Run(context.Background()).Checks, 1)
+
+	next := health.NewRegistry(time.Second, time.Hour)
+	next.Register("gitlab_token", func(ctx context.Context) error { return nil })
+	next.Register("gitlab_project", func(ctx context.Context) error { return errors.New("проект недоступен") })
+	checks.Replace(next)
+
+	report := checks.Run(context.Background()) // Кэш прежнего результата сброшен
+	assert.False(t, report.Healthy())
+	require.Len(t, report.Checks, 2)
+	assert.Equal(t, "gitlab_token", report.Checks[0].Name)
+}
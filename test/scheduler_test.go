@@ -0,0 +1,245 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/auth"
+	"github.com/vkr-mtuci/gitlab-service/internal/handler"
+	"github.com/vkr-mtuci/gitlab-service/internal/scheduler"
+	"github.com/vkr-mtuci/gitlab-service/internal/service"
+)
+
+// newTestScheduler создаёт планировщик проекта с расписаниями в файле path
+func newTestScheduler(t *testing.T, client *promotionClient, project config.ProjectConfig, path string) (*scheduler.Scheduler, *service.PromotionStore) {
+	store, err := scheduler.OpenStore(path)
+	require.NoError(t, err)
+
+	promotions := service.NewPromotionStore(service.PromotionHistoryLimit)
+	services := service.NewStaticRegistry(service.NewProjectService(client, project, config.DefaultGitLabConcurrency))
+	return scheduler.New(store, services, promotions, time.Hour), promotions
+}
+
+// ✅ Деплой выполняется в срок, а результат сохраняется в файл и переживает перезапуск
+func TestScheduler_RunsDueDeployAndSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "schedules.json")
+	client := newPromotionClient()
+	sched, _ := newTestScheduler(t, client, config.ProjectConfig{}, path)
+
+	now := time.Now()
+	created, err := sched.Create(scheduler.Schedule{
+		Type: scheduler.TypeDeploy, Environment: "production", PipelineID: 100, RunAt: now.Add(time.Hour), CreatedBy: "alice",
+	}, now)
+	require.NoError(t, err)
+	assert.Equal(t, scheduler.StatusScheduled, created.Status)
+
+	// Время ещё не наступило
+	sched.RunDue(context.Background(), now.Add(30*time.Minute))
+	assert.Empty(t, client.played)
+
+	// Перезапуск: расписание читается из файла и выполняется новым планировщиком
+	sched, _ = newTestScheduler(t, client, config.ProjectConfig{}, path)
+	sched.RunDue(context.Background(), now.Add(time.Hour+time.Second))
+	assert.Equal(t, []int{13}, client.played)
+
+	sched, _ = newTestScheduler(t, client, config.ProjectConfig{}, path)
+	done, err := sched.Get(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, scheduler.StatusTriggered, done.Status)
+	assert.Equal(t, 13, done.TriggeredJobID)
+	assert.Equal(t, "pending", done.JobStatus)
+	assert.False(t, done.ExecutedAt.IsZero())
+
+	// Повторно не запускается
+	sched.RunDue(context.Background(), now.Add(2*time.Hour))
+	assert.Len(t, client.played, 1)
+}
+
+// ⛔ Блокировка окружения проверяется в момент запуска, а не при создании расписания
+func TestScheduler_BlockedAtExecutionTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedules.json")
+	client := newPromotionClient()
+	now := time.Now()
+
+	sched, _ := newTestScheduler(t, client, config.ProjectConfig{}, path)
+	created, err := sched.Create(scheduler.Schedule{Type: scheduler.TypeDeploy, Environment: "production", JobID: 13, RunAt: now.Add(time.Minute)}, now)
+	require.NoError(t, err)
+
+	// К моменту запуска на production действует заморозка
+	sched, _ = newTestScheduler(t, client, config.ProjectConfig{Environments: []config.EnvironmentConfig{
+		{Name: "production", FreezeWindows: []config.FreezeWindow{{From: now, To: now.Add(24 * time.Hour), Reason: "релиз"}}},
+	}}, path)
+	sched.RunDue(context.Background(), now.Add(time.Minute))
+
+	blocked, err := sched.Get(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, scheduler.StatusBlocked, blocked.Status)
+	assert.Contains(t, blocked.Error, "релиз")
+	assert.Empty(t, client.played)
+}
+
+// ❌ Явно заданная джоба другого окружения не запускается: блокировки проверялись бы не для того окружения
+func TestScheduler_JobFromAnotherEnvironment(t *testing.T) {
+	client := newPromotionClient()
+	sched, _ := newTestScheduler(t, client, config.ProjectConfig{}, filepath.Join(t.TempDir(), "schedules.json"))
+
+	now := time.Now()
+	created, err := sched.Create(scheduler.Schedule{Type: scheduler.TypeDeploy, Environment: "staging", JobID: 13, RunAt: now.Add(time.Minute)}, now)
+	require.NoError(t, err)
+
+	sched.RunDue(context.Background(), now.Add(time.Minute))
+	done, err := sched.Get(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, scheduler.StatusFailed, done.Status)
+	assert.Contains(t, done.Error, `"production"`)
+	assert.Empty(t, client.played)
+}
+
+// ⏰ Расписание, пропущенное больше чем на max_delay, не выполняется
+func TestScheduler_MissedWindow(t *testing.T) {
+	client := newPromotionClient()
+	sched, _ := newTestScheduler(t, client, config.ProjectConfig{}, filepath.Join(t.TempDir(), "schedules.json"))
+
+	now := time.Now()
+	created, err := sched.Create(scheduler.Schedule{Type: scheduler.TypeDeploy, Environment: "staging", JobID: 12, RunAt: now.Add(time.Minute)}, now)
+	require.NoError(t, err)
+
+	sched.RunDue(context.Background(), now.Add(3*time.Hour))
+	missed, err := sched.Get(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, scheduler.StatusMissed, missed.Status)
+	assert.Empty(t, client.played)
+}
+
+// ✅ Продвижение по расписанию попадает в список продвижений
+func TestScheduler_Promotion(t *testing.T) {
+	client := newPromotionClient()
+	project := config.ProjectConfig{PromotionPath: promotionEnvironments}
+	sched, promotions := newTestScheduler(t, client, project, filepath.Join(t.TempDir(), "schedules.json"))
+
+	now := time.Now()
+	_, err := sched.Create(scheduler.Schedule{Type: scheduler.TypePromotion, FromEnv: "dev", ToEnv: "production", RunAt: now.Add(time.Minute)}, now)
+	assert.ErrorContains(t, err, "staging", "через стадии перескакивать нельзя")
+
+	created, err := sched.Create(scheduler.Schedule{Type: scheduler.TypePromotion, FromEnv: "dev", ToEnv: "staging", RunAt: now.Add(time.Minute), CreatedBy: "bob"}, now)
+	require.NoError(t, err)
+
+	sched.RunDue(context.Background(), now.Add(time.Minute))
+	done, err := sched.Get(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, scheduler.StatusTriggered, done.Status)
+	assert.Equal(t, []int{12}, client.played)
+
+	promotion, err := promotions.Get(done.PromotionID)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", promotion.TriggeredBy)
+}
+
+// ⚠️ Запуск, прерванный остановкой сервиса, после перезапуска считается неудачным
+func TestScheduler_InterruptedRunFailsOnRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedules.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"id":"a1","type":"deploy","environment":"staging","job_id":12,"run_at":"2026-01-01T02:00:00Z","status":"running","created_at":"2026-01-01T00:00:00Z","executed_at":"0001-01-01T00:00:00Z"}]`), 0o600))
+
+	store, err := scheduler.OpenStore(path)
+	require.NoError(t, err)
+	schedule, err := store.Get("a1")
+	require.NoError(t, err)
+	assert.Equal(t, scheduler.StatusFailed, schedule.Status)
+	assert.NotEmpty(t, schedule.Error)
+
+	require.NoError(t, os.WriteFile(path, []byte("{broken"), 0o600))
+	_, err = scheduler.OpenStore(path)
+	assert.Error(t, err)
+}
+
+// ✅ API: создание, список, отмена; отменить выполненное расписание нельзя
+func TestScheduleHandler_CreateListCancel(t *testing.T) {
+	client := newPromotionClient()
+	sched, _ := newTestScheduler(t, client, config.ProjectConfig{}, filepath.Join(t.TempDir(), "schedules.json"))
+	h := handler.NewScheduleHandler(sched)
+
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Post("/api/v1/schedules", h.Create)
+	app.Get("/api/v1/schedules", h.List)
+	app.Delete("/api/v1/schedules/:id", h.Cancel)
+
+	post := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/schedules", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+	runAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	assert.Equal(t, http.StatusCreated, post(`{"type":"deploy","environment":"staging","pipeline_id":100,"run_at":"`+runAt+`"}`))
+	assert.Equal(t, http.StatusUnprocessableEntity, post(`{"type":"deploy","environment":"staging","pipeline_id":100,"run_at":"2020-01-01T00:00:00Z"}`))
+	assert.Equal(t, http.StatusUnprocessableEntity, post(`{"type":"deploy","environment":"staging","run_at":"`+runAt+`"}`))
+	assert.Equal(t, http.StatusUnprocessableEntity, post(`{"type":"rollback","run_at":"`+runAt+`"}`))
+
+	schedules := sched.List("")
+	require.Len(t, schedules, 1)
+
+	cancel := func(id string) int {
+		resp, err := app.Test(httptest.NewRequest(http.MethodDelete, "/api/v1/schedules/"+id, nil))
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusOK, cancel(schedules[0].ID))
+	assert.Equal(t, http.StatusConflict, cancel(schedules[0].ID))
+	assert.Equal(t, http.StatusNotFound, cancel("unknown"))
+
+	// Отменённое расписание не выполняется
+	sched.RunDue(context.Background(), time.Now().Add(2*time.Hour))
+	assert.Empty(t, client.played)
+}
+
+// 👤 Расписание выполняется от имени создателя (sudo); в режиме oauth с required создание запрещено
+func TestScheduleHandler_RunAsCreator(t *testing.T) {
+	client := newPromotionClient()
+	sched, _ := newTestScheduler(t, client, config.ProjectConfig{}, filepath.Join(t.TempDir(), "schedules.json"))
+	h := handler.NewScheduleHandler(sched)
+
+	authConfig := config.AuthConfig{UserHeader: "X-Forwarded-User", TrustedProxies: []string{testClientIP}}
+	impersonation := config.ImpersonationConfig{Mode: config.ImpersonationSudo, Users: map[string]string{"ivan@example.com": "ivanov"}}
+
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler})
+	app.Use(auth.Middleware(func() config.AuthConfig { return authConfig }, handler.ErrorHandler))
+	app.Post("/api/v1/schedules",
+		auth.ImpersonateDeferred(func() config.ImpersonationConfig { return impersonation }, handler.ErrorHandler),
+		h.Create)
+
+	post := func() int {
+		runAt := time.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/schedules",
+			strings.NewReader(`{"type":"deploy","environment":"production","pipeline_id":100,"run_at":"`+runAt+`"}`))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		req.Header.Set("X-Forwarded-User", "ivan@example.com")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusCreated, post())
+	schedules := sched.List("")
+	require.Len(t, schedules, 1)
+	assert.Equal(t, "ivanov", schedules[0].RunAs)
+
+	sched.RunDue(context.Background(), time.Now().Add(2*time.Minute))
+	assert.Equal(t, []int{13}, client.played)
+	assert.Equal(t, "ivanov", client.sudo)
+
+	impersonation = config.ImpersonationConfig{Mode: config.ImpersonationOAuth, Required: true}
+	assert.Equal(t, http.StatusForbidden, post())
+	assert.Len(t, sched.List(""), 1)
+}
@@ -0,0 +1,62 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/vkr-mtuci/gitlab-service/config"
+	"github.com/vkr-mtuci/gitlab-service/internal/adapter"
+	"github.com/vkr-mtuci/gitlab-service/internal/service"
+)
+
+// ✅ Тест трейсинга: спаны сервиса и клиента GitLab в одном трейсе, traceparent уходит в GitLab
+func TestTracing_PropagatesToGitLab(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(sdktrace.NewTracerProvider())
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		_, _ = w.Write([]byte(`[{"id": 1, "name": "staging"}]`))
+	}))
+	defer server.Close()
+
+	client := adapter.NewGitLabClient(&config.Config{
+		GitLabBaseURL:   server.URL,
+		GitLabAPIURL:    "/api/v4/projects/",
+		GitLabAPIToken:  "test-token",
+		GitLabProjectID: "42",
+	})
+	svc := service.NewGitLabService(client)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := svc.GetEnvironments(ctx)
+	require.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	clientSpan, serviceSpan := spans[0], spans[1]
+	assert.Equal(t, "GitLabService.GetEnvironments", serviceSpan.Name)
+	assert.Equal(t, "GitLab GET /projects/:project/environments", clientSpan.Name)
+	assert.Equal(t, serviceSpan.SpanContext.SpanID(), clientSpan.Parent.SpanID())
+	assert.Contains(t, clientSpan.Attributes, attribute.String("gitlab.project", "42"))
+
+	assert.Contains(t, traceparent, clientSpan.SpanContext.TraceID().String())
+}